@@ -0,0 +1,98 @@
+// Command generate writes synthetic camt053 statements for load testing and demos.
+//
+// Usage:
+//
+//	go run ./cmd/generate [flags]
+//
+// A document is written per day, named camt053-YYYY-MM-DD.xml, containing a statement per account.
+// The same flags and seed always generate the same documents.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/justfredrik/bank-api/internal/generate"
+)
+
+func main() {
+	defaults := generate.DefaultConfig()
+	out := flag.String("out", "generated", "directory to write the documents to")
+	seed := flag.Uint64("seed", defaults.Seed, "seed of the generated data")
+	accounts := flag.Int("accounts", defaults.Accounts, "number of accounts")
+	from := flag.String("from", defaults.From.String(), "first statement date, YYYY-MM-DD")
+	to := flag.String("to", defaults.To.String(), "last statement date, YYYY-MM-DD")
+	currencies := flag.String("currencies", strings.Join(defaults.Currencies, ","), "comma separated currencies of the accounts, assigned in turn")
+	mix := flag.String("mix", "", "comma separated transaction kinds and weights, e.g. card=10,fee=1 (kinds: "+strings.Join(generate.Kinds(), ", ")+")")
+	transactions := flag.Int("transactions", defaults.TransactionsPerDay, "average number of transactions per account and day")
+	counterparties := flag.Int("counterparties", defaults.Counterparties, "number of counterparties")
+	structured := flag.Float64("structured", defaults.StructuredShare, "share of invoice payments with structured remittance information, 0 to 1")
+	version := flag.String("version", defaults.Version, "camt053 version of the documents, e.g. 001.08")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	config := defaults
+	config.Seed, config.Accounts, config.Version = *seed, *accounts, *version
+	config.TransactionsPerDay, config.Counterparties, config.StructuredShare = *transactions, *counterparties, *structured
+	config.Currencies = strings.Split(*currencies, ",")
+	var err error
+	if config.From, err = camt053.ParseDate(*from); err != nil {
+		exit(err)
+	}
+	if config.To, err = camt053.ParseDate(*to); err != nil {
+		exit(err)
+	}
+	if *mix != "" {
+		if config.Mix, err = generate.ParseMix(*mix); err != nil {
+			exit(err)
+		}
+	}
+
+	generator, err := generate.NewGenerator(config)
+	if err != nil {
+		exit(err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		exit(err)
+	}
+
+	for doc, ok := generator.Next(); ok; doc, ok = generator.Next() {
+		stmts := doc.Statements()
+		path := filepath.Join(*out, "camt053-"+stmts[0].Balances[0].GetDate().String()+".xml")
+		if err := writeFile(path, doc); err != nil {
+			exit(err)
+		}
+		entries := 0
+		for _, stmt := range stmts {
+			if stmt.Entries != nil {
+				entries += len(*stmt.Entries)
+			}
+		}
+		fmt.Printf("%s | statements %d | transactions %d\n", path, len(stmts), entries)
+	}
+}
+
+// writeFile writes a document to a file.
+func writeFile(path string, doc camt053.Document) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := camt053.Write(file, doc); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
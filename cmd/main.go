@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
+
 	// Load env vars before all other packages
-	_ "github.com/justfredrik/bank-api/internal/envloader"
+	_ "github.com/justfredrik/bank-api/internal/envLoader"
 
 	// Internal packages
 	"github.com/justfredrik/bank-api/internal/api"
@@ -10,22 +12,44 @@ import (
 	"github.com/justfredrik/bank-api/internal/db"
 )
 
+// Database the API serves, selected with the DB_BACKEND env variable.
+var store db.IDataBase
+
 func init() {
 
 	// ========================================================
 	// Initialize Local mock DB with camt053 data
 	// ========================================================
-	if err := db.InitializeLocalMockData(); err != nil {
+	if _, err := db.ReconciliationMode(); err != nil {
+		panic(err)
+	}
+	var err error
+	if store, err = db.OpenDataBase(); err != nil {
 		panic(err)
 	}
+	if err := db.InitializeLocalMockData(store, db.Ingestions); err != nil {
+		fmt.Printf("%s [WARNING] Starting without mock data: %s\n", db.DB_LOG_STRING, err)
+	}
 
 	// ========================================================
 	// Initialize mock API Keys for Admin and user for testing
 	// ========================================================
+	if postgres, ok := store.(*db.PostgresData); ok {
+		// Keep the API keys in the same database as the data
+		auth.UseKeyPool(auth.NewPostgresKeyPool(postgres.DB()))
+	}
 	auth.CreateMockKeys()
 }
 
 func main() {
-	router := api.SetUpRouter()
+
+	// ========================================================
+	// Watch the data directory for new statement files
+	// ========================================================
+	if _, err := db.WatchDataDirectory(store, db.Ingestions); err != nil {
+		panic(err)
+	}
+
+	router := api.SetUpRouter(store, db.Ingestions)
 	router.Run()
 }
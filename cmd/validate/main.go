@@ -0,0 +1,100 @@
+// Command validate checks camt053 documents for structural violations without loading them.
+//
+// Usage:
+//
+//	go run ./cmd/validate [-json] file.xml...
+//
+// Every violation is printed with the location of the offending element. The exit status is 1 if any
+// document could not be parsed or has violations.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// fileReport is the JSON output for a single file.
+type fileReport struct {
+	Path       string              `json:"path"`
+	Version    string              `json:"version,omitempty"`
+	Error      string              `json:"error,omitempty"` // Set if the file could not be parsed
+	Violations []camt053.Violation `json:"violations"`
+}
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-json] file.xml...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	reports := make([]fileReport, 0, flag.NArg())
+	valid := true
+	for _, path := range flag.Args() {
+		report := validateFile(path)
+		if report.Error != "" || len(report.Violations) > 0 {
+			valid = false
+		}
+		reports = append(reports, report)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(reports)
+	} else {
+		for _, report := range reports {
+			printReport(report)
+		}
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// validateFile parses and validates a single file.
+func validateFile(path string) fileReport {
+	report := fileReport{Path: path, Violations: make([]camt053.Violation, 0)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	defer file.Close()
+
+	doc, err := camt053.Parse(file)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Version = doc.Version
+	report.Violations = camt053.Validate(doc)
+
+	return report
+}
+
+// printReport prints the result of a file in a human readable format.
+func printReport(report fileReport) {
+	switch {
+	case report.Error != "":
+		fmt.Printf("%s: INVALID, unable to parse: %s\n", report.Path, report.Error)
+	case len(report.Violations) > 0:
+		fmt.Printf("%s: INVALID, %d violations (camt.053.%s)\n", report.Path, len(report.Violations), report.Version)
+		for _, violation := range report.Violations {
+			fmt.Printf("  %s\n", violation)
+		}
+	default:
+		fmt.Printf("%s: OK (camt.053.%s)\n", report.Path, report.Version)
+	}
+}
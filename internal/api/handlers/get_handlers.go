@@ -2,24 +2,32 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/justfredrik/bank-api/internal/camt053"
 	"github.com/justfredrik/bank-api/internal/db"
+	"github.com/justfredrik/bank-api/internal/export"
 )
 
-// validateAccountIdParam makes sure that the accountId is a uint64.
-func validateAccountIdParam(c *gin.Context) (uint64, error) {
-	id, err := strconv.ParseUint(c.Param("accountId"), 10, 64)
-	if err != nil {
+// validateAccountIdParam makes sure that the accountId is a valid account identifier and normalizes it.
+func validateAccountIdParam(c *gin.Context) (string, error) {
+	id := camt053.NormalizeAccountId(c.Param("accountId"))
+	if id == "" {
+		err := errors.New("accountId is empty")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
-			"message": "id is not a positive integer",
+			"message": "id is not a valid account identifier",
 		})
 		c.Abort()
+		return "", err
 	}
-	return id, err
+	return id, nil
 }
 
 // GetPing is a gin Handler that returns a pong resonse to the requester.
@@ -30,14 +38,14 @@ func GetPing(c *gin.Context) {
 }
 
 // GetAccount is a gin Handler that retrieves an account based on the accountId parameter.
-func GetAccount(c *gin.Context) {
+func (h *Handler) GetAccount(c *gin.Context) {
 
 	id, err := validateAccountIdParam(c)
 	if err != nil {
 		return
 	}
 
-	acc, err := db.DB.GetAccount(id)
+	acc, err := h.store.GetAccount(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "account not found"})
 		return
@@ -47,24 +55,67 @@ func GetAccount(c *gin.Context) {
 
 }
 
-// GetAccounts is a gin Handler that returns a list of accounts to the requester.
-func GetAccounts(c *gin.Context) {
-	// No support for pagination but would be good to have if in real prod
+// GetAccounts is a gin Handler that returns a page of accounts to the requester.
+func (h *Handler) GetAccounts(c *gin.Context) {
+	query := db.AccountQuery{}
+	if err := parsePagination(c, &query.Page, &query.PerPage, &query.Cursor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
 
-	accounts, err := db.DB.GetAccounts(0, 0)
+	accounts, err := h.store.GetAccounts(query)
+	if errors.Is(err, db.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Not Found",
-			"message": "unable to find accounts",
-		})
+		internalError(c, err)
 		return
 	}
+	accounts.Links = db.PageLinks{Next: pageLink(c, accounts.NextCursor), Prev: pageLink(c, accounts.PrevCursor)}
 
 	c.JSON(http.StatusOK, accounts)
 }
 
+// parsePagination reads the page, perPage and cursor query parameters of a list request.
+func parsePagination(c *gin.Context, page *int, perPage *int, cursor *string) error {
+	for param, target := range map[string]*int{"page": page, "perPage": perPage} {
+		if rawValue := c.Query(param); rawValue != "" {
+			value, err := strconv.Atoi(rawValue)
+			if err != nil || value < 1 {
+				return errors.New(param + " is not a positive integer")
+			}
+			*target = value
+		}
+	}
+	if *perPage > db.MAX_PER_PAGE {
+		return fmt.Errorf("perPage can be at most %d", db.MAX_PER_PAGE)
+	}
+	if *page > db.MAX_PAGE {
+		return fmt.Errorf("page can be at most %d", db.MAX_PAGE)
+	}
+
+	*cursor = c.Query("cursor")
+	if *cursor != "" && *page != 0 {
+		return errors.New("page and cursor can not be combined")
+	}
+	return nil
+}
+
+// pageLink creates a link to the current request with its page replaced by a cursor.
+// An empty string is returned if there is no cursor.
+func pageLink(c *gin.Context, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	params := c.Request.URL.Query()
+	params.Del("page")
+	params.Set("cursor", cursor)
+	return c.Request.URL.Path + "?" + params.Encode()
+}
+
 // GetTransaction is a gin Handler that returns a specific account transaction to the requester.
-func GetTransaction(c *gin.Context) {
+func (h *Handler) GetTransaction(c *gin.Context) {
 
 	accountId, err := validateAccountIdParam(c)
 	if err != nil {
@@ -73,7 +124,7 @@ func GetTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := db.DB.GetAccountTransaction(accountId, c.Param("transactionRef"))
+	transaction, err := h.store.GetAccountTransaction(accountId, c.Param("transactionRef"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "transaction not found"})
 		return
@@ -83,9 +134,61 @@ func GetTransaction(c *gin.Context) {
 
 }
 
-// GetTransactions returns a list of transactions associated with an account.
-func GetTransactions(c *gin.Context) {
-	// No support for pagination but would be good to have if in real prod
+// parseTransactionQuery converts the query parameters of a /transactions request into a transaction query.
+func parseTransactionQuery(c *gin.Context) (db.TransactionQuery, error) {
+	query := db.TransactionQuery{
+		CreditDebitIndicator: strings.ToUpper(c.Query("creditDebitIndicator")),
+		Status:               strings.ToUpper(c.Query("status")),
+		BankTransactionCode:  c.Query("bankTransactionCode"),
+	}
+
+	// Pagination
+	if err := parsePagination(c, &query.Page, &query.PerPage, &query.Cursor); err != nil {
+		return query, err
+	}
+
+	// Sorting, a leading '-' sorts in descending order
+	query.SortBy = c.Query("sort")
+	if strings.HasPrefix(query.SortBy, "-") {
+		query.SortBy = strings.TrimPrefix(query.SortBy, "-")
+		query.Descending = true
+	}
+
+	// Booking date range
+	if err := parseDateParams(c, map[string]**camt053.Date{"fromDate": &query.FromDate, "toDate": &query.ToDate}); err != nil {
+		return query, err
+	}
+
+	// Amount range
+	for param, target := range map[string]**camt053.Decimal{"minAmount": &query.MinAmount, "maxAmount": &query.MaxAmount} {
+		if rawValue := c.Query(param); rawValue != "" {
+			amount, err := db.ParseAmount(rawValue)
+			if err != nil {
+				return query, errors.New(param + " is not a positive decimal number")
+			}
+			*target = &amount
+		}
+	}
+
+	return query, query.Validate()
+}
+
+// parseDateParams parses the ISO date query parameters that are present into their targets.
+func parseDateParams(c *gin.Context, targets map[string]**camt053.Date) error {
+	for param, target := range targets {
+		if rawValue := c.Query(param); rawValue != "" {
+			date, err := camt053.ParseDate(rawValue)
+			if err != nil {
+				return errors.New("dates must be formatted as YYYY-MM-DD")
+			}
+			*target = &date
+		}
+	}
+	return nil
+}
+
+// GetTransactions returns a page of transactions associated with an account.
+func (h *Handler) GetTransactions(c *gin.Context) {
 
 	accountId, err := validateAccountIdParam(c)
 	if err != nil {
@@ -94,12 +197,328 @@ func GetTransactions(c *gin.Context) {
 		return
 	}
 
-	transactions, err := db.DB.GetAccountTransactions(accountId)
+	query, err := parseTransactionQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+
+	format, err := exportFormat(c)
+	if err != nil {
+		if errors.Is(err, errNotAcceptable) {
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "Not Acceptable", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+	if format != export.FORMAT_JSON {
+		h.exportTransactions(c, accountId, query, format)
+		return
+	}
+
+	transactions, err := h.store.GetAccountTransactions(accountId, query)
+	if errors.Is(err, db.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"})
+		return
+	}
+	if errors.Is(err, db.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "account not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was uanble to fetch the transactions"})
+		internalError(c, err)
 		return
 	}
+	transactions.Links = db.PageLinks{Next: pageLink(c, transactions.NextCursor), Prev: pageLink(c, transactions.PrevCursor)}
 
 	c.JSON(http.StatusOK, transactions)
 
 }
+
+var errNotAcceptable = errors.New("transactions can be returned as application/json, text/csv, application/x-ndjson or application/xml")
+
+// exportFormats are the MIME types transactions can be exported as through the Accept header, in order of preference.
+var exportFormats = []struct{ mime, format string }{
+	{export.MIME_CSV, export.FORMAT_CSV},
+	{export.MIME_NDJSON, export.FORMAT_NDJSON},
+	{gin.MIMEXML, export.FORMAT_XML},
+	{gin.MIMEXML2, export.FORMAT_XML},
+}
+
+// exportFormat selects the format of a /transactions response, the export query parameter takes precedence over the Accept header.
+// JSON is the default, an export format is only selected by the Accept header if the client prefers it to JSON and to
+// every other type it lists, or if it does not accept JSON at all. Browsers, which prefer text/html and accept
+// application/xml with a lower q-value, therefore get JSON.
+func exportFormat(c *gin.Context) (string, error) {
+	if rawFormat := c.Query("export"); rawFormat != "" {
+		return export.ParseFormat(rawFormat)
+	}
+
+	ranges := parseAccept(c.GetHeader("Accept"))
+	if len(ranges) == 0 {
+		return export.FORMAT_JSON, nil
+	}
+	preferred := 0.0
+	for _, r := range ranges {
+		preferred = max(preferred, r.quality)
+	}
+
+	jsonQuality := acceptQuality(ranges, gin.MIMEJSON)
+	format, formatQuality := "", 0.0
+	for _, candidate := range exportFormats {
+		if quality := acceptQuality(ranges, candidate.mime); quality > formatQuality {
+			format, formatQuality = candidate.format, quality
+		}
+	}
+	switch {
+	case formatQuality > jsonQuality && (formatQuality == preferred || jsonQuality == 0):
+		return format, nil
+	case jsonQuality > 0:
+		return export.FORMAT_JSON, nil
+	}
+	return "", errNotAcceptable
+}
+
+// mediaRange is an entry of an Accept header, such as text/* or application/xml;q=0.9.
+type mediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// parseAccept reads the media ranges of an Accept header, entries with a malformed q-value are skipped.
+func parseAccept(header string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, entry := range strings.Split(header, ",") {
+		params := strings.Split(entry, ";")
+		mainType, subType, found := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !found {
+			continue
+		}
+
+		r := mediaRange{mainType: mainType, subType: subType, quality: 1}
+		valid := true
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				valid = err == nil && quality >= 0 && quality <= 1
+				r.quality = quality
+			}
+		}
+		if valid {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// acceptQuality returns the q-value that the most specific matching media range gives a MIME type, 0 if none matches.
+func acceptQuality(ranges []mediaRange, mime string) float64 {
+	mainType, subType, _ := strings.Cut(mime, "/")
+	quality, specificity := 0.0, 0
+	for _, r := range ranges {
+		matched := 0
+		switch {
+		case r.mainType == mainType && r.subType == subType:
+			matched = 3
+		case r.mainType == mainType && r.subType == "*":
+			matched = 2
+		case r.mainType == "*" && r.subType == "*":
+			matched = 1
+		}
+		if matched > specificity {
+			quality, specificity = r.quality, matched
+		}
+	}
+	return quality
+}
+
+// exportTransactions writes every transaction matching the query as CSV, JSON Lines or a camt053 document.
+func (h *Handler) exportTransactions(c *gin.Context, accountId string, query db.TransactionQuery, format string) {
+	columns, err := export.ParseColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+	if c.Query("columns") != "" && format != export.FORMAT_CSV {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "columns can only be selected for csv exports"})
+		return
+	}
+
+	// Read the account and its transactions in one view, so that the balances belong to the same transactions
+	var account camt053.Account
+	var balances []camt053.Balance
+	var transactions []*camt053.Entry
+	err = h.store.View(func(view db.IDataReader) error {
+		found, err := view.GetAccount(accountId)
+		if err != nil {
+			return err
+		}
+		account, balances = found.Account, found.Balances
+		transactions, err = view.ExportAccountTransactions(accountId, query)
+		return err
+	})
+	if errors.Is(err, db.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+
+	filename := "transactions-" + accountId + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	switch format {
+	case export.FORMAT_CSV:
+		c.Header("Content-Type", export.MIME_CSV+"; charset=utf-8")
+		err = export.WriteCSV(c.Writer, transactions, columns)
+	case export.FORMAT_NDJSON:
+		c.Header("Content-Type", export.MIME_NDJSON)
+		err = export.WriteNDJSON(c.Writer, transactions)
+	case export.FORMAT_XML:
+		c.Header("Content-Type", gin.MIMEXML+"; charset=utf-8")
+		doc := export.Statement(account, balances, transactions, query.FromDate, query.ToDate, query.FiltersEntries(), time.Now())
+		err = export.WriteCamt053(c.Writer, doc)
+	}
+	if err != nil {
+		// The response has already been started, so the error can only be logged and the rest of the chain skipped
+		fmt.Printf("%s Failed to export transactions of %s: %s\n", API_LOG_STRING, accountId, err)
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// parseBalanceQuery converts the query parameters of a /balances request into a balance query.
+func parseBalanceQuery(c *gin.Context) (db.BalanceQuery, error) {
+	query := db.BalanceQuery{}
+	if err := parseDateParams(c, map[string]**camt053.Date{"fromDate": &query.FromDate, "toDate": &query.ToDate, "asOf": &query.AsOf}); err != nil {
+		return query, err
+	}
+
+	// Types can be repeated or comma separated, e.g. type=OPBD,CLBD
+	for _, types := range c.QueryArray("type") {
+		for _, balanceType := range strings.Split(types, ",") {
+			if balanceType = strings.TrimSpace(balanceType); balanceType != "" {
+				query.Types = append(query.Types, strings.ToUpper(balanceType))
+			}
+		}
+	}
+
+	// A single date is a range of one day
+	if c.Query("date") != "" {
+		if query.FromDate != nil || query.ToDate != nil {
+			return query, errors.New("date can not be combined with fromDate or toDate")
+		}
+		var date *camt053.Date
+		if err := parseDateParams(c, map[string]**camt053.Date{"date": &date}); err != nil {
+			return query, err
+		}
+		query.FromDate, query.ToDate = date, date
+	}
+
+	return query, query.Validate()
+}
+
+// GetBalances returns the balances of an account, optionally filtered by type and date.
+func (h *Handler) GetBalances(c *gin.Context) {
+
+	accountId, err := validateAccountIdParam(c)
+	if err != nil {
+		// This should technically be unreachable since this has already been validated in the AUTH step.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was uanble to validate the accountId"})
+		return
+	}
+
+	query, err := parseBalanceQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+
+	balances, err := h.store.GetAccountBalances(accountId, query)
+	if errors.Is(err, db.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "account not found"})
+		return
+	}
+	if errors.Is(err, db.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"})
+		return
+	}
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+
+}
+
+// GetStatements returns a list of statements loaded into an account.
+func (h *Handler) GetStatements(c *gin.Context) {
+
+	accountId, err := validateAccountIdParam(c)
+	if err != nil {
+		// This should technically be unreachable since this has already been validated in the AUTH step.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was uanble to validate the accountId"})
+		return
+	}
+
+	statements, err := h.store.GetAccountStatements(accountId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statements)
+
+}
+
+// GetStatement is a gin Handler that returns the metadata of a specific account statement to the requester.
+func (h *Handler) GetStatement(c *gin.Context) {
+
+	accountId, err := validateAccountIdParam(c)
+	if err != nil {
+		// This should technically be unreachable since this has already been validated in the AUTH step.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was uanble to validate the accountId"})
+		return
+	}
+
+	statement, err := h.store.GetAccountStatement(accountId, c.Param("statementId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "statement not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, *statement)
+
+}
+
+// GetReconciliation is a gin Handler that returns the reconciliation of a specific account statement to the requester.
+func (h *Handler) GetReconciliation(c *gin.Context) {
+
+	accountId, err := validateAccountIdParam(c)
+	if err != nil {
+		// This should technically be unreachable since this has already been validated in the AUTH step.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was uanble to validate the accountId"})
+		return
+	}
+
+	reconciliation, err := h.store.GetStatementReconciliation(accountId, c.Param("statementId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found", "message": "statement not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, *reconciliation)
+
+}
+
+// GetIngestions is a gin Handler that returns the log of ingested statement files to the requester.
+func (h *Handler) GetIngestions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ingestions.List())
+}
@@ -0,0 +1,30 @@
+// package handlers provides handler functions linking the endpoints in the router to other internal systems.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justfredrik/bank-api/internal/db"
+)
+
+const API_LOG_STRING = "[API]"
+
+// Handler provides the gin Handlers of the endpoints that use the database, which is injected
+// so that the API can run against any storage backend and tests against their own database.
+type Handler struct {
+	store      db.IDataBase
+	ingestions *db.IngestionLog
+}
+
+// NewHandler creates the handlers for a database and the log that statement uploads are recorded in.
+func NewHandler(store db.IDataBase, ingestions *db.IngestionLog) *Handler {
+	return &Handler{store: store, ingestions: ingestions}
+}
+
+// internalError logs an error of the database and responds with 500 Internal Server Error, without the details of the error.
+func internalError(c *gin.Context, err error) {
+	fmt.Printf("%s %s %s failed: %s\n", API_LOG_STRING, c.Request.Method, c.Request.URL.Path, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error", "message": "the server was unable to read the database"})
+}
@@ -0,0 +1,161 @@
+// package handlers provides handler functions linking the endpoints in the router to other internal systems.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justfredrik/bank-api/internal/bai2"
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/justfredrik/bank-api/internal/db"
+	"github.com/justfredrik/bank-api/internal/mt940"
+)
+
+// Largest camt053 document that can be uploaded.
+const MAX_UPLOAD_SIZE = 32 << 20 // 32 MiB
+
+// Name of the multipart form field containing the uploaded camt053 document.
+const UPLOAD_FORM_FIELD = "file"
+
+// openUpload returns a reader for the uploaded camt053 document and a name describing where it came from.
+// Documents can be sent as the raw request body or as a file in a multipart form.
+func openUpload(c *gin.Context) (io.ReadCloser, string, error) {
+	contentType := c.ContentType()
+
+	switch {
+	case contentType == "multipart/form-data":
+		fileHeader, err := c.FormFile(UPLOAD_FORM_FIELD)
+		if err != nil {
+			if isTooLarge(err) {
+				return nil, "", err
+			}
+			return nil, "", errors.New("multipart form is missing the '" + UPLOAD_FORM_FIELD + "' file")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, "upload:" + fileHeader.Filename, nil
+
+	case contentType == "application/xml" || contentType == "text/xml" || contentType == "text/plain" || contentType == "":
+		return c.Request.Body, "upload:request-body", nil
+
+	default:
+		return nil, "", errUnsupportedMediaType
+	}
+}
+
+var errUnsupportedMediaType = errors.New("statements must be sent as application/xml, text/xml, text/plain or multipart/form-data")
+
+// isTooLarge checks if an error was caused by the request body exceeding MAX_UPLOAD_SIZE.
+func isTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// respondTooLarge responds with a 413 error.
+func respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request Entity Too Large", "message": "statement documents can be at most 32 MiB"})
+}
+
+// PostStatements is a gin Handler that parses an uploaded camt053 document and loads it into the database.
+func (h *Handler) PostStatements(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MAX_UPLOAD_SIZE)
+
+	upload, name, err := openUpload(c)
+	if err != nil {
+		if isTooLarge(err) {
+			respondTooLarge(c)
+			return
+		}
+		if errors.Is(err, errUnsupportedMediaType) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported Media Type", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": err.Error()})
+		return
+	}
+	defer upload.Close()
+
+	result := db.ImportResult{Path: name, ImportedAt: time.Now(), ModTime: time.Now()}
+
+	data, err := db.ParseDocument(upload)
+	if err != nil {
+		result.Err = err
+		h.ingestions.Record(result)
+
+		if isTooLarge(err) {
+			respondTooLarge(c)
+			return
+		}
+
+		var parseErr *camt053.ParseError
+		if errors.As(err, &parseErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "unable to parse camt053 document: " + parseErr.Err.Error(),
+				"element": parseErr.Element,
+				"line":    parseErr.Line,
+				"column":  parseErr.Column,
+			})
+			return
+		}
+
+		var mt940Err *mt940.ParseError
+		if errors.As(err, &mt940Err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "unable to parse MT940 document: " + mt940Err.Err.Error(),
+				"field":   mt940Err.Field,
+				"line":    mt940Err.Line,
+			})
+			return
+		}
+
+		var bai2Err *bai2.ParseError
+		if errors.As(err, &bai2Err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "unable to parse BAI2 file: " + bai2Err.Err.Error(),
+				"record":  bai2Err.Record,
+				"line":    bai2Err.Line,
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "unable to read camt053 document: " + err.Error()})
+		return
+	}
+
+	summary, err := h.store.LoadCamt053(data)
+	if err != nil {
+		result.Err = err
+		h.ingestions.Record(result)
+
+		var validationErr *camt053.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "Bad Request",
+				"message":    "camt053 document is invalid",
+				"violations": validationErr.Violations,
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request", "message": "unable to load camt053 document: " + err.Error()})
+		return
+	}
+
+	result.Statements, result.Transactions = summary.Counts()
+	result.Warnings = summary.Warnings
+	h.ingestions.Record(result)
+	for _, warning := range summary.Warnings {
+		fmt.Printf("%s WARNING %s | %s\n", API_LOG_STRING, name, warning)
+	}
+
+	c.JSON(http.StatusCreated, summary)
+}
@@ -5,11 +5,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/justfredrik/bank-api/internal/api/handlers"
 	"github.com/justfredrik/bank-api/internal/auth"
+	"github.com/justfredrik/bank-api/internal/db"
 )
 
-// SetUpRouter sets up the main router of the API service.
-func SetUpRouter() *gin.Engine {
-	router := gin.Default() // Default router uses middlewares Logger and Recovery
+// SetUpRouter sets up the main router of the API service, serving the data in store.
+// Uploaded statements are loaded into store and recorded in the ingestion log.
+func SetUpRouter(store db.IDataBase, ingestions *db.IngestionLog) *gin.Engine {
+	router := gin.Default()  // Default router uses middlewares Logger and Recovery
+	router.UseRawPath = true // Route on the escaped path, so that ids can contain an escaped '/', e.g. /accounts/ACC%2F1
+	h := handlers.NewHandler(store, ingestions)
 
 	// ====================================================================================
 	{ // Declare Routes
@@ -18,15 +22,25 @@ func SetUpRouter() *gin.Engine {
 		router.GET("/ping", auth.Authenticator(auth.ROLE_ANY), handlers.GetPing)
 
 		// Only Admin can list all accounts
-		router.GET("/accounts", auth.Authenticator(auth.ROLE_ADMIN), handlers.GetAccounts)
+		router.GET("/accounts", auth.Authenticator(auth.ROLE_ADMIN), h.GetAccounts)
+
+		// Only Admin can upload statements
+		router.POST("/statements", auth.Authenticator(auth.ROLE_ADMIN), h.PostStatements)
+
+		// Only Admin can see which statement files have been ingested
+		router.GET("/ingestions", auth.Authenticator(auth.ROLE_ADMIN), h.GetIngestions)
 
 		// Endpoints that Require Account AUTH or admin AUTH
 		accountAuthGroup := router.Group("/accounts")
 		accountAuthGroup.Use(auth.Authenticator(auth.ROLE_ACCOUNT))
 		{ // Routes
-			accountAuthGroup.GET("/:accountId", handlers.GetAccount)
-			accountAuthGroup.GET("/:accountId/transactions", handlers.GetTransactions)
-			accountAuthGroup.GET("/:accountId/transactions/:transactionRef", handlers.GetTransaction)
+			accountAuthGroup.GET("/:accountId", h.GetAccount)
+			accountAuthGroup.GET("/:accountId/balances", h.GetBalances)
+			accountAuthGroup.GET("/:accountId/transactions", h.GetTransactions)
+			accountAuthGroup.GET("/:accountId/transactions/:transactionRef", h.GetTransaction)
+			accountAuthGroup.GET("/:accountId/statements", h.GetStatements)
+			accountAuthGroup.GET("/:accountId/statements/:statementId", h.GetStatement)
+			accountAuthGroup.GET("/:accountId/statements/:statementId/reconciliation", h.GetReconciliation)
 		}
 	}
 	return router
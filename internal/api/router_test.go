@@ -1,22 +1,44 @@
 package api
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/justfredrik/bank-api/internal/auth"
+	"github.com/justfredrik/bank-api/internal/camt053"
 	"github.com/justfredrik/bank-api/internal/db"
 	"github.com/stretchr/testify/assert"
 )
 
+// setUpTestRouter sets up a router serving its own database loaded with the mock data, so that tests do not share state.
 func setUpTestRouter() *gin.Engine {
+	return SetUpRouter(newTestStore())
+}
+
+// newTestStore creates a database and an ingestion log and loads the mock data into them.
+func newTestStore() (*db.BankData, *db.IngestionLog) {
 	gin.SetMode(gin.TestMode)
-	return SetUpRouter()
+	if !isSetup {
+		setup()
+	}
+	store, ingestions := db.NewBankData(), &db.IngestionLog{}
+	if err := db.InitializeLocalMockData(&store, ingestions); err != nil {
+		panic(errors.New("Test Setup Failed. failed to load mock DB with error:" + err.Error()))
+	}
+	return &store, ingestions
 }
 
 func jsonContains(jsonBytes []byte, requiredData map[string]string) (bool, error) {
@@ -45,13 +67,14 @@ type TestRequest struct {
 	requestType  string
 	endpoint     string
 	headers      map[string]string
+	body         string
 	expectedCode int
 	expectedBody map[string]string
 }
 
 type errorResponse struct {
-	message string `json: "message"`
-	error   int    `json: "error"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
 }
 
 type PongResponse struct {
@@ -62,9 +85,6 @@ func setup() {
 	if err := godotenv.Load("../../.env"); err != nil {
 		panic(errors.New("Test Setup Failed. failed to load .env variables with error:" + err.Error()))
 	}
-	if err2 := db.InitializeLocalMockData(); err2 != nil {
-		panic(errors.New("Test Setup Failed. failed to load mock DB with error:" + err2.Error()))
-	}
 	isSetup = true
 }
 
@@ -80,7 +100,7 @@ func testReqests(t *testing.T, router *gin.Engine, tests []TestRequest) {
 		t.Run(test.testName, func(t *testing.T) {
 
 			// Create Request
-			req, _ := http.NewRequest(test.requestType, test.endpoint, nil)
+			req, _ := http.NewRequest(test.requestType, test.endpoint, strings.NewReader(test.body))
 
 			// Populate Headers
 			for key, value := range test.headers {
@@ -105,7 +125,7 @@ func testReqests(t *testing.T, router *gin.Engine, tests []TestRequest) {
 // TestAccounts tests GET requests to the /ping endpoint.
 func TestPing(t *testing.T) {
 
-	apiKey := auth.NewAPIKey(auth.ROLE_ACCOUNT, 1337)
+	apiKey := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337")
 	token := apiKey.Token()
 
 	tests := []TestRequest{
@@ -142,8 +162,8 @@ func TestPing(t *testing.T) {
 // This endpoint returns a list of accounts.
 func TestAccounts(t *testing.T) {
 
-	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, 1337).Token()
-	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 54400001111).Token()
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
 
 	getTests := []TestRequest{
 		{
@@ -154,6 +174,22 @@ func TestAccounts(t *testing.T) {
 			expectedCode: http.StatusOK,
 			expectedBody: map[string]string{"accounts": ""},
 		},
+		{
+			testName:     "Paginated",
+			requestType:  "GET",
+			endpoint:     "/accounts?page=1&perPage=1",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"accounts": "", "totalCount": "", "page": "", "perPage": "", "links": ""},
+		},
+		{
+			testName:     "Invalid cursor",
+			requestType:  "GET",
+			endpoint:     "/accounts?cursor=abc.def",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"},
+		},
 		{
 			testName:     "Unauthorized API Key",
 			requestType:  "GET",
@@ -187,9 +223,9 @@ func TestAccounts(t *testing.T) {
 // This endpoint returns a specific account.
 func TestAccountsAccountId(t *testing.T) {
 
-	adminToken := (auth.NewAPIKey(auth.ROLE_ADMIN, 1337)).Token()
-	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 54400001111).Token()
-	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 1337).Token()
+	adminToken := (auth.NewAPIKey(auth.ROLE_ADMIN, "1337")).Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337").Token()
 
 	getTests := []TestRequest{
 		{
@@ -216,24 +252,69 @@ func TestAccountsAccountId(t *testing.T) {
 			expectedCode: http.StatusUnauthorized,
 			expectedBody: map[string]string{"error": "Unauthorized", "message": "Your API key is not authorized to access the requested resource"},
 		},
+		{
+			testName:     "Grouped accountId",
+			requestType:  "GET",
+			endpoint:     "/accounts/5440%200001%20111",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"account": "", "balances": ""},
+		},
+		{
+			testName:     "Invalid accountId",
+			requestType:  "GET",
+			endpoint:     "/accounts/SE4550000000058398257466SE45500000000",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: map[string]string{"error": "Unauthorized", "message": "unable to validate auth token"},
+		},
+		{
+			testName:     "Non existant account",
+			requestType:  "GET",
+			endpoint:     "/accounts/DD01100056868",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "account not found"},
+		},
 	}
 
 	testReqests(t, setUpTestRouter(), getTests)
 }
 
+// TestAccountsOtherId checks that accounts with an 'Othr' id of any Max34Text characters can be fetched with the id URL escaped.
+func TestAccountsOtherId(t *testing.T) {
+	store, ingestions := newTestStore()
+	router := SetUpRouter(store, ingestions)
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+
+	accountId := "KONTO:1234/56;7 Ö"
+	_, err := store.CreateAccount(&camt053.Account{Id: camt053.AccountId{Other: &camt053.OtherId{Id: accountId}}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, _ := http.NewRequest("GET", "/accounts/"+url.PathEscape(accountId), nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "KONTO:1234/56;7")
+}
+
 // TestAccountTransactions tests GET requests to the /accounts/:accountId/transactions endpoint.
 // This endpoint returns a list of account transactions.
 func TestAccountTransactions(t *testing.T) {
 
-	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, 1337).Token()
-	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 54400001111).Token()
-	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 1337).Token()
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337").Token()
 
 	expectedOKBody := map[string]string{
 		"transactions": "",
 		"totalCount":   "",
 		"page":         "",
 		"perPage":      "",
+		"totalPages":   "",
 	}
 
 	expectedUnauthorizedBody := map[string]string{
@@ -266,18 +347,199 @@ func TestAccountTransactions(t *testing.T) {
 			expectedCode: http.StatusUnauthorized,
 			expectedBody: expectedUnauthorizedBody,
 		},
+		{
+			testName:     "Paginated, sorted and filtered",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?page=2&perPage=2&sort=-amount&creditDebitIndicator=crdt&status=BOOK&fromDate=2018-12-01&toDate=2018-12-31&minAmount=100&maxAmount=500000&bankTransactionCode=PMNT",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: expectedOKBody,
+		},
+		{
+			testName:     "Invalid page",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?perPage=0",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "perPage is not a positive integer"},
+		},
+		{
+			testName:     "Page that would overflow",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?page=" + strconv.Itoa(math.MaxInt),
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "page can be at most " + strconv.Itoa(db.MAX_PAGE)},
+		},
+		{
+			testName:     "Invalid sort",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?sort=-reference",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "transactions can only be sorted by bookingDate, valueDate or amount"},
+		},
+		{
+			testName:     "Invalid amount",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?minAmount=1e5",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "minAmount is not a positive decimal number"},
+		},
+		{
+			testName:     "Invalid cursor",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?cursor=abc.def",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"},
+		},
+		{
+			testName:     "Page and cursor",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/transactions?page=2&cursor=abc.def",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "page and cursor can not be combined"},
+		},
+		{
+			testName:     "Non existant account",
+			requestType:  "GET",
+			endpoint:     "/accounts/1337/transactions",
+			headers:      map[string]string{"Authorization": "Bearer " + randomToken},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "account not found"},
+		},
 	}
 	testReqests(t, setUpTestRouter(), getTests)
 
 }
 
+// TestAccountBalances tests GET requests to the /accounts/:accountId/balances endpoint.
+// This endpoint returns the balances of an account.
+func TestAccountBalances(t *testing.T) {
+
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337").Token()
+
+	expectedOKBody := map[string]string{
+		"balances":   "",
+		"totalCount": "",
+	}
+
+	getTests := []TestRequest{
+		{
+			testName:     "Valid API Key (Account Owner)",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: expectedOKBody,
+		},
+		{
+			testName:     "Filtered by type and date",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances?type=OPBD,clbd&type=CLAV&date=2018-12-17",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: expectedOKBody,
+		},
+		{
+			testName:     "As of",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances?asOf=2018-12-31",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: expectedOKBody,
+		},
+		{
+			testName:     "Date and date range",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances?date=2018-12-17&fromDate=2018-12-01",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "date can not be combined with fromDate or toDate"},
+		},
+		{
+			testName:     "As of and date range",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances?asOf=2018-12-17&toDate=2018-12-31",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "asOf can not be combined with a date range"},
+		},
+		{
+			testName:     "Invalid date",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances?asOf=yesterday",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "dates must be formatted as YYYY-MM-DD"},
+		},
+		{
+			testName:     "Unauthorized API Key",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/balances",
+			headers:      map[string]string{"Authorization": "Bearer " + randomToken},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: map[string]string{"error": "Unauthorized", "message": "Your API key is not authorized to access the requested resource"},
+		},
+		{
+			testName:     "Non existant account",
+			requestType:  "GET",
+			endpoint:     "/accounts/1337/balances",
+			headers:      map[string]string{"Authorization": "Bearer " + randomToken},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "account not found"},
+		},
+	}
+	testReqests(t, setUpTestRouter(), getTests)
+
+}
+
+// TestAccountTransactionsLinks follows the next links of the /accounts/:accountId/transactions endpoint
+// and checks that every transaction is returned exactly once.
+func TestAccountTransactionsLinks(t *testing.T) {
+	store, ingestions := newTestStore()
+	router := SetUpRouter(store, ingestions)
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+
+	seen := make(map[string]bool)
+	link := "/accounts/54400001111/transactions?perPage=2&sort=-amount"
+	for link != "" {
+		req, _ := http.NewRequest("GET", link, nil)
+		req.Header.Set("Authorization", "Bearer "+accountToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "Incorrect Response Status")
+
+		var response db.TransactionsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("unable to parse response: %s", err)
+		}
+		for _, transaction := range response.Transactions {
+			assert.False(t, seen[*transaction.URLReference], "transaction on more than one page")
+			seen[*transaction.URLReference] = true
+		}
+		assert.LessOrEqual(t, len(seen), response.TotalCount)
+		if response.Links.Next != "" {
+			assert.Contains(t, response.Links.Next, "sort=-amount")
+		}
+		link = response.Links.Next
+	}
+
+	response, _ := store.GetAccountTransactions("54400001111", db.TransactionQuery{})
+	assert.Len(t, seen, response.TotalCount)
+}
+
 // TestAccountTransaction tests GET requests for the /accounts/:accountId/transactions/:transactionRef endpoint.
 // This endpoint returns a specific account transaction.
 func TestAccountTransaction(t *testing.T) {
 
-	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, 1337).Token()
-	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 54400001111).Token()
-	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, 1337).Token()
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337").Token()
 
 	expectedOKBody := map[string]string{
 		"reference":            "",
@@ -346,3 +608,546 @@ func TestAccountTransaction(t *testing.T) {
 	testReqests(t, setUpTestRouter(), getTests)
 
 }
+
+// TestAccountStatements tests GET requests for the /accounts/:accountId/statements endpoints.
+// These endpoints return the metadata of the statements loaded into an account.
+func TestAccountStatements(t *testing.T) {
+
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	randomToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "1337").Token()
+
+	getTests := []TestRequest{
+		{
+			testName:     "List statements",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"statements": "", "totalCount": ""},
+		},
+		{
+			testName:     "Get statement",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements/STOIID65181218000000000007",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"id": "STOIID65181218000000000007", "balances": "", "transactionRefs": ""},
+		},
+		{
+			testName:     "Get statement reconciliation",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements/STOIID65181218000000000007/reconciliation",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"statementId": "STOIID65181218000000000007", "status": "OK", "checks": ""},
+		},
+		{
+			testName:     "Non existant statement reconciliation",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements/NON-EXISTANT-STATEMENT/reconciliation",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "statement not found"},
+		},
+		{
+			testName:     "Non existant statement",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements/NON-EXISTANT-STATEMENT",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "statement not found"},
+		},
+		{
+			testName:     "Unauthorized API Key",
+			requestType:  "GET",
+			endpoint:     "/accounts/54400001111/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + randomToken},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: map[string]string{"error": "Unauthorized", "message": "Your API key is not authorized to access the requested resource"},
+		},
+	}
+	testReqests(t, setUpTestRouter(), getTests)
+
+}
+
+// TestIngestions tests GET requests to the /ingestions endpoint.
+// This endpoint returns the log of ingested statement files.
+func TestIngestions(t *testing.T) {
+
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+
+	getTests := []TestRequest{
+		{
+			testName:     "Valid API Key",
+			requestType:  "GET",
+			endpoint:     "/ingestions",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]string{"ingestions": "", "totalCount": ""},
+		},
+		{
+			testName:     "Unauthorized API Key",
+			requestType:  "GET",
+			endpoint:     "/ingestions",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: map[string]string{"error": "Unauthorized", "message": "Your API key is not authorized to access the requested resource"},
+		},
+	}
+	testReqests(t, setUpTestRouter(), getTests)
+
+}
+
+// TestPostStatements tests POST requests to the /statements endpoint.
+// This endpoint uploads camt053 documents.
+func TestPostStatements(t *testing.T) {
+
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+
+	sample, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	newAccount := strings.ReplaceAll(string(sample), "54400001111", "54400002222")
+	brokenSequenceNumber := strings.Replace(string(sample), "<ElctrncSeqNb>244</ElctrncSeqNb>", "<ElctrncSeqNb>24A</ElctrncSeqNb>", 1)
+
+	postTests := []TestRequest{
+		{
+			testName:     "Upload new account",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "application/xml"},
+			body:         newAccount,
+			expectedCode: http.StatusCreated,
+			expectedBody: map[string]string{"accountsCreated": "", "statementsLoaded": "", "entriesAdded": "", "duplicatesSkipped": ""},
+		},
+		{
+			testName:     "Upload duplicate statement",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "text/xml"},
+			body:         newAccount,
+			expectedCode: http.StatusCreated,
+			expectedBody: map[string]string{"duplicatesSkipped": ""},
+		},
+		{
+			testName:     "Malformed value",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "application/xml"},
+			body:         brokenSequenceNumber,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "element": "/Document/BkToCstmrStmt/Stmt/ElctrncSeqNb", "line": "", "column": ""},
+		},
+		{
+			testName:     "Malformed XML",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "application/xml"},
+			body:         "<Document><BkToCstmrStmt></Document>",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "element": "/Document/BkToCstmrStmt"},
+		},
+		{
+			testName:     "Document without statements",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "application/xml"},
+			body:         "<Document><BkToCstmrStmt></BkToCstmrStmt></Document>",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "camt053 document is invalid", "violations": ""},
+		},
+		{
+			testName:     "Unsupported media type",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + adminToken, "Content-Type": "application/json"},
+			body:         "{}",
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedBody: map[string]string{"error": "Unsupported Media Type"},
+		},
+		{
+			testName:     "Unauthorized API Key",
+			requestType:  "POST",
+			endpoint:     "/statements",
+			headers:      map[string]string{"Authorization": "Bearer " + accountToken, "Content-Type": "application/xml"},
+			body:         newAccount,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: map[string]string{"error": "Unauthorized", "message": "Your API key is not authorized to access the requested resource"},
+		},
+	}
+	testReqests(t, setUpTestRouter(), postTests)
+
+}
+
+// TestPostStatementsMultipart tests uploading a camt053 document as a multipart form file.
+func TestPostStatementsMultipart(t *testing.T) {
+
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	if !isSetup {
+		setup()
+	}
+
+	sample, err := os.ReadFile("../../data/goldman_sachs_camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	file, _ := form.CreateFormFile("file", "goldman_sachs_camt053.xml")
+	file.Write(sample)
+	form.Close()
+
+	req, _ := http.NewRequest("POST", "/statements", body)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	setUpTestRouter().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var summary db.LoadSummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary.StatementsLoaded)
+	assert.Equal(t, 15, summary.EntriesAdded+summary.DuplicatesSkipped)
+}
+
+// TestPostIntradayReport tests uploading a camt052 intraday report and settling its entries with a camt053 statement.
+func TestPostIntradayReport(t *testing.T) {
+
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	router := setUpTestRouter()
+
+	sample, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	statement := strings.ReplaceAll(string(sample), "54400001111", "54400003333")
+	report := strings.NewReplacer(
+		"camt.053", "camt.052",
+		"BkToCstmrStmt", "BkToCstmrAcctRpt",
+		"<Stmt>", "<Rpt>",
+		"</Stmt>", "</Rpt>",
+	).Replace(statement)
+
+	post := func(body string) db.LoadSummary {
+		req, _ := http.NewRequest("POST", "/statements", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/xml")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var summary db.LoadSummary
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		return summary
+	}
+	transactions := func() []*camt053.Entry {
+		req, _ := http.NewRequest("GET", "/accounts/54400003333/transactions", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response db.TransactionsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Transactions
+	}
+
+	// The report adds intraday entries
+	summary := post(report)
+	assert.Equal(t, 7, summary.EntriesAdded)
+	assert.Len(t, transactions(), 7)
+	for _, entry := range transactions() {
+		assert.True(t, entry.Intraday)
+	}
+
+	// The statement settles them
+	summary = post(statement)
+	assert.Equal(t, 0, summary.EntriesAdded)
+	assert.Equal(t, 7, summary.EntriesSettled)
+	for _, entry := range transactions() {
+		assert.False(t, entry.Intraday)
+	}
+
+	// Reports received after the statement do not replace booked entries
+	summary = post(report)
+	assert.Equal(t, 7, summary.DuplicatesSkipped)
+	assert.Equal(t, 0, summary.EntriesUpdated)
+}
+
+// TestPostMT940 tests uploading MT940 statements as plain text and that parse errors point at the offending field.
+func TestPostMT940(t *testing.T) {
+
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	router := setUpTestRouter()
+
+	statement := strings.Join([]string{
+		":20:STMT181217",
+		":25:ESSESESS/SE4550000000058398257466",
+		":28C:00244/001",
+		":60F:C181216SEK1000,00",
+		":61:1812171217D250,NTRFINV-5419//STOI5201",
+		":86:Invoice 5419",
+		":62F:C181217SEK750,00",
+		"-",
+	}, "\r\n")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/statements", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(statement)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var summary db.LoadSummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary.StatementsLoaded)
+	assert.Equal(t, 1, summary.EntriesAdded)
+
+	req, _ := http.NewRequest("GET", "/accounts/SE4550000000058398257466/transactions", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response db.TransactionsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Transactions, 1) {
+		assert.Equal(t, "INV-5419", *response.Transactions[0].Reference)
+		assert.Equal(t, "DBIT", response.Transactions[0].CreditDebitIndicator)
+	}
+
+	// The statement can be fetched by the id it is listed with
+	req, _ = http.NewRequest("GET", "/accounts/SE4550000000058398257466/statements", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var statements db.StatementsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &statements))
+	if assert.Len(t, statements.Statements, 1) {
+		statementId := statements.Statements[0].Id
+		for _, endpoint := range []string{"/statements/" + statementId, "/statements/" + statementId + "/reconciliation"} {
+			req, _ = http.NewRequest("GET", "/accounts/SE4550000000058398257466"+endpoint, nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, endpoint)
+		}
+	}
+
+	// Invalid fields are reported with their tag and line
+	w = post(strings.Replace(statement, ":60F:C181216SEK1000,00", ":60F:C181216SEK", 1))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResponse map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, "60F", errResponse["field"])
+	assert.Equal(t, float64(4), errResponse["line"])
+}
+
+// TestAccountTransactionsExport tests exporting transactions as CSV, JSON Lines and camt053 XML.
+func TestAccountTransactionsExport(t *testing.T) {
+
+	accountToken := auth.NewAPIKey(auth.ROLE_ACCOUNT, "54400001111").Token()
+	router := setUpTestRouter()
+
+	get := func(endpoint string, accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", endpoint, nil)
+		req.Header.Set("Authorization", "Bearer "+accountToken)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The JSON response tells how many transactions match the filter
+	w := get("/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response db.TransactionsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	debits := response.TotalCount
+	assert.Greater(t, debits, 0)
+
+	// Declare Tests
+	tests := []struct {
+		name                string
+		endpoint            string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"CSV by Accept header", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "text/csv", http.StatusOK, "text/csv; charset=utf-8"},
+		{"CSV by export param", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT&export=csv&columns=reference,signedAmount", "", http.StatusOK, "text/csv; charset=utf-8"},
+		{"Export param takes precedence", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT&export=ndjson", "text/csv", http.StatusOK, "application/x-ndjson"},
+		{"NDJSON", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "application/x-ndjson", http.StatusOK, "application/x-ndjson"},
+		{"camt053 XML", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "application/xml", http.StatusOK, "application/xml; charset=utf-8"},
+		{"JSON", "/accounts/54400001111/transactions", "application/json", http.StatusOK, "application/json; charset=utf-8"},
+		{"JSON for a browser", "/accounts/54400001111/transactions", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusOK, "application/json; charset=utf-8"},
+		{"JSON for any type", "/accounts/54400001111/transactions", "*/*", http.StatusOK, "application/json; charset=utf-8"},
+		{"JSON on a tie", "/accounts/54400001111/transactions", "text/csv, application/json", http.StatusOK, "application/json; charset=utf-8"},
+		{"CSV preferred to JSON", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "application/json;q=0.5, text/csv", http.StatusOK, "text/csv; charset=utf-8"},
+		{"JSON preferred to CSV", "/accounts/54400001111/transactions", "text/csv;q=0.5, application/json", http.StatusOK, "application/json; charset=utf-8"},
+		{"CSV when JSON is not accepted", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "text/html, text/csv;q=0.9", http.StatusOK, "text/csv; charset=utf-8"},
+		{"JSON excluded", "/accounts/54400001111/transactions?creditDebitIndicator=DBIT", "application/json;q=0, */*", http.StatusOK, "text/csv; charset=utf-8"},
+		{"Not acceptable", "/accounts/54400001111/transactions", "image/png", http.StatusNotAcceptable, "application/json; charset=utf-8"},
+		{"Nothing acceptable", "/accounts/54400001111/transactions", "application/json;q=0", http.StatusNotAcceptable, "application/json; charset=utf-8"},
+		{"Unknown export format", "/accounts/54400001111/transactions?export=xlsx", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"Unknown column", "/accounts/54400001111/transactions?export=csv&columns=balance", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"Columns without CSV", "/accounts/54400001111/transactions?export=ndjson&columns=reference", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"Paginated export", "/accounts/54400001111/transactions?export=csv&page=2", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := get(test.endpoint, test.accept)
+			assert.Equal(t, test.expectedCode, w.Code, w.Body.String())
+			assert.Equal(t, test.expectedContentType, w.Header().Get("Content-Type"))
+		})
+	}
+
+	// Exports contain every matching transaction
+	rows, err := csv.NewReader(get("/accounts/54400001111/transactions?creditDebitIndicator=DBIT&columns=reference,signedAmount", "text/csv").Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, debits+1)
+	assert.Equal(t, []string{"reference", "signedAmount"}, rows[0])
+	assert.True(t, strings.HasPrefix(rows[1][1], "-"))
+
+	lines := strings.Split(strings.TrimSpace(get("/accounts/54400001111/transactions?creditDebitIndicator=DBIT&export=ndjson", "").Body.String()), "\n")
+	assert.Len(t, lines, debits)
+
+	doc, err := camt053.Parse(get("/accounts/54400001111/transactions?creditDebitIndicator=DBIT&export=xml", "").Body)
+	if assert.NoError(t, err) {
+		assert.Len(t, *doc.Statements()[0].Entries, debits)
+		assert.Equal(t, "54400001111", doc.Statements()[0].Account.GetId())
+	}
+}
+
+// TestRouterDatabases checks that routers only serve the database they were set up with.
+func TestRouterDatabases(t *testing.T) {
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	first, second := setUpTestRouter(), setUpTestRouter()
+
+	sample, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	req, _ := http.NewRequest("POST", "/statements", strings.NewReader(strings.ReplaceAll(string(sample), "54400001111", "54400004444")))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", "application/xml")
+	w := httptest.NewRecorder()
+	first.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Declare Tests
+	tests := []struct {
+		name         string
+		router       *gin.Engine
+		expectedCode int
+	}{
+		{"Database the statement was uploaded to", first, http.StatusOK},
+		{"Other database", second, http.StatusNotFound},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/accounts/54400004444", nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			w := httptest.NewRecorder()
+			test.router.ServeHTTP(w, req)
+			assert.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
+
+// failingStore is a database whose reads fail with err, e.g. because the connection to a SQL database was lost.
+type failingStore struct {
+	db.IDataBase
+	err error
+}
+
+func (s failingStore) GetAccounts(query db.AccountQuery) (*db.AccountsResponse, error) {
+	return nil, s.err
+}
+
+func (s failingStore) GetAccountTransactions(accountId string, query db.TransactionQuery) (*db.TransactionsResponse, error) {
+	return nil, s.err
+}
+
+func (s failingStore) GetAccountBalances(accountId string, query db.BalanceQuery) (*db.BalancesResponse, error) {
+	return nil, s.err
+}
+
+// TestStoreErrors checks that errors of the database are reported by their cause rather than as a client error.
+func TestStoreErrors(t *testing.T) {
+	adminToken := auth.NewAPIKey(auth.ROLE_ADMIN, "1337").Token()
+	store, ingestions := newTestStore()
+	failed := errors.New("sql: database is closed")
+
+	// Declare Tests
+	tests := []struct {
+		err     error
+		request TestRequest
+	}{
+		{db.ErrInvalidCursor, TestRequest{
+			testName:     "Accounts with an invalid cursor",
+			endpoint:     "/accounts",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"},
+		}},
+		{failed, TestRequest{
+			testName:     "Accounts with a failing database",
+			endpoint:     "/accounts",
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{"error": "Internal Server Error", "message": ""},
+		}},
+		{db.ErrAccountNotFound, TestRequest{
+			testName:     "Transactions of a missing account",
+			endpoint:     "/accounts/54400001111/transactions",
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "account not found"},
+		}},
+		{db.ErrInvalidCursor, TestRequest{
+			testName:     "Transactions with an invalid cursor",
+			endpoint:     "/accounts/54400001111/transactions",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]string{"error": "Bad Request", "message": "cursor is invalid or was created for a different query"},
+		}},
+		{failed, TestRequest{
+			testName:     "Transactions with a failing database",
+			endpoint:     "/accounts/54400001111/transactions",
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{"error": "Internal Server Error", "message": ""},
+		}},
+		{db.ErrAccountNotFound, TestRequest{
+			testName:     "Balances of a missing account",
+			endpoint:     "/accounts/54400001111/balances",
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]string{"error": "Not Found", "message": "account not found"},
+		}},
+		{failed, TestRequest{
+			testName:     "Balances with a failing database",
+			endpoint:     "/accounts/54400001111/balances",
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{"error": "Internal Server Error", "message": ""},
+		}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		test.request.requestType = "GET"
+		test.request.headers = map[string]string{"Authorization": "Bearer " + adminToken}
+		testReqests(t, SetUpRouter(failingStore{IDataBase: store, err: test.err}, ingestions), []TestRequest{test.request})
+	}
+}
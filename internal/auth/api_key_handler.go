@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
-	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/justfredrik/bank-api/internal/camt053"
 )
 
 const ROLE_ANY = "any"
@@ -19,12 +21,58 @@ const ROLE_ACCOUNT = "account"
 const EVENT_CREATE = "CREATE"
 const EVENT_DELETE = "DELETE"
 const EVENT_VALIDATE = "VALIDATE"
+const EVENT_REUSE = "REUSE"
 const AUTH_LOG_STRING = "[AUTH]"
 const AUTH_DEBUG_LOG_STRING = "[AUTH-debug]"
 const AUTH_DEBUG_WARNING_STRING = "[AUTH-debug] [WARNING]"
 
-var keyTracker KeyPool = KeyPool{
-	APIKeys: make(map[string]IAPIKey),
+// Account identifiers are at most 34 characters long (Max34Text for IBAN and 'Othr' ids in camt053).
+const maxAccountIdLength = 34
+
+// Lifetime of the API keys of a key pool that is kept between restarts, unless API_KEY_TTL is set.
+// Keys of the in memory key pool do not expire unless API_KEY_TTL is set.
+const DEFAULT_PERSISTED_KEY_TTL = 30 * 24 * time.Hour
+
+// Pool the API keys are stored in, in memory unless UseKeyPool is called.
+var keyPool IAPIKeyPool = NewKeyPool()
+
+// UseKeyPool stores the API keys created from now on in pool and authenticates requests with the keys in it.
+// It is called at startup, before any keys are created.
+func UseKeyPool(pool IAPIKeyPool) {
+	keyPool = pool
+}
+
+// isPersistent checks if the keys of a key pool are kept between restarts, every pool but the in memory one is.
+func isPersistent(pool IAPIKeyPool) bool {
+	_, inMemory := pool.(*KeyPool)
+	return !inMemory
+}
+
+// isDebugMode checks if AUTH_MODE is "debug", in which the same tokens are generated at every start.
+func isDebugMode() bool {
+	return strings.ToLower(os.Getenv("AUTH_MODE")) == "debug"
+}
+
+// keyLifetime returns how long the keys created in the key pool are valid, configured with API_KEY_TTL,
+// e.g. "720h". A lifetime of 0 means that the keys do not expire.
+func keyLifetime() (time.Duration, error) {
+	rawLifetime := os.Getenv("API_KEY_TTL")
+	if rawLifetime == "" {
+		if isPersistent(keyPool) {
+			return DEFAULT_PERSISTED_KEY_TTL, nil
+		}
+		return 0, nil
+	}
+	lifetime, err := time.ParseDuration(rawLifetime)
+	if err != nil || lifetime < 0 {
+		return 0, fmt.Errorf("invalid API_KEY_TTL %q", rawLifetime)
+	}
+	return lifetime, nil
+}
+
+// isExpired checks if an API key has expired.
+func isExpired(key IAPIKey) bool {
+	return key.ExpiresTime() != 0 && key.ExpiresTime() <= time.Now().Unix()
 }
 
 var validTokenCharacters = "abcdefghijkmnpqrstuvxyzABCDEFGHJKLMN123456789"
@@ -38,7 +86,7 @@ var rng *rand.Rand
 func init() {
 
 	// If in production randomize the seed
-	if strings.ToLower(os.Getenv("AUTH_MODE")) != "debug" {
+	if !isDebugMode() {
 		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 	} else {
 		fmt.Printf("%s Running in \"debug\" mode. Switch to \"release\" mode in production", AUTH_DEBUG_WARNING_STRING)
@@ -67,7 +115,7 @@ func emit(event string, key IAPIKey) {
 
 func log(event string, key IAPIKey) { // Used to log each Key handler
 
-	fmt.Printf("%s %-20s | %-7s | %-7s |  accountId %-20d  |  token %-20s \n",
+	fmt.Printf("%s %-20s | %-7s | %-7s |  accountId %-34s  |  token %-20s \n",
 		AUTH_LOG_STRING,
 		time.Now().Format(time.RFC822),
 		event,
@@ -77,7 +125,18 @@ func log(event string, key IAPIKey) { // Used to log each Key handler
 	)
 }
 
-func NewAPIKey(role string, accountId uint64) IAPIKey {
+// NewAPIKey creates an API key like CreateAPIKey, and panics if the key can not be stored.
+// It is used to create the mock keys at startup and in tests.
+func NewAPIKey(role string, accountId string) IAPIKey {
+	key, err := CreateAPIKey(role, accountId)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// CreateAPIKey creates an API key with a new token and stores it in the key pool.
+func CreateAPIKey(role string, accountId string) (IAPIKey, error) {
 
 	// validate role string and accountId
 	switch role {
@@ -87,42 +146,89 @@ func NewAPIKey(role string, accountId uint64) IAPIKey {
 		role = ROLE_ACCOUNT
 	}
 	if role == ROLE_ADMIN {
-		accountId = 0
+		accountId = ""
+	}
+	lifetime, err := keyLifetime()
+	if err != nil {
+		return nil, err
 	}
 
 	// Create APIKey
+	created := time.Now()
 	key := BaseAPIKey{
 		token:       generateSecretToken(),
 		role:        role,
-		accountId:   accountId,
-		createdTime: time.Now().Unix(),
+		accountId:   camt053.NormalizeAccountId(accountId),
+		createdTime: created.Unix(),
+	}
+	if lifetime > 0 {
+		key.expiresTime = created.Add(lifetime).Unix()
 	}
 
-	// If token colission in Tracker re-generate Token
+	// Track API Key in the key pool, if token colission re-generate Token
 	for {
-		if _, ok := keyTracker.APIKeys[key.Token()]; !ok {
+		added, err := keyPool.AddAPIKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if added {
 			break
 		}
+
+		// In "debug" mode the same tokens are generated at every start, a key pool that is kept between
+		// restarts already has the key
+		existing, err := keyPool.GetAPIKey(key.Token())
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.Role() == key.Role() && existing.AccountId() == key.AccountId() && !isExpired(existing) {
+			emit(EVENT_REUSE, existing)
+			return existing, nil
+		}
 		key.token = generateSecretToken()
 	}
 
-	// Track API Key in Tracker struct
-	keyTracker.APIKeys[key.Token()] = key
-	keyTracker.TotalCount++
-
 	// Emit event
 	emit(EVENT_CREATE, key)
 
-	return key
+	return key, nil
 }
 
-func parseAccountIdParam(accountIdParam string) (uint64, error) {
-	// validate id param format
-	val, err := strconv.ParseUint(accountIdParam, 10, 64)
+// RevokeAPIKey removes the API key with a token from the key pool, requests with the token are no longer authorized.
+func RevokeAPIKey(apiSecretToken string) error {
+	key, err := keyPool.GetAPIKey(apiSecretToken)
 	if err != nil {
-		return 0, err
+		return err
+	}
+	if key == nil {
+		return errors.New("no API key has the token")
+	}
+	if _, err := keyPool.RevokeAPIKey(apiSecretToken); err != nil {
+		return err
 	}
-	return val, nil
+
+	// Emit event
+	emit(EVENT_DELETE, key)
+
+	return nil
+}
+
+// parseAccountIdParam validates and normalizes the accountId of a request path. Account ids are Max34Text, an IBAN or
+// any other identifier of at most 34 characters, so only control characters are rejected. Characters such as '/'
+// have to be URL escaped in the path, e.g. /accounts/ACC%2F1 for the account ACC/1.
+func parseAccountIdParam(accountIdParam string) (string, error) {
+	// validate id param format
+	accountId := camt053.NormalizeAccountId(accountIdParam)
+	if accountId == "" {
+		return "", errors.New("missing accountId")
+	}
+	if utf8.RuneCountInString(accountId) > maxAccountIdLength {
+		return "", errors.New("accountId is too long")
+	}
+	if !utf8.ValidString(accountId) || strings.IndexFunc(accountId, unicode.IsControl) >= 0 {
+		return "", errors.New("accountId contains invalid characters")
+	}
+	return accountId, nil
 }
 
 func extractAuthToken(c *gin.Context) (string, error) {
@@ -160,14 +266,21 @@ func KeyHasAccess(c *gin.Context, required_role string) (bool, error) {
 	}
 
 	// Get API key based on token from Key storage
-	if apiKey, ok := keyTracker.APIKeys[token]; ok {
+	apiKey, err := keyPool.GetAPIKey(token)
+	if err != nil {
+		return false, errors.New("unable to validate auth token")
+	}
+	if apiKey != nil && isExpired(apiKey) {
+		return false, errors.New("API key has expired")
+	}
+	if apiKey != nil {
 
 		// Check what role /privalidge the key has
 		switch required_role {
 
 		case ROLE_ACCOUNT:
 			// APIKeys accoundId needs to match with query accountId
-			var accountId uint64
+			var accountId string
 			accountId, err = parseAccountIdParam(c.Param("accountId"))
 			if err == nil {
 				isAdmin := (apiKey.Role() == ROLE_ADMIN)
@@ -7,23 +7,32 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// TestParseAccountIdParam check that the accountId is correctly converted to a uint64.
+// TestParseAccountIdParam check that the accountId is correctly validated and normalized.
 func TestParseAccountIdParam(t *testing.T) {
 
 	// Declare Tests
 	tests := []struct {
 		input          string
-		expectedOutput uint64
+		expectedOutput string
 		expectError    bool
 	}{
-		{"44433", 44433, false},
-		{"54400001111", 54400001111, false},
-		{"18446744073709551615", 18446744073709551615, false},
-		{"18446744073709551616", 0, true}, // uint64 overflow
-		{"44433a", 0, true},
-		{"-44433", 0, true},
-		{"thisisastring", 0, true},
-		{"", 0, true},
+		{"44433", "44433", false},
+		{"54400001111", "54400001111", false},
+		{"18446744073709551616", "18446744073709551616", false}, // No longer limited to uint64
+		{"DD01100056869", "DD01100056869", false},
+		{"se4550000000058398257466", "SE4550000000058398257466", false},
+		{"SE45 5000 0000 0583 9825 7466", "SE4550000000058398257466", false},
+		{"test-001.a_b", "TEST-001.A_B", false},
+		{"SE4550000000058398257466SE45500000000", "", true}, // Longer than 34 characters
+		{"44433;", "44433;", false},                         // Max34Text allows any character
+		{"44433/1", "44433/1", false},
+		{"konto-nr:1234/ö", "KONTO-NR:1234/Ö", false},
+		{"%20", "%20", false},
+		{"ÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅ", "ÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅÅ", false}, // 34 characters, 68 bytes
+		{"44433\x00", "", true},
+		{"44433\x7f1", "", true},
+		{"", "", true},
+		{"   ", "", true},
 	}
 
 	// Run Tests
@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +13,16 @@ func requestContainsToken(c *gin.Context) bool {
 	return true
 }
 
+// CreateMockKeys creates the test keys listed in the README. Keys in a key pool that is kept between restarts would
+// stay valid after the server has stopped, so they are only created there in "debug" mode.
 func CreateMockKeys() {
-	NewAPIKey(ROLE_ADMIN, 0)
-	NewAPIKey(ROLE_ACCOUNT, 54400001111)
-	NewAPIKey(ROLE_ACCOUNT, 13371337984)
+	if isPersistent(keyPool) && !isDebugMode() {
+		fmt.Printf("%s Not creating mock API keys in a persistent key pool outside of \"debug\" mode\n", AUTH_LOG_STRING)
+		return
+	}
+	NewAPIKey(ROLE_ADMIN, "")
+	NewAPIKey(ROLE_ACCOUNT, "54400001111")
+	NewAPIKey(ROLE_ACCOUNT, "13371337984")
 }
 
 func Authenticator(required_role string) (c gin.HandlerFunc) {
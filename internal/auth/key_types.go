@@ -1,39 +1,82 @@
 // Package auth provides authentication utilities and moddleware for the server.
 package auth
 
+import "sync"
+
 // IAPIKey represents an API key in the service.
 type IAPIKey interface {
 	Token() string
 	Role() string
-	AccountId() uint64
+	AccountId() string
 	CreatedTime() int64
+	ExpiresTime() int64 // Unix timestamp, 0 if the key does not expire
 }
 
 // IAPIKeyPool represents a pool of API keys used to track and fetch tokens.
 type IAPIKeyPool interface {
-	GetAPIKey() *IAPIKey
+	GetAPIKey(apiSecretToken string) (IAPIKey, error) // Returns nil if no key has the token
+	AddAPIKey(key IAPIKey) (bool, error)              // Returns false if the token is already used by another key
+	RevokeAPIKey(apiSecretToken string) (bool, error) // Returns false if no key has the token
 }
 
-// KeyPool is a pool of API keys used to track and fetch tokens.
+// KeyPool is a pool of API keys used to track and fetch tokens. Implements IAPIKeyPool
 type KeyPool struct {
 	APIKeys    map[string]IAPIKey
 	TotalCount int
+	mu         sync.RWMutex
+}
+
+var _ IAPIKeyPool = &KeyPool{}
+
+// NewKeyPool creates an empty in memory key pool.
+func NewKeyPool() *KeyPool {
+	return &KeyPool{APIKeys: make(map[string]IAPIKey)}
 }
 
 // GetAPIKey gets an API key from the key pool.
-func (k KeyPool) GetAPIKey(apiSecretToken string) *IAPIKey {
+func (k *KeyPool) GetAPIKey(apiSecretToken string) (IAPIKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
 	if key, ok := k.APIKeys[apiSecretToken]; ok {
-		return &key
+		return key, nil
 	}
-	return nil
+	return nil, nil
+}
+
+// AddAPIKey adds an API key to the key pool unless its token is already in use.
+func (k *KeyPool) AddAPIKey(key IAPIKey) (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.APIKeys[key.Token()]; ok {
+		return false, nil
+	}
+	k.APIKeys[key.Token()] = key
+	k.TotalCount++
+	return true, nil
+}
+
+// RevokeAPIKey removes an API key from the key pool.
+func (k *KeyPool) RevokeAPIKey(apiSecretToken string) (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.APIKeys[apiSecretToken]; !ok {
+		return false, nil
+	}
+	delete(k.APIKeys, apiSecretToken)
+	k.TotalCount--
+	return true, nil
 }
 
 // BaseAPIKey is the standard API key in system and implements the IAPIKey interface.
 type BaseAPIKey struct {
 	token       string
 	role        string
-	accountId   uint64
+	accountId   string
 	createdTime int64 // Unix timestamp
+	expiresTime int64 // Unix timestamp, 0 if the key does not expire
 }
 
 // Token returns the API key token.
@@ -47,7 +90,7 @@ func (t BaseAPIKey) Role() string {
 }
 
 // AccountId returns the API keys associated accounts accountId.
-func (t BaseAPIKey) AccountId() uint64 {
+func (t BaseAPIKey) AccountId() string {
 	return t.accountId
 }
 
@@ -55,3 +98,8 @@ func (t BaseAPIKey) AccountId() uint64 {
 func (t BaseAPIKey) CreatedTime() int64 {
 	return t.createdTime
 }
+
+// ExpiresTime returns a UNIX timestamp of when API key expires, 0 if it does not expire.
+func (t BaseAPIKey) ExpiresTime() int64 {
+	return t.expiresTime
+}
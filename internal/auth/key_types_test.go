@@ -0,0 +1,204 @@
+// Package auth provides authentication utilities and moddleware for the server.
+package auth
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testKeyPool checks that keys can be added to, fetched from and revoked from a key pool, and that tokens are unique.
+// It returns the tokens of the keys it added.
+func testKeyPool(t *testing.T, pool IAPIKeyPool) []string {
+	adminKey := BaseAPIKey{token: generateSecretToken(), role: ROLE_ADMIN, createdTime: 1700000000}
+	accountKey := BaseAPIKey{token: generateSecretToken(), role: ROLE_ACCOUNT, accountId: "54400001111", createdTime: 1700000001, expiresTime: 1702592001}
+	revokedKey := BaseAPIKey{token: generateSecretToken(), role: ROLE_ACCOUNT, accountId: "13371337984", createdTime: 1700000002}
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		key           IAPIKey
+		expectedAdded bool
+	}{
+		{"Admin key", adminKey, true},
+		{"Account key", accountKey, true},
+		{"Revoked key", revokedKey, true},
+		{"Token in use", BaseAPIKey{token: adminKey.token, role: ROLE_ACCOUNT, accountId: "13371337984"}, false},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			added, err := pool.AddAPIKey(test.key)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedAdded, added)
+		})
+	}
+
+	for _, expected := range []BaseAPIKey{adminKey, accountKey} {
+		key, err := pool.GetAPIKey(expected.token)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, key)
+	}
+	key, err := pool.GetAPIKey(generateSecretToken())
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	// Revoked keys are removed
+	revoked, err := pool.RevokeAPIKey(revokedKey.token)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	key, err = pool.GetAPIKey(revokedKey.token)
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	revoked, err = pool.RevokeAPIKey(revokedKey.token)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	return []string{adminKey.token, accountKey.token}
+}
+
+// TestKeyPool checks the in memory key pool.
+func TestKeyPool(t *testing.T) {
+	testKeyPool(t, NewKeyPool())
+}
+
+// TestCreateAPIKeyReuse checks that keys created again with the same tokens, as after a restart in "debug" mode,
+// are the keys already in the key pool.
+func TestCreateAPIKeyReuse(t *testing.T) {
+	defer UseKeyPool(keyPool)
+	defer func(r *rand.Rand) { rng = r }(rng)
+	UseKeyPool(NewKeyPool())
+
+	rng = rand.New(rand.NewSource(1337))
+	first, err := CreateAPIKey(ROLE_ACCOUNT, "54400001111")
+	assert.NoError(t, err)
+
+	// Restarting with the same seed reuses the key
+	rng = rand.New(rand.NewSource(1337))
+	second, err := CreateAPIKey(ROLE_ACCOUNT, "54400001111")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// A key for another account gets a token of its own
+	rng = rand.New(rand.NewSource(1337))
+	other, err := CreateAPIKey(ROLE_ACCOUNT, "13371337984")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Token(), other.Token())
+	assert.Equal(t, "13371337984", other.AccountId())
+}
+
+// persistentKeyPool is an in memory key pool that is treated as a pool that is kept between restarts.
+type persistentKeyPool struct {
+	*KeyPool
+}
+
+// TestCreateMockKeys checks that mock keys are only created in a persistent key pool in "debug" mode.
+func TestCreateMockKeys(t *testing.T) {
+	defer UseKeyPool(keyPool)
+
+	// Declare Tests
+	tests := []struct {
+		name         string
+		pool         *KeyPool
+		persistent   bool
+		authMode     string
+		expectedKeys int
+	}{
+		{"In memory", NewKeyPool(), false, "release", 3},
+		{"Persistent", NewKeyPool(), true, "release", 0},
+		{"Persistent in debug mode", NewKeyPool(), true, "debug", 3},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("AUTH_MODE", test.authMode)
+			if test.persistent {
+				UseKeyPool(persistentKeyPool{test.pool})
+			} else {
+				UseKeyPool(test.pool)
+			}
+			CreateMockKeys()
+			assert.Equal(t, test.expectedKeys, test.pool.TotalCount)
+		})
+	}
+}
+
+// TestAPIKeyExpiry checks that keys expire after API_KEY_TTL, by default only in a persistent key pool,
+// and that expired and revoked keys are not authorized.
+func TestAPIKeyExpiry(t *testing.T) {
+	defer UseKeyPool(keyPool)
+
+	// Declare Tests
+	tests := []struct {
+		name             string
+		persistent       bool
+		ttl              string
+		expectedLifetime time.Duration
+		expectError      bool
+	}{
+		{"In memory", false, "", 0, false},
+		{"Persistent", true, "", DEFAULT_PERSISTED_KEY_TTL, false},
+		{"Configured", false, "1h", time.Hour, false},
+		{"Persistent without expiry", true, "0", 0, false},
+		{"Invalid", false, "a week", 0, true},
+		{"Negative", false, "-1h", 0, true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("API_KEY_TTL", test.ttl)
+			if test.persistent {
+				UseKeyPool(persistentKeyPool{NewKeyPool()})
+			} else {
+				UseKeyPool(NewKeyPool())
+			}
+			key, err := CreateAPIKey(ROLE_ADMIN, "")
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if test.expectedLifetime == 0 {
+				assert.Equal(t, int64(0), key.ExpiresTime())
+			} else {
+				assert.Equal(t, key.CreatedTime()+int64(test.expectedLifetime.Seconds()), key.ExpiresTime())
+			}
+		})
+	}
+
+	// Expired and revoked keys are not authorized
+	pool := NewKeyPool()
+	UseKeyPool(pool)
+	expired := BaseAPIKey{token: generateSecretToken(), role: ROLE_ADMIN, expiresTime: time.Now().Add(-time.Minute).Unix()}
+	valid := BaseAPIKey{token: generateSecretToken(), role: ROLE_ADMIN, expiresTime: time.Now().Add(time.Hour).Unix()}
+	pool.AddAPIKey(expired)
+	pool.AddAPIKey(valid)
+
+	hasAccess := func(token string) (bool, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/accounts", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		return KeyHasAccess(c, ROLE_ADMIN)
+	}
+	access, err := hasAccess(expired.token)
+	assert.EqualError(t, err, "API key has expired")
+	assert.False(t, access)
+
+	access, err = hasAccess(valid.token)
+	assert.NoError(t, err)
+	assert.True(t, access)
+
+	assert.NoError(t, RevokeAPIKey(valid.token))
+	access, err = hasAccess(valid.token)
+	assert.NoError(t, err)
+	assert.False(t, access)
+	assert.Error(t, RevokeAPIKey(valid.token))
+}
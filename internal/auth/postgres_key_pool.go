@@ -0,0 +1,65 @@
+// Package auth provides authentication utilities and moddleware for the server.
+package auth
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+)
+
+// PostgresKeyPool is a pool of API keys stored in the api_keys table of a PostgreSQL database, which is created
+// by the migrations of db.PostgresData. Keys are kept between restarts and shared by every server using the database,
+// so they expire after API_KEY_TTL and can be revoked with RevokeAPIKey.
+// Implements IAPIKeyPool
+type PostgresKeyPool struct {
+	db *sql.DB
+}
+
+var _ IAPIKeyPool = &PostgresKeyPool{}
+
+// NewPostgresKeyPool creates a key pool that stores its keys in a PostgreSQL database.
+func NewPostgresKeyPool(db *sql.DB) *PostgresKeyPool {
+	return &PostgresKeyPool{db: db}
+}
+
+// hashToken hashes a token before it is stored, so that the tokens can not be read from the database.
+func hashToken(apiSecretToken string) string {
+	hash := sha256.Sum256([]byte(apiSecretToken))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetAPIKey gets an API key from the key pool.
+func (k *PostgresKeyPool) GetAPIKey(apiSecretToken string) (IAPIKey, error) {
+	key := BaseAPIKey{token: apiSecretToken}
+	err := k.db.QueryRow(`SELECT role, account_id, created_time, expires_time FROM api_keys WHERE token_hash = $1`, hashToken(apiSecretToken)).
+		Scan(&key.role, &key.accountId, &key.createdTime, &key.expiresTime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// AddAPIKey adds an API key to the key pool unless its token is already in use.
+func (k *PostgresKeyPool) AddAPIKey(key IAPIKey) (bool, error) {
+	result, err := k.db.Exec(`INSERT INTO api_keys (token_hash, role, account_id, created_time, expires_time) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (token_hash) DO NOTHING`, hashToken(key.Token()), key.Role(), key.AccountId(), key.CreatedTime(), key.ExpiresTime())
+	if err != nil {
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	return added == 1, err
+}
+
+// RevokeAPIKey removes an API key from the key pool, so that no server using the database accepts it anymore.
+func (k *PostgresKeyPool) RevokeAPIKey(apiSecretToken string) (bool, error) {
+	result, err := k.db.Exec(`DELETE FROM api_keys WHERE token_hash = $1`, hashToken(apiSecretToken))
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked == 1, err
+}
@@ -0,0 +1,75 @@
+// Package auth provides authentication utilities and moddleware for the server.
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// postgresTestSchema creates an empty schema that is dropped when the test ends, and returns a data source name
+// that uses it, the same way as the PostgreSQL tests of the db package. The tests are skipped if POSTGRES_TEST_DSN is not set.
+func postgresTestSchema(t *testing.T) string {
+	dataSourceName := os.Getenv("POSTGRES_TEST_DSN")
+	if dataSourceName == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	random := make([]byte, 8)
+	rand.Read(random)
+	schema := "auth_test_" + hex.EncodeToString(random)
+
+	admin, err := sql.Open("pgx", dataSourceName)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %s", err)
+	}
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("failed to create schema: %s", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	// Settings that pgx does not know, such as search_path, are sent to the server
+	if strings.Contains(dataSourceName, "://") {
+		u, err := url.Parse(dataSourceName)
+		if err != nil {
+			t.Fatalf("failed to parse POSTGRES_TEST_DSN: %s", err)
+		}
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return dataSourceName + " search_path=" + schema
+}
+
+// TestPostgresKeyPool checks the PostgreSQL key pool in a schema of its own, it is skipped if POSTGRES_TEST_DSN is not set.
+func TestPostgresKeyPool(t *testing.T) {
+
+	// Opening the database creates the api_keys table
+	store, err := db.OpenPostgres(postgresTestSchema(t))
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	defer store.Close()
+
+	pool := NewPostgresKeyPool(store.DB())
+	tokens := testKeyPool(t, pool)
+
+	// Tokens are only stored as hashes
+	for _, token := range tokens {
+		var stored int
+		assert.NoError(t, store.DB().QueryRow(`SELECT COUNT(*) FROM api_keys WHERE token_hash = $1`, token).Scan(&stored))
+		assert.Equal(t, 0, stored)
+	}
+}
@@ -0,0 +1,707 @@
+// package bai2 parses BAI2 cash management balance reporting files into the camt053 model.
+package bai2
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Record codes, the first field of every record.
+const RECORD_FILE_HEADER = "01"
+const RECORD_GROUP_HEADER = "02"
+const RECORD_ACCOUNT = "03"
+const RECORD_TRANSACTION = "16"
+const RECORD_ACCOUNT_TRAILER = "49"
+const RECORD_CONTINUATION = "88"
+const RECORD_GROUP_TRAILER = "98"
+const RECORD_FILE_TRAILER = "99"
+
+// Version of the BAI format that can be parsed.
+const BAI_VERSION = "2"
+
+// Issuer of the bank transaction codes of entries, the BAI type code of the transaction detail.
+const TRANSACTION_CODE_ISSUER = "BAI"
+
+// Currency used when neither the account nor the group has a currency.
+const DEFAULT_CURRENCY = "USD"
+
+// As of date modifier of groups reporting interim same day data, they become camt052 intraday reports.
+const AS_OF_DATE_MODIFIER_INTERIM_SAME_DAY = "3"
+
+// Balance types of the status type codes of account records, other status codes are kept as proprietary balance types.
+var balanceTypes = map[string]string{
+	"010": "OPBD", // Opening ledger
+	"015": "CLBD", // Closing ledger
+	"030": "ITBD", // Current ledger
+	"040": "OPAV", // Opening available
+	"045": "CLAV", // Closing available
+	"060": "ITAV", // Current available
+}
+
+// Summary type codes of account records that are mapped to the transaction summary.
+const SUMMARY_TOTAL_CREDITS = "100"
+const SUMMARY_TOTAL_DEBITS = "400"
+
+// Type code of transaction details that only contain information and are not loaded.
+const TYPE_NON_MONETARY = "890"
+
+// Length of the hash that replaces the end of statement ids that would be too long.
+const ID_HASH_LENGTH = 12
+
+// ParseError is returned when a BAI2 file can not be parsed. It points at the offending record.
+type ParseError struct {
+	Line   int    // Line of the offending record, 1 based
+	Record string // Record code of the offending record, e.g. 16
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Record == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("record %s (line %d): %s", e.Record, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// record is a logical record, continuation records are added to the record they continue.
+type record struct {
+	code  string
+	lines []string // Fields of the record and its continuation records, without the record code and the trailing '/'
+	line  int
+}
+
+// IsBAI2 sniffs the start of a document to check if it is a BAI2 file rather than XML or MT940.
+func IsBAI2(data []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(data)), RECORD_FILE_HEADER+",")
+}
+
+// Parse reads a BAI2 file and converts every account record into a camt053 statement.
+// Groups of interim same day data become camt.052 intraday reports, all groups of a file need to be of the same kind.
+// Type codes that are read but not loaded are reported in the warnings of the document.
+func Parse(r io.Reader) (camt053.Document, error) {
+	records, err := split(r)
+	if err != nil {
+		return camt053.Document{}, err
+	}
+	if len(records) == 0 {
+		return camt053.Document{}, &ParseError{Line: 1, Err: errors.New("file does not contain any records")}
+	}
+
+	p := parser{statements: make([]camt053.Statement, 0), ids: make(map[string]bool)}
+	for _, rec := range records {
+		p.record = rec
+		if err := p.parse(rec); err != nil {
+			return camt053.Document{}, &ParseError{Line: rec.line, Record: rec.code, Err: err}
+		}
+	}
+	if p.state != stateDone {
+		return camt053.Document{}, &ParseError{Line: records[len(records)-1].line, Err: errors.New("file trailer record (99) is missing")}
+	}
+
+	doc := camt053.Document{Version: camt053.DEFAULT_VERSION, Warnings: p.warnings}
+	header := camt053.GroupHeader{CreationDateTime: p.created}
+	if p.intraday {
+		doc.MessageType = camt053.MESSAGE_TYPE_REPORT
+		doc.AccountReport = &camt053.BankToCustomerAccountReport{GroupHeader: header, Reports: p.statements}
+	} else {
+		doc.MessageType = camt053.MESSAGE_TYPE_STATEMENT
+		doc.BankStatement = camt053.BankToCustomerStatement{GroupHeader: header, Statements: p.statements}
+	}
+	return doc, nil
+}
+
+// split reads the records of a file and adds continuation records to the record they continue.
+func split(r io.Reader) ([]record, error) {
+	records := make([]record, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		code, fields, _ := strings.Cut(strings.TrimSuffix(line, "/"), ",")
+
+		if code == RECORD_CONTINUATION {
+			if len(records) == 0 {
+				return nil, &ParseError{Line: lineNumber, Record: code, Err: errors.New("continuation record does not continue a record")}
+			}
+			last := &records[len(records)-1]
+			last.lines = append(last.lines, fields)
+			continue
+		}
+		records = append(records, record{code: code, lines: []string{fields}, line: lineNumber})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Position of the parser in the file.
+type state int
+
+const (
+	stateStart   state = iota // Expecting a file header
+	stateFile                 // Expecting a group header or file trailer
+	stateGroup                // Expecting an account or group trailer
+	stateAccount              // Expecting transaction details or an account trailer
+	stateDone                 // File trailer has been read
+)
+
+// parser keeps track of the file, group and account being read, and their control totals and record counts.
+type parser struct {
+	state      state
+	record     record // Record being read
+	statements []camt053.Statement
+	ids        map[string]bool // Ids of the statements
+	warnings   []string
+
+	fileId                     string
+	created                    camt053.DateTime
+	fileTotal, fileRecords     int64
+	groups                     int64
+	intraday                   bool
+	groupCurrency              string
+	asOfDate                   camt053.Date
+	groupTotal, groupRecords   int64
+	accounts                   int64
+	account                    *camt053.Statement
+	accountTotal, accountCount int64
+}
+
+// warn adds a warning about the record being read.
+func (p *parser) warn(format string, args ...any) {
+	warning := &ParseError{Line: p.record.line, Record: p.record.code, Err: fmt.Errorf(format, args...)}
+	p.warnings = append(p.warnings, warning.Error())
+}
+
+// statementId returns a unique id for the statement of an account, built from the file id, the as of date and
+// the account number, e.g. FILE01-20181216-123456789. Banks reuse file ids, so the file id alone is not unique.
+// The id can be used in a URL, see sanitizeId, and ids longer than MAX_ID_LENGTH end with a hash of the full id.
+func (p *parser) statementId(accountNumber string) string {
+	id := sanitizeId(fmt.Sprintf("%s-%s-%s", p.fileId, strings.ReplaceAll(p.asOfDate.String(), "-", ""), accountNumber))
+	if len(id) > camt053.MAX_ID_LENGTH {
+		hash := sha256.Sum256([]byte(id))
+		id = id[:camt053.MAX_ID_LENGTH-ID_HASH_LENGTH-1] + "-" + hex.EncodeToString(hash[:])[:ID_HASH_LENGTH]
+	}
+
+	// An account that is reported in several groups with the same as of date gets a statement per group
+	unique := id
+	for n := 2; p.ids[unique]; n++ {
+		suffix := "-" + strconv.Itoa(n)
+		unique = id[:min(len(id), camt053.MAX_ID_LENGTH-len(suffix))] + suffix
+	}
+	p.ids[unique] = true
+	return unique
+}
+
+// sanitizeId replaces slashes with dashes and removes other characters that are not letters, digits, '-', '.' or '_'.
+func sanitizeId(id string) string {
+	return strings.Map(func(char rune) rune {
+		switch {
+		case char == '/':
+			return '-'
+		case (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9'):
+			return char
+		case strings.ContainsRune("-._", char):
+			return char
+		}
+		return -1
+	}, id)
+}
+
+// parse reads a record, checking that it comes in the right place.
+func (p *parser) parse(rec record) error {
+	records := int64(len(rec.lines))
+	p.fileRecords += records
+	p.groupRecords += records
+	p.accountCount += records
+
+	switch {
+	case rec.code == RECORD_FILE_HEADER && p.state == stateStart:
+		return p.fileHeader(rec)
+	case rec.code == RECORD_GROUP_HEADER && p.state == stateFile:
+		return p.groupHeader(rec)
+	case rec.code == RECORD_ACCOUNT && p.state == stateGroup:
+		return p.accountIdentifier(rec)
+	case rec.code == RECORD_TRANSACTION && p.state == stateAccount:
+		return p.transactionDetail(rec)
+	case rec.code == RECORD_ACCOUNT_TRAILER && p.state == stateAccount:
+		return p.accountTrailer(rec)
+	case rec.code == RECORD_GROUP_TRAILER && p.state == stateGroup:
+		return p.groupTrailer(rec)
+	case rec.code == RECORD_FILE_TRAILER && p.state == stateFile:
+		return p.fileTrailer(rec)
+	case p.state == stateDone:
+		return errors.New("record after the file trailer")
+	}
+
+	switch rec.code {
+	case RECORD_FILE_HEADER, RECORD_GROUP_HEADER, RECORD_ACCOUNT, RECORD_TRANSACTION,
+		RECORD_ACCOUNT_TRAILER, RECORD_GROUP_TRAILER, RECORD_FILE_TRAILER:
+		return fmt.Errorf("record %s is out of place", rec.code)
+	}
+	return fmt.Errorf("'%s' is not a record code", rec.code)
+}
+
+// fileHeader reads a 01 file header: sender, receiver, creation date, creation time, file id, record length, block size and version.
+func (p *parser) fileHeader(rec record) error {
+	fields := newFieldReader(rec.lines)
+	fields.next() // Sender identification
+	fields.next() // Receiver identification
+	created, err := parseDateTime(fields.next(), fields.next())
+	if err != nil {
+		return err
+	}
+	p.fileId = fields.next()
+	fields.next() // Physical record length
+	fields.next() // Block size
+	if version := fields.next(); version != BAI_VERSION {
+		return fmt.Errorf("version '%s' is not supported, only BAI version %s files can be parsed", version, BAI_VERSION)
+	}
+	if p.fileId == "" {
+		return errors.New("file identification number is missing")
+	}
+
+	p.created = created
+	p.state = stateFile
+	return nil
+}
+
+// groupHeader reads a 02 group header: receiver, originator, group status, as of date, as of time, currency and as of date modifier.
+func (p *parser) groupHeader(rec record) error {
+	fields := newFieldReader(rec.lines)
+	fields.next() // Ultimate receiver identification
+	fields.next() // Originator identification
+	fields.next() // Group status
+	asOfDate, err := parseDate(fields.next())
+	if err != nil {
+		return err
+	}
+	fields.next() // As of time
+	currency := fields.next()
+	intraday := fields.next() == AS_OF_DATE_MODIFIER_INTERIM_SAME_DAY
+
+	if p.groups > 0 && intraday != p.intraday {
+		return errors.New("groups of interim same day data and other groups can not be mixed")
+	}
+
+	p.intraday = intraday
+	p.groupCurrency = currency
+	p.asOfDate = asOfDate
+	p.groupTotal, p.groupRecords, p.accounts = 0, int64(len(rec.lines)), 0
+	p.groups++
+	p.state = stateGroup
+	return nil
+}
+
+// accountIdentifier reads a 03 account identifier: account number, currency and repeated status or summary
+// type codes with their amount, item count and funds type.
+func (p *parser) accountIdentifier(rec record) error {
+	fields := newFieldReader(rec.lines)
+	accountNumber := fields.next()
+	if accountNumber == "" {
+		return errors.New("customer account number is missing")
+	}
+	currency := fields.next()
+	if currency == "" {
+		currency = p.groupCurrency
+	}
+	if currency == "" {
+		currency = DEFAULT_CURRENCY
+	}
+
+	p.accounts++
+	stmt := camt053.Statement{
+		Id:               p.statementId(accountNumber),
+		CreationDateTime: p.created,
+		Account:          account(accountNumber),
+	}
+	stmt.Account.Currency = &currency
+	p.accountTotal, p.accountCount = 0, int64(len(rec.lines))
+
+	skipped := make([]string, 0)
+	for fields.more() {
+		typeCode := fields.next()
+		rawAmount, rawCount := fields.next(), fields.next()
+		if _, err := fields.fundsType(); err != nil {
+			return err
+		}
+		if typeCode == "" {
+			continue
+		}
+		if rawAmount == "" {
+			continue // The amount is not reported
+		}
+		amount, err := parseAmount(rawAmount)
+		if err != nil {
+			return err
+		}
+		p.accountTotal += amount
+
+		switch {
+		case typeCode == SUMMARY_TOTAL_CREDITS || typeCode == SUMMARY_TOTAL_DEBITS:
+			summary, err := entrySummary(currency, amount, rawCount)
+			if err != nil {
+				return err
+			}
+			if stmt.TransactionSummary == nil {
+				stmt.TransactionSummary = &camt053.TransactionSummary{}
+			}
+			if typeCode == SUMMARY_TOTAL_CREDITS {
+				stmt.TransactionSummary.TotalCreditEntries = &summary
+			} else {
+				stmt.TransactionSummary.TotalDebitEntries = &summary
+			}
+		case typeCode < "100":
+			balance, err := p.balance(typeCode, currency, amount)
+			if err != nil {
+				return err
+			}
+			stmt.Balances = append(stmt.Balances, balance)
+		default:
+			skipped = append(skipped, typeCode)
+		}
+	}
+	if len(skipped) > 0 {
+		p.warn("summary type codes %s of account %s are not loaded, only total credits (%s) and total debits (%s) are",
+			strings.Join(skipped, ", "), accountNumber, SUMMARY_TOTAL_CREDITS, SUMMARY_TOTAL_DEBITS)
+	}
+
+	p.account = &stmt
+	p.state = stateAccount
+	return nil
+}
+
+// balance converts the amount of a status type code into a balance dated with the as of date of the group.
+func (p *parser) balance(typeCode string, currency string, amount int64) (camt053.Balance, error) {
+	value, err := toAmount(currency, abs(amount))
+	if err != nil {
+		return camt053.Balance{}, err
+	}
+	balanceType := camt053.CodeOrProprietary{}
+	if code, ok := balanceTypes[typeCode]; ok {
+		balanceType.Code = &code
+	} else {
+		balanceType.Proprietary = &typeCode
+	}
+	date := p.asOfDate
+	return camt053.Balance{
+		Type:                 camt053.BalanceType{CodeOrProprietary: balanceType},
+		Amount:               value,
+		CreditDebitIndicator: creditDebitIndicator(amount >= 0),
+		Date:                 camt053.DateAndDateTime{Date: &date},
+	}, nil
+}
+
+// transactionDetail reads a 16 transaction detail: type code, amount, funds type, bank reference,
+// customer reference and text. The text runs to the end of the record, including its continuation records.
+func (p *parser) transactionDetail(rec record) error {
+	fields := newFieldReader(rec.lines)
+	typeCode := fields.next()
+	rawAmount := fields.next()
+	valueDate, err := fields.fundsType()
+	if err != nil {
+		return err
+	}
+	bankRef, customerRef := fields.next(), fields.next()
+	text := fields.rest()
+
+	amount, err := parseAmount(rawAmount)
+	if err != nil {
+		return err
+	}
+	if amount < 0 {
+		return errors.New("transaction amounts can not be negative, the type code determines if it is a credit or a debit")
+	}
+	p.accountTotal += amount
+
+	if typeCode == TYPE_NON_MONETARY {
+		return nil
+	}
+	code, err := strconv.Atoi(typeCode)
+	if err != nil || code < 100 || code > 999 {
+		return fmt.Errorf("'%s' is not a transaction detail type code (100-999)", typeCode)
+	}
+	if code > 699 {
+		// Loan, custom and other details can not be told apart as credits or debits, they are counted in the totals
+		p.warn("type code '%s' is neither a credit (100-399) nor a debit (400-699), the transaction detail is not loaded", typeCode)
+		return nil
+	}
+
+	currency := *p.account.Account.Currency
+	value, err := toAmount(currency, amount)
+	if err != nil {
+		return err
+	}
+	bookingDate := p.asOfDate
+	if valueDate == nil {
+		valueDate = &bookingDate
+	}
+	entry := camt053.Entry{
+		Amount:               value,
+		CreditDebitIndicator: creditDebitIndicator(code < 400),
+		Status:               "BOOK",
+		BookingDate:          &camt053.DateAndDateTime{Date: &bookingDate},
+		ValueDate:            &camt053.DateAndDateTime{Date: valueDate},
+		BankTransactionCode: camt053.BankTransactionCode{
+			ProprietaryCode: &camt053.BankTransactionProprietaryCode{Code: typeCode, Issuer: TRANSACTION_CODE_ISSUER},
+		},
+	}
+	if customerRef != "" {
+		entry.Reference = &customerRef
+	}
+	if bankRef != "" {
+		entry.AccountServicerRef = &bankRef
+	}
+	if len(text) > 0 {
+		entry.EntryDetails = &[]camt053.EntryDetail{{TransactionDetails: &[]camt053.TransactionDetail{{
+			RemittanceInformation: &camt053.RemittanceInformation{Unstructured: &text},
+		}}}}
+	}
+
+	if p.account.Entries == nil {
+		p.account.Entries = &[]camt053.Entry{}
+	}
+	*p.account.Entries = append(*p.account.Entries, entry)
+	return nil
+}
+
+// accountTrailer reads a 49 account trailer: account control total and number of records.
+func (p *parser) accountTrailer(rec record) error {
+	fields := newFieldReader(rec.lines)
+	if err := checkControl("account", fields.next(), p.accountTotal, fields.next(), p.accountCount); err != nil {
+		return err
+	}
+
+	p.statements = append(p.statements, *p.account)
+	p.account = nil
+	p.groupTotal += p.accountTotal
+	p.state = stateGroup
+	return nil
+}
+
+// groupTrailer reads a 98 group trailer: group control total, number of accounts and number of records.
+func (p *parser) groupTrailer(rec record) error {
+	fields := newFieldReader(rec.lines)
+	rawTotal, rawAccounts := fields.next(), fields.next()
+	if err := checkControl("group", rawTotal, p.groupTotal, fields.next(), p.groupRecords); err != nil {
+		return err
+	}
+	if accounts, err := strconv.ParseInt(rawAccounts, 10, 64); err != nil || accounts != p.accounts {
+		return fmt.Errorf("number of accounts '%s' does not match the %d accounts of the group", rawAccounts, p.accounts)
+	}
+
+	p.fileTotal += p.groupTotal
+	p.state = stateFile
+	return nil
+}
+
+// fileTrailer reads a 99 file trailer: file control total, number of groups and number of records.
+func (p *parser) fileTrailer(rec record) error {
+	fields := newFieldReader(rec.lines)
+	rawTotal, rawGroups := fields.next(), fields.next()
+	if err := checkControl("file", rawTotal, p.fileTotal, fields.next(), p.fileRecords); err != nil {
+		return err
+	}
+	if groups, err := strconv.ParseInt(rawGroups, 10, 64); err != nil || groups != p.groups {
+		return fmt.Errorf("number of groups '%s' does not match the %d groups of the file", rawGroups, p.groups)
+	}
+	if p.groups == 0 {
+		return errors.New("file does not contain any groups")
+	}
+
+	p.state = stateDone
+	return nil
+}
+
+// checkControl checks the control total and number of records of a trailer against what was read.
+func checkControl(name string, rawTotal string, total int64, rawRecords string, records int64) error {
+	if expected, err := parseAmount(rawTotal); err != nil || expected != total {
+		return fmt.Errorf("%s control total '%s' does not match the sum of the amounts, %d", name, rawTotal, total)
+	}
+	if expected, err := strconv.ParseInt(rawRecords, 10, 64); err != nil || expected != records {
+		return fmt.Errorf("number of records '%s' does not match the %d records of the %s", rawRecords, records, name)
+	}
+	return nil
+}
+
+// fieldReader reads the comma separated fields of a record. Continuation records start a new field.
+type fieldReader struct {
+	lines [][]string
+	line  int
+	field int
+}
+
+func newFieldReader(lines []string) *fieldReader {
+	reader := &fieldReader{lines: make([][]string, len(lines))}
+	for i, line := range lines {
+		reader.lines[i] = strings.Split(line, ",")
+	}
+	return reader
+}
+
+// more checks if there are fields left to read.
+func (f *fieldReader) more() bool {
+	for f.line < len(f.lines) && f.field >= len(f.lines[f.line]) {
+		f.line, f.field = f.line+1, 0
+	}
+	return f.line < len(f.lines)
+}
+
+// next returns the next field, fields that are left out at the end of a record are empty.
+func (f *fieldReader) next() string {
+	if !f.more() {
+		return ""
+	}
+	value := strings.TrimSpace(f.lines[f.line][f.field])
+	f.field++
+	return value
+}
+
+// rest returns the remaining text of the record, one line per record, commas included.
+func (f *fieldReader) rest() []string {
+	text := make([]string, 0)
+	for ; f.line < len(f.lines); f.line, f.field = f.line+1, 0 {
+		if f.field >= len(f.lines[f.line]) {
+			continue
+		}
+		if line := strings.TrimSpace(strings.Join(f.lines[f.line][f.field:], ",")); line != "" {
+			text = append(text, line)
+		}
+	}
+	return text
+}
+
+// fundsType reads a funds type and the fields that depend on it. The value date is returned for value dated funds.
+func (f *fieldReader) fundsType() (*camt053.Date, error) {
+	switch fundsType := f.next(); fundsType {
+	case "", "Z", "0", "1", "2":
+		return nil, nil
+	case "V": // Value date and time
+		date, err := parseDate(f.next())
+		f.next()
+		if err != nil {
+			return nil, err
+		}
+		return &date, nil
+	case "S": // Immediate, one day and two or more days availability
+		f.next()
+		f.next()
+		f.next()
+		return nil, nil
+	case "D": // Number of distributions followed by days and amount of each
+		rawCount := f.next()
+		count, err := strconv.Atoi(rawCount)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("'%s' is not a number of distributions", rawCount)
+		}
+		for i := 0; i < count; i++ {
+			f.next()
+			f.next()
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("'%s' is not a funds type", fundsType)
+	}
+}
+
+// account converts an account number into an account, IBANs are kept as IBANs.
+func account(accountNumber string) camt053.Account {
+	if camt053.IsValidIBAN(accountNumber) {
+		return camt053.Account{Id: camt053.AccountId{IBAN: &accountNumber}}
+	}
+	return camt053.Account{Id: camt053.AccountId{Other: &camt053.OtherId{Id: accountNumber}}}
+}
+
+// entrySummary converts the amount and item count of a summary type code.
+func entrySummary(currency string, amount int64, rawCount string) (camt053.CreditDebitEntry, error) {
+	value, err := toAmount(currency, abs(amount))
+	if err != nil {
+		return camt053.CreditDebitEntry{}, err
+	}
+	count := 0
+	if rawCount != "" {
+		if count, err = strconv.Atoi(rawCount); err != nil {
+			return camt053.CreditDebitEntry{}, fmt.Errorf("'%s' is not an item count", rawCount)
+		}
+	}
+	return camt053.CreditDebitEntry{NumberOfEntries: count, Sum: &value.Value}, nil
+}
+
+// parseAmount parses an amount in the minor unit of the currency, e.g. 150000 is 1500.00 USD. Amounts can have a sign.
+func parseAmount(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseInt(strings.TrimPrefix(raw, "+"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not an amount", raw)
+	}
+	return amount, nil
+}
+
+// toAmount converts an amount in the minor unit of a currency.
+func toAmount(currency string, amount int64) (camt053.Amount, error) {
+	return camt053.NewAmount(currency, camt053.NewDecimal(amount, camt053.MinorUnits(currency)))
+}
+
+// parseDate parses a YYMMDD date.
+func parseDate(value string) (camt053.Date, error) {
+	t, err := time.Parse("060102", value)
+	if err != nil {
+		return camt053.Date{}, errors.New("'" + value + "' is not a date (YYMMDD)")
+	}
+	return camt053.NewDate(t.Date()), nil
+}
+
+// parseDateTime parses a YYMMDD date and a HHMM time, the end of the day is either 2400 or 9999. Times are read as UTC.
+func parseDateTime(rawDate string, rawTime string) (camt053.DateTime, error) {
+	date, err := parseDate(rawDate)
+	if err != nil {
+		return camt053.DateTime{}, err
+	}
+	t := date.Time()
+	switch rawTime {
+	case "":
+	case "2400", "9999":
+		t = t.AddDate(0, 0, 1)
+	default:
+		clock, err := time.Parse("1504", rawTime)
+		if err != nil {
+			return camt053.DateTime{}, errors.New("'" + rawTime + "' is not a time (HHMM)")
+		}
+		t = t.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	}
+	return camt053.NewDateTime(t), nil
+}
+
+// creditDebitIndicator converts a direction into a credit debit indicator.
+func creditDebitIndicator(credit bool) string {
+	if credit {
+		return "CRDT"
+	}
+	return "DBIT"
+}
+
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
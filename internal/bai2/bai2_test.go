@@ -0,0 +1,215 @@
+// package bai2 parses BAI2 cash management balance reporting files into the camt053 model.
+package bai2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleFile is a prior day BAI2 file with one account, its balances and summaries, and four transaction details.
+// The control totals are the sum of every amount, 1030000 in the account record and 110000 in the details.
+const sampleFile = `01,SENDR1,RECVR1,181217,0600,FILE01,80,,2/
+02,RECVR1,BANKUS33,1,181216,2400,USD,2/
+03,123456789,USD,010,500000,,,015,420000,,,100,15000,1,Z,400,95000,2,Z/
+16,165,15000,Z,BR-1,CUST-1,ACME PAYROLL/
+16,475,50000,V,181215,,BR-2,CHK1001/
+88,CHECK 1001
+88,RENT DECEMBER
+16,495,45000,0,BR-3,,WIRE TO SUPPLIER, INVOICE 77/
+16,890,0,Z,BR-4,,INFO ONLY/
+49,1140000,8/
+98,1140000,1,10/
+99,1140000,1,12/`
+
+// TestParse checks that the records of a BAI2 file are mapped onto a camt053 statement.
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sampleFile))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, camt053.MESSAGE_TYPE_STATEMENT, doc.MessageType)
+	assert.NoError(t, camt053.ValidateDocument(doc))
+	assert.Equal(t, "2018-12-17T06:00:00Z", doc.Header().CreationDateTime.String())
+
+	stmts := doc.Statements()
+	assert.Len(t, stmts, 1)
+	stmt := stmts[0]
+	assert.Equal(t, "FILE01-20181216-123456789", stmt.Id)
+	assert.Equal(t, "123456789", stmt.Account.GetId())
+	assert.Equal(t, "USD", *stmt.Account.Currency)
+
+	// Status type codes become balances dated with the as of date
+	assert.Len(t, stmt.Balances, 2)
+	assert.Equal(t, "OPBD", stmt.Balances[0].TypeCode())
+	assert.Equal(t, "5000.00", stmt.Balances[0].Amount.Value.String())
+	assert.Equal(t, "CLBD", stmt.Balances[1].TypeCode())
+	assert.Equal(t, "2018-12-16", stmt.Balances[1].GetDate().String())
+
+	// Summary type codes become the transaction summary
+	assert.Equal(t, 1, stmt.TransactionSummary.TotalCreditEntries.NumberOfEntries)
+	assert.Equal(t, "150.00", stmt.TransactionSummary.TotalCreditEntries.Sum.String())
+	assert.Equal(t, 2, stmt.TransactionSummary.TotalDebitEntries.NumberOfEntries)
+	assert.Equal(t, "950.00", stmt.TransactionSummary.TotalDebitEntries.Sum.String())
+
+	// Non-monetary details are not loaded
+	entries := *stmt.Entries
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "CRDT", entries[0].CreditDebitIndicator)
+	assert.Equal(t, "150.00", entries[0].Amount.Value.String())
+	assert.Equal(t, "CUST-1", *entries[0].Reference)
+	assert.Equal(t, "BR-1", *entries[0].AccountServicerRef)
+	assert.Equal(t, "165", entries[0].BankTransactionCode.ProprietaryCode.Code)
+	assert.Equal(t, TRANSACTION_CODE_ISSUER, entries[0].BankTransactionCode.ProprietaryCode.Issuer)
+	assert.Equal(t, []string{"ACME PAYROLL"}, remittance(entries[0]))
+
+	assert.Equal(t, "DBIT", entries[1].CreditDebitIndicator)
+	assert.Equal(t, "2018-12-16", entries[1].BookingDate.Date.String())
+	assert.Equal(t, "2018-12-15", entries[1].ValueDate.Date.String())
+	assert.Equal(t, []string{"CHECK 1001", "RENT DECEMBER"}, remittance(entries[1]))
+
+	assert.Nil(t, entries[2].Reference)
+	assert.Equal(t, "2018-12-16", entries[2].ValueDate.Date.String())
+	assert.Equal(t, []string{"WIRE TO SUPPLIER, INVOICE 77"}, remittance(entries[2]))
+	assert.Empty(t, doc.Warnings)
+}
+
+// remittance returns the unstructured remittance information of an entry.
+func remittance(entry camt053.Entry) []string {
+	if entry.EntryDetails == nil {
+		return nil
+	}
+	return *(*(*entry.EntryDetails)[0].TransactionDetails)[0].RemittanceInformation.Unstructured
+}
+
+// TestParseIntraday checks that groups of interim same day data become intraday reports.
+func TestParseIntraday(t *testing.T) {
+	doc, err := Parse(strings.NewReader(strings.Replace(sampleFile, "USD,2/", "USD,3/", 1)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, camt053.MESSAGE_TYPE_REPORT, doc.MessageType)
+	assert.True(t, doc.IsIntraday())
+	assert.Len(t, doc.Statements(), 1)
+}
+
+// TestParseErrors checks that errors point at the offending record.
+func TestParseErrors(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name           string
+		old            string // Part of sampleFile to replace
+		new            string
+		expectedRecord string
+		expectedLine   int
+	}{
+		{"Unsupported version", ",80,,2/", ",80,,3/", "01", 1},
+		{"Invalid as of date", "181216", "181299", "02", 2},
+		{"Missing account number", "03,123456789,", "03,,", "03", 3},
+		{"Unknown funds type", "165,15000,Z", "165,15000,X", "16", 4},
+		{"Negative transaction amount", "165,15000", "165,-15000", "16", 4},
+		{"Type code that is not a transaction detail", "16,495", "16,095", "16", 8},
+		{"Type code that is not a number", "16,495", "16,X95", "16", 8},
+		{"Wrong account control total", "49,1140000,8/", "49,1140001,8/", "49", 10},
+		{"Wrong number of account records", "49,1140000,8/", "49,1140000,7/", "49", 10},
+		{"Wrong number of accounts", "98,1140000,1,10/", "98,1140000,2,10/", "98", 11},
+		{"Wrong number of file records", "99,1140000,1,12/", "99,1140000,1,11/", "99", 12},
+		{"Transaction outside account", "49,1140000,8/\n", "49,1140000,8/\n16,165,1,Z,,,/\n", "16", 11},
+		{"Unknown record code", "16,890", "17,890", "17", 9},
+		{"Missing file trailer", "\n99,1140000,1,12/", "", "", 11},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(strings.Replace(sampleFile, test.old, test.new, 1)))
+			var parseErr *ParseError
+			if !assert.True(t, errors.As(err, &parseErr), "expected a ParseError, got %v", err) {
+				return
+			}
+			assert.Equal(t, test.expectedRecord, parseErr.Record, parseErr.Error())
+			assert.Equal(t, test.expectedLine, parseErr.Line, parseErr.Error())
+		})
+	}
+}
+
+// TestParseWarnings checks that type codes that are not loaded are reported rather than rejecting the file.
+func TestParseWarnings(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name             string
+		old              string // Part of sampleFile to replace
+		new              string
+		expectedEntries  int
+		expectedWarnings []string
+	}{
+		{"Loan detail", "16,495", "16,720", 2, []string{
+			"record 16 (line 8): type code '720' is neither a credit (100-399) nor a debit (400-699), the transaction detail is not loaded",
+		}},
+		{"Custom detail", "16,495", "16,950", 2, []string{
+			"record 16 (line 8): type code '950' is neither a credit (100-399) nor a debit (400-699), the transaction detail is not loaded",
+		}},
+		{"Other summary codes", "400,95000,2,Z/", "400,95000,2,Z,102,0,,,402,0,,/", 3, []string{
+			"record 03 (line 3): summary type codes 102, 402 of account 123456789 are not loaded, only total credits (100) and total debits (400) are",
+		}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := Parse(strings.NewReader(strings.Replace(sampleFile, test.old, test.new, 1)))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, *doc.Statements()[0].Entries, test.expectedEntries)
+			assert.Equal(t, test.expectedWarnings, doc.Warnings)
+		})
+	}
+}
+
+// TestStatementId checks that statement ids are unique, can be used in a URL and are short enough to be valid.
+func TestStatementId(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		fileId        string
+		asOfDate      string
+		accountNumber string
+		expectedId    string
+	}{
+		{"FILE01", "181216", "123456789", "FILE01-20181216-123456789"},
+		{"FILE/01", "181216", "1234 5678", "FILE-01-20181216-12345678"},
+		{"FILE01", "181216", "SE4550000000058398257466", "FILE01-20181216-SE4550-e45a0f876f61"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.expectedId, func(t *testing.T) {
+			asOfDate, err := parseDate(test.asOfDate)
+			assert.NoError(t, err)
+			p := parser{fileId: test.fileId, asOfDate: asOfDate, ids: make(map[string]bool)}
+			id := p.statementId(test.accountNumber)
+			assert.Equal(t, test.expectedId, id)
+			assert.LessOrEqual(t, len(id), camt053.MAX_ID_LENGTH)
+
+			// The same account in another group of the file gets its own id
+			other := p.statementId(test.accountNumber)
+			assert.True(t, strings.HasSuffix(other, "-2"), other)
+			assert.LessOrEqual(t, len(other), camt053.MAX_ID_LENGTH)
+		})
+	}
+}
+
+// TestIsBAI2 checks that BAI2 files are told apart from XML documents and MT940 messages.
+func TestIsBAI2(t *testing.T) {
+	assert.True(t, IsBAI2([]byte(sampleFile)))
+	assert.True(t, IsBAI2([]byte("\n"+sampleFile)))
+	assert.False(t, IsBAI2([]byte(`<?xml version="1.0"?><Document/>`)))
+	assert.False(t, IsBAI2([]byte(":20:STMT\n:25:123")))
+}
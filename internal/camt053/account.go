@@ -1,25 +1,32 @@
 // package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
 package camt053
 
+import "strings"
+
+// Account identification schemes recognised by the API.
+const SCHEME_IBAN = "IBAN"
+const SCHEME_BBAN = "BBAN"
+
 // Account represents the 'Acct' XML tag.
 type Account struct {
 	//XMLName                  xml.Name `xml:"Acct"`
-	Id       AccountId     `xml:"Id" json:"id"`
-	Currency *string       `xml:"Ccy" json:"currency,omitempty"`
-	Owner    *AccountOwner `xml:"Ownr" json:"owner,omitempty"`
-	Servicer *Servicer     `xml:"Svcr" json:"servicer,omitempty"`
+	Id       AccountId          `xml:"Id" json:"id"`
+	Type     *CodeOrProprietary `xml:"Tp" json:"type,omitempty"` // From 001.03
+	Currency *string            `xml:"Ccy" json:"currency,omitempty"`
+	Name     *string            `xml:"Nm" json:"name,omitempty"` // From 001.03
+	Owner    *AccountOwner      `xml:"Ownr" json:"owner,omitempty"`
+	Servicer *Servicer          `xml:"Svcr" json:"servicer,omitempty"`
 }
 
-// GetId returns the accounts id.
-func (acc Account) GetId() uint64 {
-	return acc.Id.Other.Id
+// GetId returns the accounts id in its normalized form.
+func (acc Account) GetId() string {
+	return acc.Id.Normalized()
 }
 
 // AccountOwner represents the 'Ownr' XML tag.
 type AccountOwner struct {
-	Name     string    `xml:"Nm" json:"name"`
-	Id       AccountId `xml:"Id>OrgId>Othr"`
-	Servicer Servicer  `xml:"Svcr" json:"servicer,omitempty"`
+	Name string   `xml:"Nm,omitempty" json:"name"`
+	Id   *OtherId `xml:"Id>OrgId>Othr" json:"id,omitempty"`
 }
 
 // Account represents the 'Id>Othr' XML tag.
@@ -27,3 +34,63 @@ type AccountId struct {
 	IBAN  *string  `xml:"IBAN" json:"IBAN,omitempty"`
 	Other *OtherId `xml:"Othr" json:"other,omitempty"`
 }
+
+// Raw returns the identifier exactly as it was given in the document. IBAN takes precedence over 'Othr'.
+func (id AccountId) Raw() string {
+	if id.IBAN != nil {
+		return *id.IBAN
+	}
+	if id.Other != nil {
+		return id.Other.Id
+	}
+	return ""
+}
+
+// Normalized returns the identifier in the form used to look up accounts in the API.
+func (id AccountId) Normalized() string {
+	return NormalizeAccountId(id.Raw())
+}
+
+// Scheme returns the identification scheme of the account id, e.g. IBAN, BBAN or a proprietary scheme.
+func (id AccountId) Scheme() string {
+	if id.IBAN != nil {
+		return SCHEME_IBAN
+	}
+	if id.Other != nil {
+		return id.Other.Scheme()
+	}
+	return ""
+}
+
+// NormalizeAccountId removes all whitespace and upper cases an account identifier.
+// IBANs are frequently printed in groups of four characters, e.g. "SE45 5000 0000 0583 9825 7466",
+// and should resolve to the same account as the electronic format.
+func NormalizeAccountId(rawId string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(rawId), ""))
+}
+
+// IsValidIBAN checks the country code, length and the ISO 7064 mod 97-10 check digits of an IBAN.
+func IsValidIBAN(iban string) bool {
+	iban = NormalizeAccountId(iban)
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i, char := range iban {
+		isLetter := char >= 'A' && char <= 'Z'
+		isDigit := char >= '0' && char <= '9'
+		if (i < 2 && !isLetter) || (i >= 2 && i < 4 && !isDigit) || (!isLetter && !isDigit) {
+			return false
+		}
+	}
+
+	// Move the first four characters to the end and convert letters to numbers (A = 10 ... Z = 35)
+	remainder := 0
+	for _, char := range iban[4:] + iban[:4] {
+		if char >= 'A' {
+			remainder = (remainder*100 + int(char-'A'+10)) % 97
+		} else {
+			remainder = (remainder*10 + int(char-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
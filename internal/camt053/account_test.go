@@ -0,0 +1,64 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAccountId checks that IBAN, BBAN and proprietary account identifiers are unmarshaled and normalized.
+func TestAccountId(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name           string
+		input          string
+		expectedId     string
+		expectedScheme string
+	}{
+		{"IBAN", `<Acct><Id><IBAN>SE45 5000 0000 0583 9825 7466</IBAN></Id></Acct>`, "SE4550000000058398257466", SCHEME_IBAN},
+		{"BBAN", `<Acct><Id><Othr><Id>54400001111</Id><SchmeNm><Cd>BBAN</Cd></SchmeNm></Othr></Id></Acct>`, "54400001111", SCHEME_BBAN},
+		{"Proprietary scheme", `<Acct><Id><Othr><Id>1119993</Id><SchmeNm><Prtry>BGNR</Prtry></SchmeNm></Othr></Id></Acct>`, "1119993", "BGNR"},
+		{"Alphanumeric without scheme", `<Acct><Id><Othr><Id>DD01100056869</Id></Othr></Id></Acct>`, "DD01100056869", ""},
+		{"Missing id", `<Acct><Id></Id></Acct>`, "", ""},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var acc Account
+			err := xml.Unmarshal([]byte(test.input), &acc)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedId, acc.GetId())
+			assert.Equal(t, test.expectedScheme, acc.Id.Scheme())
+		})
+	}
+}
+
+// TestIsValidIBAN checks the IBAN check digit validation.
+func TestIsValidIBAN(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"SE4550000000058398257466", true},
+		{"se45 5000 0000 0583 9825 7466", true},
+		{"GB82WEST12345698765432", true},
+		{"GB82WEST12345698765433", false}, // Wrong check digits
+		{"DD01100056869", false},          // Too short
+		{"1234567890123456", false},       // Missing country code
+		{"", false},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsValidIBAN(test.input))
+		})
+	}
+}
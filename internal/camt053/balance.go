@@ -3,11 +3,27 @@ package camt053
 
 // Balance represents the 'Bal' XML tag.
 type Balance struct {
-	Type                 BalanceType `xml:"Tp" json:"type"`
-	CreditLine           *CreditLine `xml:"CdtLine" json:"creditLine,omitempty"`
-	Amount               Amount      `xml:"Amt" json:"amount"`
-	CreditDebitIndicator string      `xml:"CdtDbtInd" json:"creditDebitIndicator"`
-	Date                 string      `xml:"Dt>Dt" json:"date"`
+	Type                 BalanceType     `xml:"Tp" json:"type"`
+	CreditLine           *CreditLine     `xml:"CdtLine" json:"creditLine,omitempty"`
+	Amount               Amount          `xml:"Amt" json:"amount"`
+	CreditDebitIndicator string          `xml:"CdtDbtInd" json:"creditDebitIndicator"`
+	Date                 DateAndDateTime `xml:"Dt" json:"date"`
+}
+
+// GetDate returns the date of the balance, balances dated with a date time use its date in its own time zone offset.
+func (bal Balance) GetDate() Date {
+	return bal.Date.Day()
+}
+
+// TypeCode returns the code of the balance type, e.g. OPBD or CLBD, or its proprietary type if it has no code.
+func (bal Balance) TypeCode() string {
+	if bal.Type.CodeOrProprietary.Code != nil {
+		return *bal.Type.CodeOrProprietary.Code
+	}
+	if bal.Type.CodeOrProprietary.Proprietary != nil {
+		return *bal.Type.CodeOrProprietary.Proprietary
+	}
+	return ""
 }
 
 // BalanceType represents the 'Tp' XML tag.
@@ -0,0 +1,35 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBalanceDate checks that balances dated with either a date or a date time have a date.
+func TestBalanceDate(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name         string
+		xml          string
+		expectedType string
+		expectedDate string
+	}{
+		{"Date", "<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Dt><Dt>2018-12-17</Dt></Dt></Bal>", "CLBD", "2018-12-17"},
+		{"Date time", "<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Dt><DtTm>2023-09-30T20:00:00.000</DtTm></Dt></Bal>", "OPBD", "2023-09-30"},
+		{"Proprietary type", "<Bal><Tp><CdOrPrtry><Prtry>DAILY</Prtry></CdOrPrtry></Tp><Dt><Dt>2018-12-17</Dt></Dt></Bal>", "DAILY", "2018-12-17"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var balance Balance
+			assert.NoError(t, xml.Unmarshal([]byte(test.xml), &balance))
+			assert.Equal(t, test.expectedType, balance.TypeCode())
+			assert.Equal(t, test.expectedDate, balance.GetDate().String())
+		})
+	}
+}
@@ -6,35 +6,90 @@ import "encoding/xml"
 // Tom Payne had a good talk that gave me some pointers on how to work with encoding/xml
 
 // Document represnts the root 'Document' tag of the camt053 XML document.
+// camt.052 documents contain an AccountReport and camt.054 documents a Notification instead of a BankStatement.
 type Document struct {
-	XMLName       xml.Name                `xml:"Document"`
-	BankStatement BankToCustomerStatement `xml:"BkToCstmrStmt" json:"bankStatement"`
+	XMLName       xml.Name                               `xml:"Document"`
+	Version       string                                 `xml:"-" json:"version"`     // Detected from the namespace, e.g. 001.08
+	MessageType   string                                 `xml:"-" json:"messageType"` // camt.053, camt.052 or camt.054
+	BankStatement BankToCustomerStatement                `xml:"BkToCstmrStmt" json:"bankStatement"`
+	AccountReport *BankToCustomerAccountReport           `xml:"BkToCstmrAcctRpt" json:"accountReport,omitempty"`
+	Notification  *BankToCustomerDebitCreditNotification `xml:"BkToCstmrDbtCdtNtfctn" json:"notification,omitempty"`
+	Warnings      []string                               `xml:"-" json:"warnings,omitempty"` // Parts of a converted document that were not loaded
+}
+
+// IsIntraday checks if the document is a camt.052 intraday account report.
+func (doc Document) IsIntraday() bool {
+	return doc.AccountReport != nil
+}
+
+// IsNotification checks if the document is a camt.054 debit credit notification.
+func (doc Document) IsNotification() bool {
+	return doc.Notification != nil
+}
+
+// Header returns the group header of the statement, the account report or the notification.
+func (doc Document) Header() GroupHeader {
+	if doc.Notification != nil {
+		return doc.Notification.GroupHeader
+	}
+	if doc.AccountReport != nil {
+		return doc.AccountReport.GroupHeader
+	}
+	return doc.BankStatement.GroupHeader
+}
+
+// Statements returns the statements of a camt.053 document, the reports of a camt.052 document or
+// the notifications of a camt.054 document. The returned slice shares its elements with the document.
+func (doc Document) Statements() []Statement {
+	if doc.Notification != nil {
+		return doc.Notification.Notifications
+	}
+	if doc.AccountReport != nil {
+		return doc.AccountReport.Reports
+	}
+	return doc.BankStatement.Statements
 }
 
 // BankToCustomerStatement represents the 'BkToCstmrStmt' XML tag.
 type BankToCustomerStatement struct {
 	//XMLName     xml.Name    `xml:"BkToCstmrStmt"`
 	GroupHeader GroupHeader `xml:"GrpHdr"  json:"groupHeader"`
-	Statement   Statement   `xml:"Stmt" json:"Statement"`
+	Statements  []Statement `xml:"Stmt" json:"statements"`
+}
+
+// BankToCustomerAccountReport represents the 'BkToCstmrAcctRpt' XML tag of camt.052 documents.
+// A report ('Rpt') has the same structure as a statement.
+type BankToCustomerAccountReport struct {
+	GroupHeader GroupHeader `xml:"GrpHdr" json:"groupHeader"`
+	Reports     []Statement `xml:"Rpt" json:"reports"`
+}
+
+// BankToCustomerDebitCreditNotification represents the 'BkToCstmrDbtCdtNtfctn' XML tag of camt.054 documents.
+// A notification ('Ntfctn') has the same structure as a statement, without balances.
+type BankToCustomerDebitCreditNotification struct {
+	GroupHeader   GroupHeader `xml:"GrpHdr" json:"groupHeader"`
+	Notifications []Statement `xml:"Ntfctn" json:"notifications"`
 }
 
 // GroupHeader represents the 'GrpHdr' XML tag.
 type GroupHeader struct {
 	//XMLName         xml.Name `xml:"GrpHdr"`
-	MessageId         int                `xml:"MsgId" json:"messageId"`
-	CreationDateTime  string             `xml:"CreDtTm" json:"creationDateTime"`
-	MessageRecipient  *MessageRecipient  `xml:"MsgRcpt" json:"messageRecipient,omitempty`
+	MessageId         string             `xml:"MsgId" json:"messageId"`
+	CreationDateTime  DateTime           `xml:"CreDtTm" json:"creationDateTime"`
+	MessageRecipient  *MessageRecipient  `xml:"MsgRcpt" json:"messageRecipient,omitempty"`
 	MessagePagination *MessagePagination `xml:"MsgPgntn,omitempty" json:"messagePagination,omitempty"`
 }
 
 // MessageRecipient represents the 'MsgRcpt' XML tag.
 type MessageRecipient struct {
-	Name string  `xml:"Nm" json:"name,omitempty"`
-	Id   OtherId `xml:"Id>OrgId>Othr" json: "id"`
+	Name string  `xml:"Nm,omitempty" json:"name,omitempty"`
+	Id   OtherId `xml:"Id>OrgId>Othr" json:"id"`
 }
 
-// MessagePagination represents the 'MsgPgntn' XML tag. (not implemented)
+// MessagePagination represents the 'MsgPgntn' XML tag.
 type MessagePagination struct {
+	PageNumber        string `xml:"PgNb" json:"pageNumber"`
+	LastPageIndicator bool   `xml:"LastPgInd" json:"lastPageIndicator"`
 }
 
 // Statement represents the 'Stmt' XML tag.
@@ -43,7 +98,7 @@ type Statement struct {
 	Id                       string              `xml:"Id" json:"id"`
 	ElectronicSequenceNumber *int                `xml:"ElctrncSeqNb" json:"electronicSequenceNumber,omitempty"`
 	LegalSequenceNumber      *int                `xml:"LglSeqNb" json:"legalSequenceNumber,omitempty"`
-	CreationDateTime         string              `xml:"CreDtTm" json:"createdDateTime"`
+	CreationDateTime         DateTime            `xml:"CreDtTm" json:"createdDateTime"`
 	FromDate                 *FromDate           `xml:"FrToDt" json:"fromDate,omitempty"`
 	Account                  Account             `xml:"Acct" json:"account"`
 	Balances                 []Balance           `xml:"Bal" json:"balances"`
@@ -53,14 +108,60 @@ type Statement struct {
 
 // TransactionSummary represents the 'TxsSummry' XML tag.
 type TransactionSummary struct {
+	TotalEntries       *TotalEntries     `xml:"TtlNtries" json:"totalEntries,omitempty"`
 	TotalCreditEntries *CreditDebitEntry `xml:"TtlCdtNtries" json:"totalCreditEntries,omitempty"`
 	TotalDebitEntries  *CreditDebitEntry `xml:"TtlDbtNtries" json:"totalDebitEntries,omitempty"`
 }
 
+// TotalEntries represents the 'TtlNtries' XML tag.
+type TotalEntries struct {
+	NumberOfEntries      int      `xml:"NbOfNtries" json:"numberOfEntries"`
+	Sum                  *Decimal `xml:"Sum" json:"sum,omitempty"`
+	TotalNetEntryAmount  *Decimal `xml:"TtlNetNtryAmt" json:"totalNetEntryAmount,omitempty"`
+	CreditDebitIndicator string   `xml:"CdtDbtInd,omitempty" json:"creditDebitIndicator,omitempty"`
+}
+
 // CreditDebitEntry represents the 'TtlCdtNtries' and the 'TtlDbtNtries' XML tags.
 type CreditDebitEntry struct {
-	NumberOfEntries int    `xml:"NbOfNtries" json:"numberOfEntries"`
-	Sum             string `xml:"Sum" json:"sum"`
+	NumberOfEntries int      `xml:"NbOfNtries" json:"numberOfEntries"`
+	Sum             *Decimal `xml:"Sum" json:"sum,omitempty"`
+}
+
+// Summarize sums up the credit and debit entries of a statement, the net amount is credits minus debits.
+// The entries are expected to be in the same currency, the currency of the account. Statements without entries have no summary.
+func Summarize(entries []Entry) *TransactionSummary {
+	if len(entries) == 0 {
+		return nil
+	}
+	zero := NewDecimal(0, MinorUnits(entries[0].Amount.Currency))
+	creditSum, debitSum := zero, zero
+	credits, debits := CreditDebitEntry{Sum: &creditSum}, CreditDebitEntry{Sum: &debitSum}
+	for _, entry := range entries {
+		if entry.CreditDebitIndicator == "DBIT" {
+			debits.NumberOfEntries++
+			debitSum = debitSum.Add(entry.Amount.Value)
+		} else {
+			credits.NumberOfEntries++
+			creditSum = creditSum.Add(entry.Amount.Value)
+		}
+	}
+
+	total := creditSum.Add(debitSum)
+	net := creditSum.Sub(debitSum)
+	indicator := "CRDT"
+	if net.Sign() < 0 {
+		net, indicator = net.Neg(), "DBIT"
+	}
+	return &TransactionSummary{
+		TotalEntries: &TotalEntries{
+			NumberOfEntries:      credits.NumberOfEntries + debits.NumberOfEntries,
+			Sum:                  &total,
+			TotalNetEntryAmount:  &net,
+			CreditDebitIndicator: indicator,
+		},
+		TotalCreditEntries: &credits,
+		TotalDebitEntries:  &debits,
+	}
 }
 
 // CodePorProprietary represents the 'CdOrPrtry' XML tag.
@@ -69,31 +170,55 @@ type CodeOrProprietary struct {
 	Proprietary *string `xml:"Prtry" json:"proprietary,omitempty"`
 }
 
-// Amount represents the 'Amt' XML tag.
-type Amount struct {
-	Currency string `xml:"Ccy,attr" json:"currency"`
-	Value    string `xml:",chardata" json:"value"`
-}
-
 // FromDate represents the 'FrToDt' XML tag.
 type FromDate struct {
-	FromDateTime string `xml:"FrDtTm" json:"fromDateTime"`
-	ToDateTime   string `xml:"ToDtTm" json:"toDateTime"`
+	FromDateTime DateTime `xml:"FrDtTm" json:"fromDateTime"`
+	ToDateTime   DateTime `xml:"ToDtTm" json:"toDateTime"`
 }
 
 // OtherId represents the 'Othr' XML tag which can be found nested inside Id tags.
 type OtherId struct {
-	Id         uint64 `xml:"Id" json:"id"`
-	SchemeName string `xml:"SchmeNm>Cd" json:"schemeName"`
+	Id                    string  `xml:"Id" json:"id"`
+	SchemeName            string  `xml:"SchmeNm>Cd,omitempty" json:"schemeName,omitempty"`
+	ProprietarySchemeName *string `xml:"SchmeNm>Prtry" json:"proprietarySchemeName,omitempty"`
+	Issuer                *string `xml:"Issr" json:"issuer,omitempty"`
 }
 
-// FinancialInstitutionId represents the 'FinInstId' XML tag.
+// Scheme returns the scheme code of the identifier, falling back on the proprietary scheme name.
+func (o OtherId) Scheme() string {
+	if o.SchemeName == "" && o.ProprietarySchemeName != nil {
+		return *o.ProprietarySchemeName
+	}
+	return o.SchemeName
+}
+
+// FinancialInstitutionId represents the 'FinInstnId' XML tag. The BIC is called 'BIC' up to 001.04 and 'BICFI' from 001.05.
 type FinancialInstitutionId struct {
-	BIC  *string `xml:"BIC" json:"bicCode,omitempty"`
-	Name *string `xml:"Nm" json:"name,omitempty"`
+	BIC  *string `json:"bicCode,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
+// rawFinancialInstitutionId contains the elements of 'FinInstnId' in every version.
+type rawFinancialInstitutionId struct {
+	BIC   *string `xml:"BIC"`
+	BICFI *string `xml:"BICFI"`
+	Name  *string `xml:"Nm"`
+}
+
+// UnmarshalXML reads the BIC from either 'BIC' or 'BICFI'.
+func (f *FinancialInstitutionId) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw rawFinancialInstitutionId
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	f.BIC, f.Name = raw.BIC, raw.Name
+	if f.BIC == nil {
+		f.BIC = raw.BICFI
+	}
+	return nil
 }
 
-//Servicer represents the 'Svcr' XML tag.
+// Servicer represents the 'Svcr' XML tag.
 type Servicer struct {
 	FinancialInstitutionId FinancialInstitutionId `xml:"FinInstnId" json:"financialInstitutionId,omitempty"`
 }
@@ -0,0 +1,109 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"regexp"
+)
+
+// Number of decimals of currencies that do not use 2 decimals, according to ISO 4217.
+var currencyMinorUnits = map[string]int{
+	// No minor unit
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// Thousandths
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// Ten thousandths
+	"CLF": 4, "UYW": 4,
+}
+
+const DEFAULT_MINOR_UNITS = 2
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// MinorUnits returns the number of decimals used by a ISO 4217 currency, e.g. 2 for SEK and 0 for JPY.
+func MinorUnits(currency string) int {
+	if units, ok := currencyMinorUnits[currency]; ok {
+		return units
+	}
+	return DEFAULT_MINOR_UNITS
+}
+
+// Amount represents the 'Amt' XML tag.
+type Amount struct {
+	Currency string
+	Value    Decimal
+}
+
+// rawAmount is how amounts are represented in XML and JSON.
+type rawAmount struct {
+	Currency string  `xml:"Ccy,attr" json:"currency"`
+	Value    Decimal `xml:",chardata" json:"value"`
+}
+
+// NewAmount validates an amount, the currency needs to be a ISO 4217 code and the value can not
+// be negative or have more decimals than the currency. The value is scaled to the decimals of the currency.
+func NewAmount(currency string, value Decimal) (Amount, error) {
+	if !currencyPattern.MatchString(currency) {
+		return Amount{}, errors.New("currency '" + currency + "' is not a ISO 4217 currency code")
+	}
+	if value.Sign() < 0 {
+		return Amount{}, errors.New("amount " + value.String() + " is negative, use the credit debit indicator for the sign")
+	}
+	scaled, err := value.Rescale(MinorUnits(currency))
+	if err != nil {
+		return Amount{}, errors.New("amount " + value.String() + " has more decimals than " + currency + " allows")
+	}
+	return Amount{Currency: currency, Value: scaled}, nil
+}
+
+// String formats the amount with its currency, e.g. "1250.50 SEK".
+func (a Amount) String() string {
+	return a.Value.String() + " " + a.Currency
+}
+
+// Signed returns the value of the amount, negative if the credit debit indicator is DBIT.
+func (a Amount) Signed(creditDebitIndicator string) Decimal {
+	if creditDebitIndicator == "DBIT" {
+		return a.Value.Neg()
+	}
+	return a.Value
+}
+
+func (a Amount) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(rawAmount(a), start)
+}
+
+// UnmarshalXML parses and validates an amount, see NewAmount.
+func (a *Amount) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw rawAmount
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	amount, err := NewAmount(raw.Currency, raw.Value)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rawAmount(a))
+}
+
+// UnmarshalJSON parses and validates an amount, see NewAmount.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var raw rawAmount
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	amount, err := NewAmount(raw.Currency, raw.Value)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
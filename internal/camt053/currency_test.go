@@ -0,0 +1,80 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAmountXML checks that amounts are validated and scaled to the minor units of their currency.
+func TestAmountXML(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		xml           string
+		expectedValue string
+		expectError   bool
+	}{
+		{"Two decimals", `<Amt Ccy="SEK">4408320.31</Amt>`, "4408320.31", false},
+		{"Scaled to currency", `<Amt Ccy="SEK">2500000</Amt>`, "2500000.00", false},
+		{"No minor unit", `<Amt Ccy="JPY">1500</Amt>`, "1500", false},
+		{"Three decimals", `<Amt Ccy="KWD">10.5</Amt>`, "10.500", false},
+		{"Trailing zeros", `<Amt Ccy="USD">10.5000</Amt>`, "10.50", false},
+		{"Whitespace", "<Amt Ccy=\"EUR\">\n  99.99\n</Amt>", "99.99", false},
+		{"Too many decimals", `<Amt Ccy="SEK">10.005</Amt>`, "", true},
+		{"Too many decimals for currency", `<Amt Ccy="JPY">10.5</Amt>`, "", true},
+		{"Negative", `<Amt Ccy="SEK">-10.00</Amt>`, "", true},
+		{"Malformed", `<Amt Ccy="SEK">10,00</Amt>`, "", true},
+		{"Empty", `<Amt Ccy="SEK"></Amt>`, "", true},
+		{"Missing currency", `<Amt>10.00</Amt>`, "", true},
+		{"Invalid currency", `<Amt Ccy="kronor">10.00</Amt>`, "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var amount Amount
+			err := xml.Unmarshal([]byte(test.xml), &amount)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedValue, amount.Value.String())
+		})
+	}
+}
+
+// TestAmountJSON checks that amounts are serialised with the decimals of their currency and as strings.
+func TestAmountJSON(t *testing.T) {
+	var amount Amount
+	assert.NoError(t, xml.Unmarshal([]byte(`<Amt Ccy="SEK">2500000</Amt>`), &amount))
+
+	data, err := json.Marshal(amount)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"currency":"SEK","value":"2500000.00"}`, string(data))
+
+	var decoded Amount
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, amount, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"currency":"SEK","value":10.5}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"currency":"SEK","value":"10.505"}`), &decoded))
+
+	data, err = xml.Marshal(amount)
+	assert.NoError(t, err)
+	assert.Equal(t, `<Amount Ccy="SEK">2500000.00</Amount>`, string(data))
+}
+
+// TestSignedAmount checks that debit amounts are negative.
+func TestSignedAmount(t *testing.T) {
+	amount, err := NewAmount("SEK", NewDecimal(1050, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.50", amount.Signed("CRDT").String())
+	assert.Equal(t, "-10.50", amount.Signed("DBIT").String())
+	assert.Equal(t, "10.50 SEK", amount.String())
+}
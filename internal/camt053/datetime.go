@@ -0,0 +1,211 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+const ISO_DATE_FORMAT = "2006-01-02"
+
+// Date formats accepted by ParseDate, ISO dates may have a time zone which is ignored.
+var dateLayouts = []string{"2006-01-02", "2006-01-02Z07:00"}
+
+// Date time formats accepted by ParseDateTime. Fractional seconds are accepted by all of them.
+// Date times without a time zone are assumed to be in UTC.
+var dateTimeLayouts = []string{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05"}
+
+// Date is a calendar date without a time zone, such as a booking date.
+type Date struct {
+	t time.Time // Midnight UTC
+}
+
+// NewDate creates a date.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a ISO 8601 date, e.g. "2018-12-17". Time zones, e.g. "2018-12-17+01:00", are ignored.
+func ParseDate(raw string) (Date, error) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return NewDate(t.Date()), nil
+		}
+	}
+	return Date{}, errors.New("'" + raw + "' is not a ISO date (YYYY-MM-DD)")
+}
+
+// IsZero checks if the date has been set.
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// Time returns midnight UTC of the date.
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+// Compare returns -1 if d is before other, 0 if they are the same date and 1 if d is after other.
+func (d Date) Compare(other Date) int {
+	return d.t.Compare(other.t)
+}
+
+// String formats the date as YYYY-MM-DD.
+func (d Date) String() string {
+	return d.t.Format(ISO_DATE_FORMAT)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// DateTime is a point in time together with the time zone offset it was given in, which decides its date.
+type DateTime struct {
+	t time.Time // In UTC or a fixed zone with the offset
+}
+
+// NewDateTime creates a date time from a time, keeping the time zone offset of the time.
+func NewDateTime(t time.Time) DateTime {
+	if _, offset := t.Zone(); offset != 0 {
+		return DateTime{t: t.In(time.FixedZone("", offset))}
+	}
+	return DateTime{t: t.UTC()}
+}
+
+// ParseDateTime parses a ISO 8601 date time with or without fractional seconds and time zone,
+// e.g. "2018-12-17T10:00:00+01:00" or "2023-09-30T20:00:00.000". Date times without a time zone are assumed to be in UTC.
+func ParseDateTime(raw string) (DateTime, error) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return NewDateTime(t), nil
+		}
+	}
+	return DateTime{}, errors.New("'" + raw + "' is not a ISO date time (YYYY-MM-DDThh:mm:ss)")
+}
+
+// IsZero checks if the date time has been set.
+func (dt DateTime) IsZero() bool {
+	return dt.t.IsZero()
+}
+
+// Time returns the date time as a UTC time.
+func (dt DateTime) Time() time.Time {
+	return dt.t.UTC()
+}
+
+// Date returns the date of the date time in its own time zone offset, e.g. 2018-12-17 for 2018-12-17T00:30:00+01:00.
+func (dt DateTime) Date() Date {
+	return NewDate(dt.t.Date())
+}
+
+// Compare returns -1 if dt is before other, 0 if they are equal and 1 if dt is after other.
+func (dt DateTime) Compare(other DateTime) int {
+	return dt.t.Compare(other.t)
+}
+
+// String formats the date time with its time zone offset, e.g. "2018-12-17T10:00:00+01:00" or "2018-12-17T09:00:00Z" in UTC.
+// Fractional seconds are only included if they are not zero.
+func (dt DateTime) String() string {
+	return dt.t.Format(time.RFC3339Nano)
+}
+
+func (dt DateTime) MarshalText() ([]byte, error) {
+	return []byte(dt.String()), nil
+}
+
+func (dt *DateTime) UnmarshalText(text []byte) error {
+	parsed, err := ParseDateTime(string(text))
+	if err != nil {
+		return err
+	}
+	*dt = parsed
+	return nil
+}
+
+// DateAndDateTime represents the choice between a 'Dt' and a 'DtTm' XML tag, e.g. in 'BookgDt' and 'Bal/Dt'.
+// In JSON it is a single string, either a date or a date time.
+type DateAndDateTime struct {
+	Date     *Date     `xml:"Dt,omitempty"`
+	DateTime *DateTime `xml:"DtTm,omitempty"`
+}
+
+// Day returns the date, or the date of the date time in its own time zone offset.
+func (d DateAndDateTime) Day() Date {
+	if d.DateTime != nil {
+		return d.DateTime.Date()
+	}
+	if d.Date != nil {
+		return *d.Date
+	}
+	return Date{}
+}
+
+// IsZero checks if neither a date nor a date time has been set.
+func (d DateAndDateTime) IsZero() bool {
+	return d.Date == nil && d.DateTime == nil
+}
+
+// String formats the date or the date time.
+func (d DateAndDateTime) String() string {
+	if d.DateTime != nil {
+		return d.DateTime.String()
+	}
+	if d.Date != nil {
+		return d.Date.String()
+	}
+	return ""
+}
+
+// UnmarshalXML makes sure that exactly one of date and date time is set.
+func (d *DateAndDateTime) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	type choice DateAndDateTime // Avoids calling UnmarshalXML recursively
+	var parsed choice
+	if err := decoder.DecodeElement(&parsed, &start); err != nil {
+		return err
+	}
+	if (parsed.Date == nil) == (parsed.DateTime == nil) {
+		return errors.New(start.Name.Local + " must contain either a Dt or a DtTm")
+	}
+	*d = DateAndDateTime(parsed)
+	return nil
+}
+
+func (d DateAndDateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON parses a date time if the string contains a time, otherwise a date.
+func (d *DateAndDateTime) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if strings.Contains(raw, "T") {
+		dateTime, err := ParseDateTime(raw)
+		if err != nil {
+			return err
+		}
+		*d = DateAndDateTime{DateTime: &dateTime}
+		return nil
+	}
+	date, err := ParseDate(raw)
+	if err != nil {
+		return err
+	}
+	*d = DateAndDateTime{Date: &date}
+	return nil
+}
@@ -0,0 +1,133 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseDate checks the accepted ISO date formats.
+func TestParseDate(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"2018-12-17", "2018-12-17", false},
+		{" 2018-12-17\n", "2018-12-17", false},
+		{"2018-12-17+01:00", "2018-12-17", false},
+		{"2018-12-17Z", "2018-12-17", false},
+		{"2018-12-32", "", true},
+		{"2018-13-01", "", true},
+		{"2018-2-1", "", true},
+		{"17/12/2018", "", true},
+		{"2018-12-17T10:00:00", "", true},
+		{"", "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			date, err := ParseDate(test.input)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, date.String())
+		})
+	}
+}
+
+// TestParseDateTime checks that the date time formats of the sample files are accepted and keep their time zone offset.
+func TestParseDateTime(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"2018-12-18T07:07:37", "2018-12-18T07:07:37Z", false},
+		{"2018-12-18T07:07:37+01:00", "2018-12-18T07:07:37+01:00", false},
+		{"2018-12-18T07:07:37+00:00", "2018-12-18T07:07:37Z", false},
+		{"2023-09-30T20:00:00.000", "2023-09-30T20:00:00Z", false},
+		{"2023-09-30T23:59:00.000Z", "2023-09-30T23:59:00Z", false},
+		{"2023-10-01T00:30:00.250-05:00", "2023-10-01T00:30:00.25-05:00", false},
+		{"2018-12-18", "", true},
+		{"2018-12-18 07:07:37", "", true},
+		{"2018-12-18T25:00:00", "", true},
+		{"yesterday", "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			dateTime, err := ParseDateTime(test.input)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, dateTime.String())
+		})
+	}
+
+	// Equal instants in different time zones are equal
+	a, _ := ParseDateTime("2018-12-18T07:00:00+01:00")
+	b, _ := ParseDateTime("2018-12-18T06:00:00Z")
+	assert.Equal(t, 0, a.Compare(b))
+	assert.Equal(t, b.Time(), a.Time())
+
+	// The date is the date in the time zone offset of the date time
+	c, _ := ParseDateTime("2018-12-17T00:30:00+01:00")
+	assert.Equal(t, "2018-12-17", c.Date().String())
+	assert.Equal(t, "2018-12-16", c.Time().Format(ISO_DATE_FORMAT))
+}
+
+// TestDateAndDateTime checks that either a date or a date time can be used and that they are serialised as strings.
+func TestDateAndDateTime(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name         string
+		xml          string
+		expectedJSON string
+		expectedDay  string
+		expectError  bool
+	}{
+		{"Date", "<BookgDt><Dt>2018-12-17</Dt></BookgDt>", `"2018-12-17"`, "2018-12-17", false},
+		{"Date time", "<BookgDt><DtTm>2023-09-30T20:00:00.000</DtTm></BookgDt>", `"2023-09-30T20:00:00Z"`, "2023-09-30", false},
+		{"Date time with offset", "<BookgDt><DtTm>2018-12-17T00:30:00+01:00</DtTm></BookgDt>", `"2018-12-17T00:30:00+01:00"`, "2018-12-17", false},
+		{"Both", "<BookgDt><Dt>2018-12-17</Dt><DtTm>2018-12-17T10:00:00</DtTm></BookgDt>", "", "", true},
+		{"Neither", "<BookgDt></BookgDt>", "", "", true},
+		{"Invalid date", "<BookgDt><Dt>2018-02-30</Dt></BookgDt>", "", "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var date DateAndDateTime
+			err := xml.Unmarshal([]byte(test.xml), &date)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedDay, date.Day().String())
+
+			data, err := json.Marshal(date)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedJSON, string(data))
+
+			var decoded DateAndDateTime
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, date.String(), decoded.String())
+		})
+	}
+}
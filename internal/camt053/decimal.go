@@ -0,0 +1,151 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Decimal is an exact decimal number, such as an amount of money. It is stored as an unscaled
+// integer and a scale, the number of digits after the decimal point, so 12.50 is 1250 with scale 2.
+// The zero value is 0 with scale 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal creates the decimal unscaled * 10^-scale.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a decimal number such as "1250.50" or "-3". Exponents and thousand separators are not allowed.
+func ParseDecimal(raw string) (Decimal, error) {
+	raw = strings.TrimSpace(raw)
+	if !decimalPattern.MatchString(raw) {
+		return Decimal{}, errors.New("'" + raw + "' is not a decimal number")
+	}
+
+	integer, fraction, _ := strings.Cut(raw, ".")
+	unscaled, ok := new(big.Int).SetString(integer+fraction, 10)
+	if !ok {
+		return Decimal{}, errors.New("'" + raw + "' is not a decimal number")
+	}
+	return Decimal{unscaled: unscaled, scale: len(fraction)}, nil
+}
+
+// int returns the unscaled value, treating the zero value as 0.
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 depending on if the decimal is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero checks if the decimal is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Rescale returns the decimal with a new scale. Decreasing the scale fails if it would drop non zero digits.
+func (d Decimal) Rescale(scale int) (Decimal, error) {
+	if scale < 0 {
+		return d, errors.New("scale can not be negative")
+	}
+	if scale >= d.scale {
+		factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.scale)), nil)
+		return Decimal{unscaled: new(big.Int).Mul(d.int(), factor), scale: scale}, nil
+	}
+
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale-scale)), nil)
+	quotient, remainder := new(big.Int).QuoRem(d.int(), factor, new(big.Int))
+	if remainder.Sign() != 0 {
+		return d, errors.New(d.String() + " has more than " + strconv.Itoa(scale) + " decimals")
+	}
+	return Decimal{unscaled: quotient, scale: scale}, nil
+}
+
+// align returns both decimals with the largest of their scales.
+func align(a Decimal, b Decimal) (*big.Int, *big.Int, int) {
+	scale := max(a.scale, b.scale)
+	a, _ = a.Rescale(scale) // Increasing the scale can not fail
+	b, _ = b.Rescale(scale)
+	return a.int(), b.int(), scale
+}
+
+// Add returns d + other, with the largest scale of the two.
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - other, with the largest scale of the two.
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Cmp compares the values of two decimals regardless of scale, returning -1 if d < other, 0 if d == other and 1 if d > other.
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Rat returns the decimal as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.int(), denominator)
+}
+
+// String formats the decimal with all of its decimals, e.g. "1250.50".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalText formats the decimal for XML and JSON, JSON decimals are strings so that no precision is lost.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a decimal from XML or JSON.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,78 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseDecimal(t *testing.T, raw string) Decimal {
+	d, err := ParseDecimal(raw)
+	if err != nil {
+		t.Fatalf("failed to parse decimal '%s': %s", raw, err)
+	}
+	return d
+}
+
+// TestParseDecimal checks that decimals keep their precision and are formatted the way they were written.
+func TestParseDecimal(t *testing.T) {
+	for _, valid := range []string{"0", "0.00", "100", "100.5", "242041.00", "-3.14", "0.001", "123456789012345678901234567890.123456789"} {
+		assert.Equal(t, valid, mustParseDecimal(t, valid).String())
+	}
+	for _, invalid := range []string{"", "1/3", "1e5", "10.", ".5", "1,000.00", "+5", "abc", "0x10"} {
+		_, err := ParseDecimal(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// The zero value is zero
+	assert.Equal(t, "0", Decimal{}.String())
+	assert.True(t, Decimal{}.IsZero())
+}
+
+// TestDecimalArithmetic checks that decimal arithmetic is exact.
+func TestDecimalArithmetic(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name     string
+		result   Decimal
+		expected string
+	}{
+		{"Add", mustParseDecimal(t, "0.1").Add(mustParseDecimal(t, "0.2")), "0.3"},
+		{"Add scales", mustParseDecimal(t, "100").Add(mustParseDecimal(t, "0.05")), "100.05"},
+		{"Sub", mustParseDecimal(t, "10.00").Sub(mustParseDecimal(t, "10.01")), "-0.01"},
+		{"Neg", mustParseDecimal(t, "4408320.31").Neg(), "-4408320.31"},
+		{"Neg zero", Decimal{}.Neg(), "0"},
+		{"New", NewDecimal(-5, 3), "-0.005"},
+		{"Zero value", Decimal{}.Add(mustParseDecimal(t, "1.50")), "1.50"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.result.String())
+		})
+	}
+
+	assert.Equal(t, 0, mustParseDecimal(t, "1.50").Cmp(mustParseDecimal(t, "1.5")))
+	assert.Equal(t, -1, mustParseDecimal(t, "-2").Cmp(mustParseDecimal(t, "1")))
+	assert.Equal(t, 1, mustParseDecimal(t, "0.10").Cmp(mustParseDecimal(t, "0.09")))
+	assert.Equal(t, "3/2", mustParseDecimal(t, "1.50").Rat().RatString())
+}
+
+// TestDecimalRescale checks that rescaling never loses digits.
+func TestDecimalRescale(t *testing.T) {
+	scaled, err := mustParseDecimal(t, "12.5").Rescale(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.50", scaled.String())
+
+	scaled, err = mustParseDecimal(t, "12.500").Rescale(0)
+	assert.Error(t, err)
+	scaled, err = mustParseDecimal(t, "12.000").Rescale(0)
+	assert.NoError(t, err)
+	assert.Equal(t, "12", scaled.String())
+
+	_, err = mustParseDecimal(t, "1").Rescale(-1)
+	assert.Error(t, err)
+}
@@ -1,22 +1,29 @@
 // package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
 package camt053
 
+import (
+	"encoding/xml"
+	"strings"
+)
+
 // Entry represents the 'Ntry' XML tag.
 type Entry struct {
 	Reference            *string `xml:"NtryRef" json:"reference"`
 	URLReference         *string `xml:"-" json:"urlReference"` // Not part of camt053, used as resource ref in API.
+	Intraday             bool    `xml:"-" json:"intraday"`     // Not part of camt053, set on entries from camt052 reports until a camt053 statement settles them.
 	Amount               Amount  `xml:"Amt" json:"amount"`
 	CreditDebitIndicator string  `xml:"CdtDbtInd" json:"creditDebitIndicator"`
 	//RvslInd optional
-	Status              string              `xml:"Sts" json:"status"`
-	BookingDate         *string             `xml:"BookgDt>Dt" json:"bookingDate,omitempty"`
-	ValueDate           *string             `xml:"ValDt>Dt" json:"valueDate,omitempty"`
+	Status              EntryStatus         `xml:"Sts" json:"status"`
+	BookingDate         *DateAndDateTime    `xml:"BookgDt" json:"bookingDate,omitempty"`
+	ValueDate           *DateAndDateTime    `xml:"ValDt" json:"valueDate,omitempty"`
 	AccountServicerRef  *string             `xml:"AcctSvcrRef" json:"accountServicerRef,omitempty"`
 	BankTransactionCode BankTransactionCode `xml:"BkTxCd" json:"bankTransactionCode,omitempty"`
 	AmountDetails       *AmountDetails      `xml:"AmtDtls" json:"amountDetails,omitempty"`
 	Charges             *[]Charge           `xml:"Chrgs" json:"charges,omitempty"`
 	//TechInptChanl, optional
-	EntryDetails *[]EntryDetail `xml:"NtryDtls" json:"entryDetails,omitempty"`
+	EntryDetails          *[]EntryDetail `xml:"NtryDtls" json:"entryDetails,omitempty"`
+	AdditionalInformation *string        `xml:"AddtlNtryInf" json:"additionalInformation,omitempty"`
 }
 
 // BankTransactionCode represents the 'BkTxCd' XML tag.
@@ -40,7 +47,7 @@ type BankTransactionCodeFamily struct {
 // BanTransactionProprietaryCode represents the 'Prtry' XML tag inside bank transaction code sections.
 type BankTransactionProprietaryCode struct {
 	Code   string `xml:"Cd" json:"code"`
-	Issuer string `xml:"Issr" json:"issuer"`
+	Issuer string `xml:"Issr,omitempty" json:"issuer"`
 }
 
 // AmountDetails represents the 'AmtDtls' XML tag.
@@ -66,7 +73,7 @@ type ProprietaryAmount struct {
 
 // Charge represents the 'Chrgs' XML tag. (not part of test data set)
 type Charge struct { // Charges are specified in 2.172 and 2.152 in SEB MIG camt052-053-054v2 spec
-	TotalChargesAndTaxAmount *string `xml:"TtlChrgsAndTaxAmt" json:"totalChargesAndTaxAmount,omitempty"`
+	TotalChargesAndTaxAmount *Amount `xml:"TtlChrgsAndTaxAmt" json:"totalChargesAndTaxAmount,omitempty"`
 	Amount                   Amount  `xml:"Amt" json:"amount"`
 	Type                     *string `xml:"Tp>Cd" json:"type"`
 	Rate                     *string `xml:"Rate" json:"rate"`
@@ -83,8 +90,8 @@ type EntryDetail struct {
 
 // BatchInformation represents the 'Btch' XML tag.
 type BatchInformation struct {
-	MessageId            string `xml:"MsgId" json:"messageId"`
-	PaymentInformationId string `xml:"PmtInfId" json:"paymentInformationId"`
+	MessageId            string `xml:"MsgId,omitempty" json:"messageId"`
+	PaymentInformationId string `xml:"PmtInfId,omitempty" json:"paymentInformationId"`
 	//NbOfTxs, optional
 	//TtlAmt, optional
 	//CdtDbtInd, optional
@@ -135,7 +142,7 @@ type CreditorReferenceInformation struct {
 // RefferedDocumentInformation represents the 'RfrdDocInf' XML tag.
 type ReferredDocumentInformation struct {
 	Type   CodeOrProprietary `xml:"Tp>CdOrPrtry" json:"type,omitempty"`
-	Number string            `xml:"Nb" json:"number,omitempty"`
+	Number string            `xml:"Nb,omitempty" json:"number,omitempty"`
 }
 
 // ReferredDocumentAmount represents the 'RfrdDocAmt' XML tag.
@@ -143,3 +150,28 @@ type ReferredDocumentAmount struct {
 	RemittedAmount   *Amount `xml:"RmtdAmt" json:"remittedAmount,omitempty"`
 	DuePayableAmount *Amount `xml:"DuePyblAmt" json:"duePayableAmount,omitempty"`
 }
+
+// EntryStatus represents the 'Sts' XML tag of an entry. Up to 001.07 it is a code, e.g. <Sts>BOOK</Sts>,
+// from 001.08 it is a choice between a code and a proprietary status, e.g. <Sts><Cd>BOOK</Cd></Sts>.
+type EntryStatus string
+
+// UnmarshalXML reads the status from the text of the element or from its 'Cd' or 'Prtry' element.
+func (s *EntryStatus) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw struct {
+		Text        string  `xml:",chardata"`
+		Code        *string `xml:"Cd"`
+		Proprietary *string `xml:"Prtry"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	switch {
+	case raw.Code != nil:
+		*s = EntryStatus(strings.TrimSpace(*raw.Code))
+	case raw.Proprietary != nil:
+		*s = EntryStatus(strings.TrimSpace(*raw.Proprietary))
+	default:
+		*s = EntryStatus(strings.TrimSpace(raw.Text))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseError is returned when a camt053 document can not be unmarshaled.
+// It points at the XML element that could not be parsed.
+type ParseError struct {
+	Line    int    // Line of the offending element, 1 based
+	Column  int    // Column of the offending element, 1 based
+	Element string // Path to the offending element, e.g. /Document/BkToCstmrStmt/Stmt/Ntry/Amt
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Element == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s (line %d, column %d): %s", e.Element, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads and unmarshals a camt053 document, a camt052 intraday account report or a camt054 notification.
+func Parse(r io.Reader) (Document, error) {
+	var doc Document
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return doc, err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("document is empty")
+		}
+		return doc, locateError(data, decoder.InputOffset(), err)
+	}
+
+	messageType, version, err := ParseNamespace(doc.XMLName.Space)
+	if err == nil {
+		err = checkMessageType(&doc, messageType)
+	}
+	if err != nil {
+		line, column := lineAndColumn(data, rootOffset(data))
+		return doc, &ParseError{Line: line, Column: column, Element: "/Document", Err: err}
+	}
+	doc.Version = version
+
+	return doc, nil
+}
+
+// checkMessageType sets the message type of the document from its message element,
+// it has to match the message type of the namespace if there is one.
+func checkMessageType(doc *Document, namespaceType string) error {
+	switch {
+	case doc.Notification != nil:
+		doc.MessageType = MESSAGE_TYPE_NOTIFICATION
+	case doc.AccountReport != nil:
+		doc.MessageType = MESSAGE_TYPE_REPORT
+	default:
+		doc.MessageType = MESSAGE_TYPE_STATEMENT
+	}
+	if namespaceType != "" && namespaceType != doc.MessageType {
+		return errors.New(namespaceType + " document does not contain a " + messageElements[namespaceType])
+	}
+	return nil
+}
+
+// locateError finds the element that was being parsed when decoding stopped at offset.
+func locateError(data []byte, offset int64, err error) *ParseError {
+	parseErr := &ParseError{Err: err}
+
+	// Syntax errors already know where they occurred.
+	var syntaxErr *xml.SyntaxError
+	isSyntaxErr := errors.As(err, &syntaxErr)
+
+	// Walk the tokens up to where decoding stopped and keep track of the open elements.
+	// Value errors are reported once the offending element has been closed, in which case
+	// the last closed element is the offending one.
+	decoder := xml.NewDecoder(bytes.NewReader(data[:offset]))
+	stack := make([]string, 0)
+	starts := make([]int64, 0) // Offset of each open element
+	lastClosed, lastClosedStart := "", int64(0)
+	for {
+		start := decoder.InputOffset()
+		token, tokenErr := decoder.RawToken()
+		if tokenErr != nil || (isSyntaxErr && decoder.InputOffset() >= offset) {
+			break // The token ending where the syntax error occurred is the malformed one
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			starts = append(starts, start)
+			lastClosed = ""
+		case xml.EndElement:
+			if len(stack) > 0 {
+				lastClosed, lastClosedStart = "/"+strings.Join(stack, "/"), starts[len(starts)-1]
+				stack, starts = stack[:len(stack)-1], starts[:len(starts)-1]
+			}
+		}
+	}
+
+	position := offset
+	if len(stack) > 0 {
+		parseErr.Element, position = "/"+strings.Join(stack, "/"), starts[len(starts)-1]
+	}
+	if !isSyntaxErr && lastClosed != "" {
+		parseErr.Element, position = lastClosed, lastClosedStart
+	}
+	if isSyntaxErr {
+		position = offset
+	}
+	parseErr.Line, parseErr.Column = lineAndColumn(data, position)
+	if isSyntaxErr {
+		parseErr.Err = errors.New(syntaxErr.Msg)
+	}
+
+	return parseErr
+}
+
+// rootOffset returns the offset of the root element.
+func rootOffset(data []byte) int64 {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			return 0
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			return start
+		}
+	}
+}
+
+// lineAndColumn converts a byte offset into a 1 based line and column.
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,140 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseSampleFiles checks that the sample files in the data directory can be parsed.
+func TestParseSampleFiles(t *testing.T) {
+	for _, path := range []string{"../../data/camt053.xml", "../../data/goldman_sachs_camt053.xml"} {
+		t.Run(path, func(t *testing.T) {
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("failed to open test data: %s", err)
+			}
+			defer file.Close()
+
+			doc, err := Parse(file)
+			assert.NoError(t, err)
+			assert.Len(t, doc.BankStatement.Statements, 1)
+			assert.Equal(t, "001.02", doc.Version)
+		})
+	}
+}
+
+// TestParseErrors checks that parse errors point at the offending XML element.
+func TestParseErrors(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name            string
+		input           string
+		expectedElement string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{
+			name:            "Invalid creation date time",
+			input:           "<Document>\n<BkToCstmrStmt>\n<GrpHdr>\n  <CreDtTm>yesterday</CreDtTm>\n</GrpHdr>\n</BkToCstmrStmt>\n</Document>",
+			expectedElement: "/Document/BkToCstmrStmt/GrpHdr/CreDtTm",
+			expectedLine:    4,
+			expectedColumn:  3,
+		},
+		{
+			name:            "Invalid sequence number",
+			input:           "<Document><BkToCstmrStmt><Stmt><Id>1</Id><ElctrncSeqNb>one</ElctrncSeqNb></Stmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt/ElctrncSeqNb",
+			expectedLine:    1,
+			expectedColumn:  42,
+		},
+		{
+			name:            "Malformed amount",
+			input:           "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n<Ntry><Amt Ccy=\"SEK\">1,000.00</Amt></Ntry></Stmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt/Ntry/Amt",
+			expectedLine:    2,
+			expectedColumn:  7,
+		},
+		{
+			name:            "Too many decimals for currency",
+			input:           "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n<Bal><Amt Ccy=\"JPY\">100.50</Amt></Bal></Stmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt/Bal/Amt",
+			expectedLine:    2,
+			expectedColumn:  6,
+		},
+		{
+			name:            "Invalid booking date",
+			input:           "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n<Ntry><BookgDt><Dt>2018-12-32</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt/Ntry/BookgDt/Dt",
+			expectedLine:    2,
+			expectedColumn:  16,
+		},
+		{
+			name:            "Unclosed element",
+			input:           "<Document>\n<BkToCstmrStmt>\n<Stmt>\n<Id>1</Id>\n",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt",
+			expectedLine:    5,
+			expectedColumn:  1,
+		},
+		{
+			name:            "Mismatched element",
+			input:           "<Document>\n<BkToCstmrStmt>\n<Stmt></Stmts>\n</BkToCstmrStmt>\n</Document>",
+			expectedElement: "/Document/BkToCstmrStmt/Stmt",
+			expectedLine:    3,
+			expectedColumn:  15,
+		},
+		{
+			name:            "Wrong root element",
+			input:           "<Invoice></Invoice>",
+			expectedElement: "/Invoice",
+			expectedLine:    1,
+			expectedColumn:  1,
+		},
+		{
+			name:            "Unsupported version",
+			input:           "<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.01\"><BkToCstmrStmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document",
+			expectedLine:    2,
+			expectedColumn:  1,
+		},
+		{
+			name:            "Not a camt053 namespace",
+			input:           "<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03\"></Document>",
+			expectedElement: "/Document",
+			expectedLine:    1,
+			expectedColumn:  1,
+		},
+		{
+			name:            "camt052 namespace without report",
+			input:           "<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.052.001.02\"><BkToCstmrStmt></BkToCstmrStmt></Document>",
+			expectedElement: "/Document",
+			expectedLine:    1,
+			expectedColumn:  1,
+		},
+		{
+			name:            "Empty document",
+			input:           "",
+			expectedElement: "",
+			expectedLine:    1,
+			expectedColumn:  1,
+		},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input))
+
+			var parseErr *ParseError
+			assert.True(t, errors.As(err, &parseErr), err)
+			assert.Equal(t, test.expectedElement, parseErr.Element)
+			assert.Equal(t, test.expectedLine, parseErr.Line)
+			assert.Equal(t, test.expectedColumn, parseErr.Column)
+		})
+	}
+}
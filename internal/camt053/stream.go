@@ -0,0 +1,277 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// StreamHandler receives the parts of a document as Stream decodes them. Nil callbacks are skipped,
+// an error returned by a callback stops the decoding and is returned by Stream.
+type StreamHandler struct {
+	Header       func(doc Document) error   // The document with its version, message type and group header, but without statements
+	Statement    func(stmt Statement) error // A statement, report or notification without its entries, before its first entry
+	Entry        func(entry Entry) error    // An entry of the last statement
+	EndStatement func(stmt Statement) error // The same statement after its last entry
+}
+
+// Stream decodes a camt053 document, a camt052 intraday account report or a camt054 notification one statement
+// and one entry at a time, so that only a single entry is kept in memory however large the document is.
+// The document is read the same way as by Parse, but the elements of a statement have to come before its
+// entries, as in the schema.
+func Stream(r io.Reader, handler StreamHandler) error {
+	s := &stream{decoder: xml.NewDecoder(r), handler: handler}
+	return s.document()
+}
+
+// stream keeps track of where in the document the decoder is, to locate errors.
+type stream struct {
+	decoder *xml.Decoder
+	handler StreamHandler
+	path    []string // Open elements
+	line    int      // Position of the last token read
+	column  int
+}
+
+// token reads the next token and remembers where it started.
+func (s *stream) token() (xml.Token, error) {
+	s.line, s.column = s.decoder.InputPos()
+	token, err := s.decoder.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(s.path) == 0 {
+			return nil, s.errorAt("", errors.New("document is empty"))
+		}
+		if errors.Is(err, io.EOF) {
+			err = errors.New("unexpected EOF")
+		}
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = errors.New(syntaxErr.Msg)
+		}
+		line, column := s.decoder.InputPos()
+		return nil, &ParseError{Line: line, Column: column, Element: s.element(""), Err: err}
+	}
+	return token, nil
+}
+
+// errorAt returns a parse error located at the last token read, in a child of the open element.
+func (s *stream) errorAt(child string, err error) *ParseError {
+	return &ParseError{Line: s.line, Column: s.column, Element: s.element(child), Err: err}
+}
+
+// element returns the path to a child of the open element, or to the open element if child is empty.
+func (s *stream) element(child string) string {
+	path := s.path
+	if child != "" {
+		path = append(path[:len(path):len(path)], child)
+	}
+	if len(path) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(path, "/")
+}
+
+// decode decodes the element that was just started into v.
+func (s *stream) decode(v any, start xml.StartElement) error {
+	if err := s.decoder.DecodeElement(v, &start); err != nil {
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = errors.New(syntaxErr.Msg)
+		}
+		return s.errorAt(start.Name.Local, err)
+	}
+	return nil
+}
+
+// children reads the children of the open element and calls child for each of them, until the element ends.
+func (s *stream) children(child func(start xml.StartElement) error) error {
+	for {
+		token, err := s.token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := child(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			s.path = s.path[:len(s.path)-1]
+			return nil
+		}
+	}
+}
+
+// document reads the root element, its namespace and its message elements.
+func (s *stream) document() error {
+	var root xml.StartElement
+	for {
+		token, err := s.token()
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+	if root.Name.Local != "Document" {
+		return s.errorAt(root.Name.Local, errors.New("expected element type <Document> but have <"+root.Name.Local+">"))
+	}
+	namespaceType, version, err := ParseNamespace(root.Name.Space)
+	if err != nil {
+		return s.errorAt("Document", err)
+	}
+	rootLine, rootColumn := s.line, s.column
+	s.path = append(s.path, "Document")
+
+	doc := Document{XMLName: root.Name, Version: version}
+	messages := 0
+	err = s.children(func(start xml.StartElement) error {
+		for messageType, element := range messageElements {
+			if start.Name.Local == element {
+				if namespaceType != "" && namespaceType != messageType {
+					return &ParseError{Line: rootLine, Column: rootColumn, Element: "/Document", Err: errors.New(namespaceType + " document does not contain a " + messageElements[namespaceType])}
+				}
+				messages++
+				doc.MessageType = messageType
+				return s.message(doc, start)
+			}
+		}
+		return s.decoder.Skip()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Documents without a message are read as empty statements, like Parse does
+	if messages == 0 {
+		if namespaceType != "" && namespaceType != MESSAGE_TYPE_STATEMENT {
+			return &ParseError{Line: rootLine, Column: rootColumn, Element: "/Document", Err: errors.New(namespaceType + " document does not contain a " + messageElements[namespaceType])}
+		}
+		doc.MessageType = MESSAGE_TYPE_STATEMENT
+		return s.header(doc, GroupHeader{})
+	}
+	return nil
+}
+
+// message reads the group header and the statements of a message element, e.g. 'BkToCstmrStmt'.
+func (s *stream) message(doc Document, start xml.StartElement) error {
+	s.path = append(s.path, start.Name.Local)
+	headerSent := false
+	err := s.children(func(child xml.StartElement) error {
+		switch child.Name.Local {
+		case "GrpHdr":
+			var header GroupHeader
+			if err := s.decode(&header, child); err != nil {
+				return err
+			}
+			headerSent = true
+			return s.header(doc, header)
+		case statementElements[doc.MessageType]:
+			if !headerSent {
+				headerSent = true
+				if err := s.header(doc, GroupHeader{}); err != nil {
+					return err
+				}
+			}
+			return s.statement(child)
+		}
+		return s.decoder.Skip()
+	})
+	if err == nil && !headerSent {
+		err = s.header(doc, GroupHeader{})
+	}
+	return err
+}
+
+// header passes the document to the handler, with the group header in its message.
+func (s *stream) header(doc Document, header GroupHeader) error {
+	switch doc.MessageType {
+	case MESSAGE_TYPE_REPORT:
+		doc.AccountReport = &BankToCustomerAccountReport{GroupHeader: header}
+	case MESSAGE_TYPE_NOTIFICATION:
+		doc.Notification = &BankToCustomerDebitCreditNotification{GroupHeader: header}
+	default:
+		doc.BankStatement.GroupHeader = header
+	}
+	if s.handler.Header != nil {
+		return s.handler.Header(doc)
+	}
+	return nil
+}
+
+// statement reads a 'Stmt', 'Rpt' or 'Ntfctn' element, passing its entries to the handler one at a time.
+func (s *stream) statement(start xml.StartElement) error {
+	s.path = append(s.path, start.Name.Local)
+	var stmt Statement
+	started := false
+	err := s.children(func(child xml.StartElement) error {
+		if child.Name.Local == "Ntry" {
+			if !started {
+				started = true
+				if s.handler.Statement != nil {
+					if err := s.handler.Statement(stmt); err != nil {
+						return err
+					}
+				}
+			}
+			var entry Entry
+			if err := s.decode(&entry, child); err != nil {
+				return err
+			}
+			if s.handler.Entry != nil {
+				return s.handler.Entry(entry)
+			}
+			return nil
+		}
+
+		field := statementField(&stmt, child.Name.Local)
+		if field == nil {
+			return s.decoder.Skip()
+		}
+		if started {
+			return s.errorAt(child.Name.Local, errors.New(child.Name.Local+" has to come before the entries of the statement"))
+		}
+		return s.decode(field, child)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !started && s.handler.Statement != nil {
+		if err := s.handler.Statement(stmt); err != nil {
+			return err
+		}
+	}
+	if s.handler.EndStatement != nil {
+		return s.handler.EndStatement(stmt)
+	}
+	return nil
+}
+
+// statementField returns the field of a statement that an element is decoded into, nil for elements that are not modeled.
+func statementField(stmt *Statement, element string) any {
+	switch element {
+	case "Id":
+		return &stmt.Id
+	case "ElctrncSeqNb":
+		return &stmt.ElectronicSequenceNumber
+	case "LglSeqNb":
+		return &stmt.LegalSequenceNumber
+	case "CreDtTm":
+		return &stmt.CreationDateTime
+	case "FrToDt":
+		return &stmt.FromDate
+	case "Acct":
+		return &stmt.Account
+	case "Bal":
+		stmt.Balances = append(stmt.Balances, Balance{})
+		return &stmt.Balances[len(stmt.Balances)-1]
+	case "TxsSummry":
+		return &stmt.TransactionSummary
+	}
+	return nil
+}
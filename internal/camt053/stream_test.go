@@ -0,0 +1,294 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collect streams a document and puts it back together.
+func collect(data []byte) (Document, error) {
+	var doc Document
+	statements := make([]Statement, 0)
+	err := Stream(bytes.NewReader(data), StreamHandler{
+		Header: func(header Document) error {
+			doc = header
+			return nil
+		},
+		Statement: func(stmt Statement) error {
+			statements = append(statements, stmt)
+			return nil
+		},
+		Entry: func(entry Entry) error {
+			stmt := &statements[len(statements)-1]
+			if stmt.Entries == nil {
+				stmt.Entries = &[]Entry{}
+			}
+			*stmt.Entries = append(*stmt.Entries, entry)
+			return nil
+		},
+	})
+	switch {
+	case doc.IsNotification():
+		doc.Notification.Notifications = statements
+	case doc.IsIntraday():
+		doc.AccountReport.Reports = statements
+	case len(statements) > 0:
+		doc.BankStatement.Statements = statements
+	}
+	return doc, err
+}
+
+// TestStreamSampleFiles checks that streaming the sample files gives the same documents as parsing them.
+func TestStreamSampleFiles(t *testing.T) {
+	for _, path := range []string{"../../data/camt053.xml", "../../data/goldman_sachs_camt053.xml"} {
+		t.Run(path, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read test data: %s", err)
+			}
+			parsed, err := Parse(bytes.NewReader(data))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			streamed, err := collect(data)
+			if assert.NoError(t, err) {
+				assert.Equal(t, parsed, streamed)
+			}
+		})
+	}
+}
+
+// TestStreamMessageTypes checks that reports and notifications are streamed with their message type.
+func TestStreamMessageTypes(t *testing.T) {
+	sample := parseSampleFile(t, "../../data/camt053.xml")
+
+	// Declare Tests
+	tests := []struct {
+		name string
+		doc  Document
+	}{
+		{"camt052", Document{Version: "001.08", MessageType: MESSAGE_TYPE_REPORT, AccountReport: &BankToCustomerAccountReport{sample.BankStatement.GroupHeader, sample.BankStatement.Statements}}},
+		{"camt054", Document{Version: "001.02", MessageType: MESSAGE_TYPE_NOTIFICATION, Notification: &BankToCustomerDebitCreditNotification{sample.BankStatement.GroupHeader, sample.BankStatement.Statements}}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Marshal(test.doc)
+			if !assert.NoError(t, err) {
+				return
+			}
+			parsed, err := Parse(bytes.NewReader(data))
+			if !assert.NoError(t, err) {
+				return
+			}
+			streamed, err := collect(data)
+			if assert.NoError(t, err) {
+				assert.Equal(t, parsed, streamed)
+			}
+		})
+	}
+}
+
+// TestStreamErrors checks that stream errors point at the offending XML element.
+func TestStreamErrors(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name            string
+		input           string
+		expectedElement string
+		expectedLine    int
+	}{
+		{"Empty document", "", "", 1},
+		{"Wrong root element", "<Invoice></Invoice>", "/Invoice", 1},
+		{"Unsupported version", "<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.01\"></Document>", "/Document", 2},
+		{"camt052 namespace without report", "<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.052.001.02\">\n<BkToCstmrStmt></BkToCstmrStmt></Document>", "/Document", 1},
+		{"Invalid creation date time", "<Document>\n<BkToCstmrStmt>\n<GrpHdr><CreDtTm>yesterday</CreDtTm></GrpHdr>\n</BkToCstmrStmt>\n</Document>", "/Document/BkToCstmrStmt/GrpHdr", 3},
+		{"Malformed amount", "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n<Ntry><Amt Ccy=\"SEK\">1,000.00</Amt></Ntry></Stmt></BkToCstmrStmt></Document>", "/Document/BkToCstmrStmt/Stmt/Ntry", 2},
+		{"Invalid balance", "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n\n<Bal><Amt Ccy=\"JPY\">100.50</Amt></Bal></Stmt></BkToCstmrStmt></Document>", "/Document/BkToCstmrStmt/Stmt/Bal", 3},
+		{"Balance after the entries", "<Document><BkToCstmrStmt><Stmt><Id>1</Id>\n<Ntry></Ntry>\n<Bal></Bal></Stmt></BkToCstmrStmt></Document>", "/Document/BkToCstmrStmt/Stmt/Bal", 3},
+		{"Unclosed element", "<Document>\n<BkToCstmrStmt>\n<Stmt>\n<Id>1</Id>\n", "/Document/BkToCstmrStmt/Stmt", 5},
+		{"Mismatched element", "<Document>\n<BkToCstmrStmt>\n<Stmt></Stmts>\n</BkToCstmrStmt>\n</Document>", "/Document/BkToCstmrStmt/Stmt", 3},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := collect([]byte(test.input))
+			var parseErr *ParseError
+			if !assert.True(t, errors.As(err, &parseErr), "expected a ParseError, got %v", err) {
+				return
+			}
+			assert.Equal(t, test.expectedElement, parseErr.Element, parseErr.Error())
+			assert.Equal(t, test.expectedLine, parseErr.Line, parseErr.Error())
+		})
+	}
+}
+
+// TestStreamHandlerError checks that an error returned by a callback stops the decoding.
+func TestStreamHandlerError(t *testing.T) {
+	data, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+
+	stop := errors.New("stop")
+	entries := 0
+	err = Stream(bytes.NewReader(data), StreamHandler{
+		Entry: func(entry Entry) error {
+			entries++
+			return stop
+		},
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, entries)
+}
+
+// TestStreamValidator checks that validating a streamed document finds the same violations as Validate.
+func TestStreamValidator(t *testing.T) {
+	doc := parseSampleFile(t, "../../data/camt053.xml")
+	stmt := &doc.BankStatement.Statements[0]
+	stmt.Id = ""
+	stmt.Balances = nil
+	(*stmt.Entries)[1].CreditDebitIndicator = "CREDIT"
+	(*stmt.Entries)[3].Status = "DONE"
+	data, err := Marshal(doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sv := &StreamValidator{}
+	err = Stream(bytes.NewReader(data), StreamHandler{
+		Header:    func(doc Document) error { sv.Header(doc); return nil },
+		Statement: func(stmt Statement) error { sv.Statement(stmt); return nil },
+		Entry:     func(entry Entry) error { sv.Entry(entry); return nil },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Validate(doc), sv.Violations())
+	assert.Len(t, sv.Violations(), 4)
+	assert.Error(t, sv.Err())
+
+	// Documents without statements
+	sv = &StreamValidator{}
+	assert.NoError(t, Stream(strings.NewReader("<Document><BkToCstmrStmt></BkToCstmrStmt></Document>"), StreamHandler{
+		Header: func(doc Document) error { sv.Header(doc); return nil },
+	}))
+	assert.ElementsMatch(t, Validate(Document{}), sv.Violations())
+}
+
+// largeDocument returns a statement with the entries of the sample file repeated until it has the number of entries.
+func largeDocument(b *testing.B, entries int) []byte {
+	file, err := os.Open("../../data/camt053.xml")
+	if err != nil {
+		b.Fatalf("failed to open test data: %s", err)
+	}
+	defer file.Close()
+	doc, err := Parse(file)
+	if err != nil {
+		b.Fatalf("failed to parse test data: %s", err)
+	}
+
+	stmt := &doc.BankStatement.Statements[0]
+	sample := *stmt.Entries
+	repeated := make([]Entry, entries)
+	for i := range repeated {
+		repeated[i] = sample[i%len(sample)]
+		ref := fmt.Sprintf("REF-%08d", i)
+		repeated[i].Reference = &ref
+	}
+	stmt.Entries = &repeated
+
+	data, err := Marshal(doc)
+	if err != nil {
+		b.Fatalf("failed to write document: %s", err)
+	}
+	return data
+}
+
+// heapSampler samples the live heap while a document is decoded, to show how much memory decoding needs.
+// Garbage is collected before each sample so that only memory that is still in use is counted.
+type heapSampler struct {
+	base uint64
+	peak uint64
+}
+
+func newHeapSampler() *heapSampler {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return &heapSampler{base: stats.HeapAlloc}
+}
+
+func (h *heapSampler) sample() {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > h.base && stats.HeapAlloc-h.base > h.peak {
+		h.peak = stats.HeapAlloc - h.base
+	}
+}
+
+// Number of entries between heap samples.
+const HEAP_SAMPLE_INTERVAL = 1000
+
+// BenchmarkParse parses documents of increasing size. The heap needed grows with the size of the document.
+func BenchmarkParse(b *testing.B) {
+	for _, entries := range []int{1000, 10000, 50000} {
+		data := largeDocument(b, entries)
+		b.Run(fmt.Sprintf("entries=%d", entries), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			peak := uint64(0)
+			for i := 0; i < b.N; i++ {
+				heap := newHeapSampler()
+				doc, err := Parse(bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+				heap.sample() // The whole document is in memory once it has been parsed
+				runtime.KeepAlive(doc)
+				peak = max(peak, heap.peak)
+			}
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
+
+// BenchmarkStream streams documents of increasing size. The heap needed stays the same.
+func BenchmarkStream(b *testing.B) {
+	for _, entries := range []int{1000, 10000, 50000} {
+		data := largeDocument(b, entries)
+		b.Run(fmt.Sprintf("entries=%d", entries), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			peak := uint64(0)
+			for i := 0; i < b.N; i++ {
+				heap := newHeapSampler()
+				count := 0
+				err := Stream(bytes.NewReader(data), StreamHandler{
+					Entry: func(entry Entry) error {
+						if count++; count%HEAP_SAMPLE_INTERVAL == 0 {
+							heap.sample()
+						}
+						return nil
+					},
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				peak = max(peak, heap.peak)
+			}
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
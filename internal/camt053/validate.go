@@ -0,0 +1,299 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Max length of identifiers and references (Max35Text).
+const MAX_ID_LENGTH = 35
+
+// Codes allowed in the code lists checked by Validate.
+var (
+	creditDebitCodes = []string{"CRDT", "DBIT"}
+	entryStatusCodes = []string{"BOOK", "PDNG", "INFO", "FUTR"} // FUTR from 001.08
+	balanceTypeCodes = []string{"OPBD", "CLBD", "CLAV", "FWAV", "ITBD", "ITAV", "OPAV", "PRCD", "XPCD", "INFO"}
+)
+
+// Violation is a structural error in a camt053 document, such as a missing mandatory element or a code that is not in its code list.
+type Violation struct {
+	Path    string `json:"path"` // XPath-like location of the element, e.g. /Document/BkToCstmrStmt/Stmt[1]/Ntry[2]/CdtDbtInd
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError is returned when a document has violations.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return "document is invalid: " + e.Violations[0].String()
+	}
+	return fmt.Sprintf("document is invalid: %s (and %d more violations)", e.Violations[0], len(e.Violations)-1)
+}
+
+// validator collects the violations of a document.
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(path string, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the cardinality, mandatory elements, code lists, max lengths and amount formats of a document.
+// It returns every violation found, a valid document has none.
+func Validate(doc Document) []Violation {
+	sv := &StreamValidator{}
+	sv.Header(doc)
+	if len(doc.Statements()) == 0 {
+		sv.add(sv.path+"/"+sv.element, "at least one %s is required", sv.name)
+	}
+	for i, stmt := range doc.Statements() {
+		sv.statement(fmt.Sprintf("%s/%s[%d]", sv.path, sv.element, i+1), stmt, sv.requireBalance)
+	}
+	return sv.violations
+}
+
+// StreamValidator validates a document one part at a time while it is decoded by Stream, it finds the same
+// violations as Validate. Its methods are called from the callbacks of a StreamHandler.
+type StreamValidator struct {
+	validator
+	path           string // Path to the message element, e.g. /Document/BkToCstmrStmt
+	element        string // Element of the statements, e.g. Stmt
+	name           string
+	requireBalance bool
+	statements     int // Number of statements read so far
+	entries        int // Number of entries read of the last statement
+}
+
+// Header validates the group header of a document.
+func (sv *StreamValidator) Header(doc Document) {
+	sv.violations = make([]Violation, 0)
+
+	// Reports and notifications have the same structure as statements, but balances are optional
+	sv.path, sv.element, sv.name, sv.requireBalance = "/Document/BkToCstmrStmt", "Stmt", "statement", true
+	if doc.IsIntraday() {
+		sv.path, sv.element, sv.name, sv.requireBalance = "/Document/BkToCstmrAcctRpt", "Rpt", "report", false
+	}
+	if doc.IsNotification() {
+		sv.path, sv.element, sv.name, sv.requireBalance = "/Document/BkToCstmrDbtCdtNtfctn", "Ntfctn", "notification", false
+	}
+	sv.groupHeader(sv.path+"/GrpHdr", doc.Header())
+}
+
+// Statement validates a statement without its entries.
+func (sv *StreamValidator) Statement(stmt Statement) {
+	sv.statements++
+	sv.entries = 0
+	stmt.Entries = nil
+	sv.statement(sv.statementPath(), stmt, sv.requireBalance)
+}
+
+// Entry validates an entry of the last statement.
+func (sv *StreamValidator) Entry(entry Entry) {
+	sv.entries++
+	sv.entry(fmt.Sprintf("%s/Ntry[%d]", sv.statementPath(), sv.entries), entry)
+}
+
+// Violations returns every violation found in the document, it is called once the whole document has been read.
+func (sv *StreamValidator) Violations() []Violation {
+	violations := sv.violations
+	if sv.statements == 0 {
+		violations = append(violations, Violation{Path: sv.path + "/" + sv.element, Message: "at least one " + sv.name + " is required"})
+	}
+	return violations
+}
+
+// Err returns a *ValidationError if the document has violations.
+func (sv *StreamValidator) Err() error {
+	if violations := sv.Violations(); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (sv *StreamValidator) statementPath() string {
+	return fmt.Sprintf("%s/%s[%d]", sv.path, sv.element, sv.statements)
+}
+
+// ValidateDocument validates a document and returns a *ValidationError if it has violations.
+func ValidateDocument(doc Document) error {
+	if violations := Validate(doc); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (v *validator) groupHeader(path string, header GroupHeader) {
+	v.identifier(path+"/MsgId", header.MessageId, false)
+	if header.CreationDateTime.IsZero() {
+		v.add(path+"/CreDtTm", "creation date time is required")
+	}
+}
+
+func (v *validator) statement(path string, stmt Statement, requireBalance bool) {
+	v.identifier(path+"/Id", stmt.Id, true)
+	if stmt.CreationDateTime.IsZero() {
+		v.add(path+"/CreDtTm", "creation date time is required")
+	}
+	if stmt.FromDate != nil && stmt.FromDate.ToDateTime.Compare(stmt.FromDate.FromDateTime) < 0 {
+		v.add(path+"/FrToDt", "to date time is before from date time")
+	}
+
+	v.account(path+"/Acct", stmt.Account)
+
+	if requireBalance && len(stmt.Balances) == 0 {
+		v.add(path+"/Bal", "at least one balance is required")
+	}
+	for i, balance := range stmt.Balances {
+		v.balance(fmt.Sprintf("%s/Bal[%d]", path, i+1), balance)
+	}
+
+	if stmt.TransactionSummary != nil {
+		v.transactionSummary(path+"/TxsSummry", *stmt.TransactionSummary)
+	}
+
+	if stmt.Entries != nil {
+		for i, entry := range *stmt.Entries {
+			v.entry(fmt.Sprintf("%s/Ntry[%d]", path, i+1), entry)
+		}
+	}
+}
+
+func (v *validator) account(path string, acc Account) {
+	switch {
+	case acc.Id.IBAN != nil:
+		if !IsValidIBAN(*acc.Id.IBAN) {
+			v.add(path+"/Id/IBAN", "'%s' is not a valid IBAN", *acc.Id.IBAN)
+		}
+	case acc.Id.Other != nil:
+		v.identifier(path+"/Id/Othr/Id", acc.Id.Other.Id, true)
+	default:
+		v.add(path+"/Id", "account id is required, either IBAN or Othr/Id")
+	}
+	if acc.Currency != nil && !currencyPattern.MatchString(*acc.Currency) {
+		v.add(path+"/Ccy", "'%s' is not a ISO 4217 currency code", *acc.Currency)
+	}
+	if acc.Type != nil {
+		v.codeOrProprietary(path+"/Tp", *acc.Type)
+	}
+}
+
+func (v *validator) balance(path string, balance Balance) {
+	v.codeOrProprietary(path+"/Tp/CdOrPrtry", balance.Type.CodeOrProprietary)
+	if code := balance.Type.CodeOrProprietary.Code; code != nil {
+		v.code(path+"/Tp/CdOrPrtry/Cd", *code, balanceTypeCodes)
+	}
+	if balance.CreditLine != nil {
+		v.amount(path+"/CdtLine/Amt", balance.CreditLine.Amount)
+	}
+	v.amount(path+"/Amt", balance.Amount)
+	v.code(path+"/CdtDbtInd", balance.CreditDebitIndicator, creditDebitCodes)
+	if balance.Date.IsZero() {
+		v.add(path+"/Dt", "date is required")
+	}
+}
+
+func (v *validator) transactionSummary(path string, summary TransactionSummary) {
+	if total := summary.TotalEntries; total != nil {
+		v.count(path+"/TtlNtries/NbOfNtries", total.NumberOfEntries)
+		v.sum(path+"/TtlNtries/Sum", total.Sum)
+		v.sum(path+"/TtlNtries/TtlNetNtryAmt", total.TotalNetEntryAmount)
+		if total.CreditDebitIndicator != "" {
+			v.code(path+"/TtlNtries/CdtDbtInd", total.CreditDebitIndicator, creditDebitCodes)
+		}
+	}
+	if credit := summary.TotalCreditEntries; credit != nil {
+		v.count(path+"/TtlCdtNtries/NbOfNtries", credit.NumberOfEntries)
+		v.sum(path+"/TtlCdtNtries/Sum", credit.Sum)
+	}
+	if debit := summary.TotalDebitEntries; debit != nil {
+		v.count(path+"/TtlDbtNtries/NbOfNtries", debit.NumberOfEntries)
+		v.sum(path+"/TtlDbtNtries/Sum", debit.Sum)
+	}
+}
+
+func (v *validator) entry(path string, entry Entry) {
+	if entry.Reference != nil {
+		v.identifier(path+"/NtryRef", *entry.Reference, false)
+	}
+	v.amount(path+"/Amt", entry.Amount)
+	v.code(path+"/CdtDbtInd", entry.CreditDebitIndicator, creditDebitCodes)
+	v.code(path+"/Sts", string(entry.Status), entryStatusCodes)
+	if entry.AccountServicerRef != nil {
+		v.identifier(path+"/AcctSvcrRef", *entry.AccountServicerRef, false)
+	}
+	if entry.BankTransactionCode.Domain == nil && entry.BankTransactionCode.ProprietaryCode == nil {
+		v.add(path+"/BkTxCd", "bank transaction code is required, either Domn or Prtry")
+	}
+	if entry.Charges != nil {
+		for i, charge := range *entry.Charges {
+			v.amount(fmt.Sprintf("%s/Chrgs[%d]/Amt", path, i+1), charge.Amount)
+		}
+	}
+}
+
+// identifier checks that an identifier is set if it is required and that it is at most MAX_ID_LENGTH characters.
+func (v *validator) identifier(path string, id string, required bool) {
+	if strings.TrimSpace(id) == "" {
+		if required {
+			v.add(path, "is required")
+		}
+		return
+	}
+	if length := utf8.RuneCountInString(id); length > MAX_ID_LENGTH {
+		v.add(path, "is %d characters long, at most %d are allowed", length, MAX_ID_LENGTH)
+	}
+}
+
+// code checks that a code is set and is one of the codes in its code list.
+func (v *validator) code(path string, code string, codes []string) {
+	if code == "" {
+		v.add(path, "is required")
+		return
+	}
+	for _, allowed := range codes {
+		if code == allowed {
+			return
+		}
+	}
+	v.add(path, "'%s' is not one of %s", code, strings.Join(codes, ", "))
+}
+
+// codeOrProprietary checks that exactly one of a code and a proprietary value is set.
+func (v *validator) codeOrProprietary(path string, choice CodeOrProprietary) {
+	if (choice.Code == nil) == (choice.Proprietary == nil) {
+		v.add(path, "exactly one of Cd and Prtry is required")
+	}
+}
+
+// amount checks amounts that were not created with NewAmount, e.g. documents that were not unmarshaled.
+func (v *validator) amount(path string, amount Amount) {
+	if amount.Currency == "" && amount.Value.unscaled == nil {
+		v.add(path, "amount is required")
+		return
+	}
+	if _, err := NewAmount(amount.Currency, amount.Value); err != nil {
+		v.add(path, "%s", err)
+	}
+}
+
+func (v *validator) sum(path string, sum *Decimal) {
+	if sum != nil && sum.Sign() < 0 {
+		v.add(path, "%s is negative", sum)
+	}
+}
+
+func (v *validator) count(path string, count int) {
+	if count < 0 {
+		v.add(path, "number of entries can not be negative")
+	}
+}
@@ -0,0 +1,149 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validStatement is a minimal statement with every mandatory element, the tests replace parts of it.
+const validStatement = `<Stmt>
+<Id>STMT-1</Id>
+<CreDtTm>2023-09-30T20:00:00</CreDtTm>
+<Acct><Id><IBAN>SE4550000000058398257466</IBAN></Id><Ccy>SEK</Ccy></Acct>
+<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="SEK">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2023-09-30</Dt></Dt></Bal>
+<Ntry><NtryRef>REF-1</NtryRef><Amt Ccy="SEK">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts><BkTxCd><Prtry><Cd>TRF</Cd></Prtry></BkTxCd></Ntry>
+</Stmt>`
+
+// validationDocument wraps statements in a document.
+func validationDocument(statements string) string {
+	return `<Document><BkToCstmrStmt><GrpHdr><MsgId>1</MsgId><CreDtTm>2023-09-30T20:00:00</CreDtTm></GrpHdr>` +
+		statements + `</BkToCstmrStmt></Document>`
+}
+
+// TestValidate checks that structural violations are reported with the location of the element.
+func TestValidate(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		old           string // Part of validStatement to replace
+		new           string
+		expectedPaths []string
+	}{
+		{"Valid", "", "", []string{}},
+		{"Missing statement id", "<Id>STMT-1</Id>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Id"}},
+		{"Too long statement id", "STMT-1", strings.Repeat("1", 36), []string{"/Document/BkToCstmrStmt/Stmt[1]/Id"}},
+		{"Missing creation date time", "<CreDtTm>2023-09-30T20:00:00</CreDtTm>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/CreDtTm"}},
+		{"Missing account id", "<Id><IBAN>SE4550000000058398257466</IBAN></Id>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Acct/Id"}},
+		{"Invalid IBAN", "SE4550000000058398257466", "SE4550000000058398257467", []string{"/Document/BkToCstmrStmt/Stmt[1]/Acct/Id/IBAN"}},
+		{"Invalid account currency", "<Ccy>SEK</Ccy>", "<Ccy>sek</Ccy>", []string{"/Document/BkToCstmrStmt/Stmt[1]/Acct/Ccy"}},
+		{"Missing balance", `<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="SEK">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2023-09-30</Dt></Dt></Bal>`, "",
+			[]string{"/Document/BkToCstmrStmt/Stmt[1]/Bal"}},
+		{"Unknown balance type", "<Cd>OPBD</Cd>", "<Cd>OPEN</Cd>", []string{"/Document/BkToCstmrStmt/Stmt[1]/Bal[1]/Tp/CdOrPrtry/Cd"}},
+		{"Missing balance type", "<Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Bal[1]/Tp/CdOrPrtry"}},
+		{"Missing balance date", "<Dt><Dt>2023-09-30</Dt></Dt>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Bal[1]/Dt"}},
+		{"Missing balance amount", `<Amt Ccy="SEK">100.00</Amt>`, "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Bal[1]/Amt"}},
+		{"Unknown credit debit indicator", "<CdtDbtInd>DBIT</CdtDbtInd>", "<CdtDbtInd>DEBIT</CdtDbtInd>", []string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/CdtDbtInd"}},
+		{"Unknown status", "<Sts>BOOK</Sts>", "<Sts>DONE</Sts>", []string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/Sts"}},
+		{"Missing status", "<Sts>BOOK</Sts>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/Sts"}},
+		{"Too long entry reference", "REF-1", strings.Repeat("R", 36), []string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/NtryRef"}},
+		{"Missing bank transaction code", "<BkTxCd><Prtry><Cd>TRF</Cd></Prtry></BkTxCd>", "", []string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/BkTxCd"}},
+		{"Several violations", "<Sts>BOOK</Sts>", "<Sts>DONE</Sts><AcctSvcrRef>" + strings.Repeat("A", 40) + "</AcctSvcrRef>",
+			[]string{"/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/Sts", "/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/AcctSvcrRef"}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := validationDocument(strings.Replace(validStatement, test.old, test.new, 1))
+			doc, err := Parse(strings.NewReader(input))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			paths := []string{}
+			for _, violation := range Validate(doc) {
+				paths = append(paths, violation.Path)
+			}
+			assert.Equal(t, test.expectedPaths, paths)
+		})
+	}
+}
+
+// TestValidateStatements checks the cardinality of statements and that violations are located in the right statement.
+func TestValidateStatements(t *testing.T) {
+	doc, err := Parse(strings.NewReader(validationDocument("")))
+	assert.NoError(t, err)
+	assert.Equal(t, []Violation{{Path: "/Document/BkToCstmrStmt/Stmt", Message: "at least one statement is required"}}, Validate(doc))
+
+	second := strings.Replace(validStatement, "<CdtDbtInd>DBIT</CdtDbtInd>", "<CdtDbtInd>X</CdtDbtInd>", 1)
+	doc, err = Parse(strings.NewReader(validationDocument(validStatement + second)))
+	assert.NoError(t, err)
+	violations := Validate(doc)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "/Document/BkToCstmrStmt/Stmt[2]/Ntry[1]/CdtDbtInd", violations[0].Path)
+}
+
+// TestValidateMessageId checks that message ids are read as text and that their length is limited like other identifiers.
+func TestValidateMessageId(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		messageId     string
+		expectedPaths []string
+	}{
+		{"Numeric message id", "1", []string{}},
+		{"Text message id", "MSG-2018-12-17-001", []string{}},
+		{"Too long message id", strings.Repeat("M", 36), []string{"/Document/BkToCstmrStmt/GrpHdr/MsgId"}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := strings.Replace(validationDocument(validStatement), "<MsgId>1</MsgId>", "<MsgId>"+test.messageId+"</MsgId>", 1)
+			doc, err := Parse(strings.NewReader(input))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.messageId, doc.Header().MessageId)
+
+			paths := []string{}
+			for _, violation := range Validate(doc) {
+				paths = append(paths, violation.Path)
+			}
+			assert.Equal(t, test.expectedPaths, paths)
+		})
+	}
+}
+
+// TestValidateDocument checks that documents created without unmarshaling are validated and that violations are returned as a ValidationError.
+func TestValidateDocument(t *testing.T) {
+	file, err := os.Open("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to open test data: %s", err)
+	}
+	defer file.Close()
+
+	doc, err := Parse(file)
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateDocument(doc))
+
+	// Amounts that bypass NewAmount are checked as well
+	entries := *doc.BankStatement.Statements[0].Entries
+	entries[0].Amount = Amount{Currency: "SEK", Value: NewDecimal(1, 3)}
+	entries[1].Amount = Amount{}
+
+	err = ValidateDocument(doc)
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Violations, 2)
+	assert.Equal(t, "/Document/BkToCstmrStmt/Stmt[1]/Ntry[1]/Amt", validationErr.Violations[0].Path)
+	assert.Equal(t, "/Document/BkToCstmrStmt/Stmt[1]/Ntry[2]/Amt", validationErr.Violations[1].Path)
+	assert.Contains(t, err.Error(), "(and 1 more violations)")
+}
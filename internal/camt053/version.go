@@ -0,0 +1,82 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"errors"
+	"strings"
+)
+
+// Namespace of ISO 20022 messages without the message type and version.
+const ISO20022_NAMESPACE_PREFIX = "urn:iso:std:iso:20022:tech:xsd:"
+
+// Message types that can be parsed.
+const MESSAGE_TYPE_STATEMENT = "camt.053"    // End of day bank to customer statement
+const MESSAGE_TYPE_REPORT = "camt.052"       // Intraday bank to customer account report
+const MESSAGE_TYPE_NOTIFICATION = "camt.054" // Bank to customer debit credit notification
+
+// Element of the message in the 'Document' of each message type.
+var messageElements = map[string]string{
+	MESSAGE_TYPE_STATEMENT:    "BkToCstmrStmt",
+	MESSAGE_TYPE_REPORT:       "BkToCstmrAcctRpt",
+	MESSAGE_TYPE_NOTIFICATION: "BkToCstmrDbtCdtNtfctn",
+}
+
+// Namespace of camt053 documents without the version, e.g. urn:iso:std:iso:20022:tech:xsd:camt.053.001.02.
+const NAMESPACE_PREFIX = ISO20022_NAMESPACE_PREFIX + MESSAGE_TYPE_STATEMENT + "."
+
+// Version assumed for documents without a namespace, the version the model was first written against.
+const DEFAULT_VERSION = "001.02"
+
+// Versions of camt053 that can be parsed. camt.052 and camt.054 use the same version numbers.
+var SUPPORTED_VERSIONS = []string{
+	"001.02", "001.03", "001.04", "001.05", "001.06", "001.07",
+	"001.08", "001.09", "001.10", "001.11", "001.12", "001.13",
+}
+
+// ParseNamespace returns the message type and version of a namespace, e.g. "camt.052" and "001.08".
+// Documents without a namespace have no message type and are assumed to be DEFAULT_VERSION.
+func ParseNamespace(namespace string) (string, string, error) {
+	if namespace == "" {
+		return "", DEFAULT_VERSION, nil
+	}
+	message, found := strings.CutPrefix(namespace, ISO20022_NAMESPACE_PREFIX)
+	messageType, version, _ := strings.Cut(message, ".001.")
+	if _, supported := messageElements[messageType]; !found || !supported {
+		return "", "", errors.New("namespace '" + namespace + "' is not a camt053, camt052 or camt054 namespace")
+	}
+	version = "001." + version
+	for _, supported := range SUPPORTED_VERSIONS {
+		if version == supported {
+			return messageType, version, nil
+		}
+	}
+	return "", "", errors.New(messageType + " version " + version + " is not supported")
+}
+
+// VersionFromNamespace returns the camt053 version of a namespace, e.g. "001.08".
+// Documents without a namespace are assumed to be DEFAULT_VERSION.
+func VersionFromNamespace(namespace string) (string, error) {
+	messageType, version, err := ParseNamespace(namespace)
+	if err != nil {
+		return "", err
+	}
+	if messageType != "" && messageType != MESSAGE_TYPE_STATEMENT {
+		return "", errors.New("namespace '" + namespace + "' is not a camt053 namespace")
+	}
+	return version, nil
+}
+
+// Namespace returns the namespace of a camt053 version.
+func Namespace(version string) string {
+	return NAMESPACE_PREFIX + version
+}
+
+// ReportNamespace returns the namespace of a camt052 version.
+func ReportNamespace(version string) string {
+	return ISO20022_NAMESPACE_PREFIX + MESSAGE_TYPE_REPORT + "." + version
+}
+
+// NotificationNamespace returns the namespace of a camt054 version.
+func NotificationNamespace(version string) string {
+	return ISO20022_NAMESPACE_PREFIX + MESSAGE_TYPE_NOTIFICATION + "." + version
+}
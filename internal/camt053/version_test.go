@@ -0,0 +1,244 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVersionFromNamespace checks that versions are detected from the document namespace.
+func TestVersionFromNamespace(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		namespace       string
+		expectedVersion string
+		expectedErr     bool
+	}{
+		{"", DEFAULT_VERSION, false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02", "001.02", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.08", "001.08", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.13", "001.13", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.01", "", true},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.14", "", true},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.054.001.08", "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.namespace, func(t *testing.T) {
+			version, err := VersionFromNamespace(test.namespace)
+			assert.Equal(t, test.expectedErr, err != nil, err)
+			assert.Equal(t, test.expectedVersion, version)
+		})
+	}
+
+	for _, version := range SUPPORTED_VERSIONS {
+		detected, err := VersionFromNamespace(Namespace(version))
+		assert.NoError(t, err)
+		assert.Equal(t, version, detected)
+	}
+}
+
+// TestParseNamespace checks that the message type and version are detected from camt053 and camt052 namespaces.
+func TestParseNamespace(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		namespace           string
+		expectedMessageType string
+		expectedVersion     string
+		expectedErr         bool
+	}{
+		{"", "", DEFAULT_VERSION, false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02", MESSAGE_TYPE_STATEMENT, "001.02", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.052.001.08", MESSAGE_TYPE_REPORT, "001.08", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.054.001.02", MESSAGE_TYPE_NOTIFICATION, "001.02", false},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.052.001.14", "", "", true},
+		{"urn:iso:std:iso:20022:tech:xsd:camt.053", "", "", true},
+		{"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03", "", "", true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.namespace, func(t *testing.T) {
+			messageType, version, err := ParseNamespace(test.namespace)
+			assert.Equal(t, test.expectedErr, err != nil, err)
+			assert.Equal(t, test.expectedMessageType, messageType)
+			assert.Equal(t, test.expectedVersion, version)
+		})
+	}
+
+	_, err := VersionFromNamespace(ReportNamespace("001.02"))
+	assert.Error(t, err)
+	_, err = VersionFromNamespace(NotificationNamespace("001.02"))
+	assert.Error(t, err)
+}
+
+// TestParseAccountReport checks that camt052 reports are parsed into the same model as statements.
+func TestParseAccountReport(t *testing.T) {
+	input := `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.08">
+<BkToCstmrAcctRpt>
+<GrpHdr><MsgId>1</MsgId><CreDtTm>2023-09-30T12:00:00</CreDtTm></GrpHdr>
+<Rpt>
+<Id>RPT-1</Id>
+<CreDtTm>2023-09-30T12:00:00</CreDtTm>
+<Acct><Id><IBAN>SE4550000000058398257466</IBAN></Id></Acct>
+<Bal><Tp><CdOrPrtry><Cd>ITBD</Cd></CdOrPrtry></Tp><Amt Ccy="SEK">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><DtTm>2023-09-30T12:00:00</DtTm></Dt></Bal>
+<Ntry><NtryRef>REF-1</NtryRef><Amt Ccy="SEK">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts><BkTxCd><Prtry><Cd>TRF</Cd></Prtry></BkTxCd></Ntry>
+</Rpt>
+<Rpt>
+<Id>RPT-2</Id>
+<CreDtTm>2023-09-30T12:00:00</CreDtTm>
+<Acct><Id><IBAN>SE4550000000058398257466</IBAN></Id></Acct>
+</Rpt>
+</BkToCstmrAcctRpt>
+</Document>`
+
+	doc, err := Parse(strings.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, MESSAGE_TYPE_REPORT, doc.MessageType)
+	assert.Equal(t, "001.08", doc.Version)
+	assert.True(t, doc.IsIntraday())
+	assert.Equal(t, "1", doc.Header().MessageId)
+	assert.Empty(t, doc.BankStatement.Statements)
+
+	reports := doc.Statements()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, "RPT-1", reports[0].Id)
+	assert.Equal(t, "ITBD", reports[0].Balances[0].TypeCode())
+	assert.Equal(t, EntryStatus("PDNG"), (*reports[0].Entries)[0].Status)
+
+	// Balances are optional in reports, violations point at the report
+	assert.Empty(t, Validate(doc))
+	(*reports[0].Entries)[0].CreditDebitIndicator = ""
+	violations := Validate(doc)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "/Document/BkToCstmrAcctRpt/Rpt[1]/Ntry[1]/CdtDbtInd", violations[0].Path)
+}
+
+// TestParseNotification checks that camt054 notifications are parsed into the same model as statements.
+func TestParseNotification(t *testing.T) {
+	input := `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.02">
+<BkToCstmrDbtCdtNtfctn>
+<GrpHdr><MsgId>7</MsgId><CreDtTm>2023-09-30T12:00:00</CreDtTm></GrpHdr>
+<Ntfctn>
+<Id>NTFCTN-1</Id>
+<CreDtTm>2023-09-30T12:00:00</CreDtTm>
+<Acct><Id><IBAN>SE4550000000058398257466</IBAN></Id></Acct>
+<Ntry>
+<Amt Ccy="SEK">10.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts><AcctSvcrRef>SVCR-1</AcctSvcrRef><BkTxCd><Prtry><Cd>TRF</Cd></Prtry></BkTxCd>
+<NtryDtls><TxDtls><RmtInf><Ustrd>Invoice 4711</Ustrd></RmtInf></TxDtls></NtryDtls>
+</Ntry>
+</Ntfctn>
+</BkToCstmrDbtCdtNtfctn>
+</Document>`
+
+	doc, err := Parse(strings.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, MESSAGE_TYPE_NOTIFICATION, doc.MessageType)
+	assert.True(t, doc.IsNotification())
+	assert.False(t, doc.IsIntraday())
+	assert.Equal(t, "7", doc.Header().MessageId)
+	assert.Empty(t, Validate(doc))
+
+	notifications := doc.Statements()
+	assert.Len(t, notifications, 1)
+	entry := (*notifications[0].Entries)[0]
+	assert.Equal(t, "SVCR-1", *entry.AccountServicerRef)
+	assert.Equal(t, []string{"Invoice 4711"}, *(*(*entry.EntryDetails)[0].TransactionDetails)[0].RemittanceInformation.Unstructured)
+
+	// A camt054 namespace requires a notification
+	_, err = Parse(strings.NewReader(strings.ReplaceAll(input, "BkToCstmrDbtCdtNtfctn", "BkToCstmrStmt")))
+	assert.ErrorContains(t, err, "camt.054 document does not contain a BkToCstmrDbtCdtNtfctn")
+}
+
+// TestParseVersions checks that the structural differences between versions are unmarshaled into the same model.
+func TestParseVersions(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.%s">
+<BkToCstmrStmt>
+<GrpHdr><MsgId>1</MsgId><CreDtTm>2023-09-30T20:00:00</CreDtTm></GrpHdr>
+<Stmt>
+<Id>1</Id>
+<CreDtTm>2023-09-30T20:00:00</CreDtTm>
+<Acct>
+<Id><IBAN>SE4550000000058398257466</IBAN></Id>
+%s
+<Svcr><FinInstnId>%s</FinInstnId></Svcr>
+</Acct>
+<Ntry>
+<Amt Ccy="SEK">100.00</Amt>
+<CdtDbtInd>CRDT</CdtDbtInd>
+%s
+<BookgDt><Dt>2023-09-30</Dt></BookgDt>
+<ValDt>%s</ValDt>
+</Ntry>
+</Stmt>
+</BkToCstmrStmt>
+</Document>`
+
+	// Declare Tests
+	tests := []struct {
+		version           string
+		account           string
+		financialInstId   string
+		status            string
+		valueDate         string
+		expectedType      *string
+		expectedName      *string
+		expectedValueDate string
+	}{
+		{"02", "<Ccy>SEK</Ccy>", "<BIC>ESSESESS</BIC>", "<Sts>BOOK</Sts>", "<Dt>2023-09-30</Dt>",
+			nil, nil, "2023-09-30"},
+		{"04", "<Tp><Cd>CACC</Cd></Tp><Ccy>SEK</Ccy><Nm>Payroll</Nm>", "<BIC>ESSESESS</BIC>", "<Sts>BOOK</Sts>", "<DtTm>2023-09-30T12:00:00Z</DtTm>",
+			strPtr("CACC"), strPtr("Payroll"), "2023-09-30T12:00:00Z"},
+		{"08", "<Tp><Cd>CACC</Cd></Tp><Ccy>SEK</Ccy><Nm>Payroll</Nm>", "<BICFI>ESSESESS</BICFI>", "<Sts><Cd>BOOK</Cd></Sts>", "<DtTm>2023-09-30T14:00:00+02:00</DtTm>",
+			strPtr("CACC"), strPtr("Payroll"), "2023-09-30T14:00:00+02:00"},
+		{"13", "<Tp><Prtry>SALARY</Prtry></Tp><Ccy>SEK</Ccy>", "<BICFI>ESSESESS</BICFI><Nm>SEB</Nm>", "<Sts><Prtry>BOOK</Prtry></Sts>", "<Dt>2023-09-30</Dt>",
+			nil, nil, "2023-09-30"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			input := fmt.Sprintf(document, test.version, test.account, test.financialInstId, test.status, test.valueDate)
+			doc, err := Parse(strings.NewReader(input))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, "001."+test.version, doc.Version)
+			stmt := doc.BankStatement.Statements[0]
+			if test.expectedType != nil {
+				assert.Equal(t, test.expectedType, stmt.Account.Type.Code)
+			}
+			assert.Equal(t, test.expectedName, stmt.Account.Name)
+			assert.Equal(t, "ESSESESS", *stmt.Account.Servicer.FinancialInstitutionId.BIC)
+			entry := (*stmt.Entries)[0]
+			assert.Equal(t, EntryStatus("BOOK"), entry.Status)
+			assert.Equal(t, test.expectedValueDate, entry.ValueDate.String())
+		})
+	}
+}
+
+// TestEntryStatus checks that statuses are read both as codes and as code or proprietary choices.
+func TestEntryStatus(t *testing.T) {
+	for _, input := range []string{"<Sts>PDNG</Sts>", "<Sts><Cd>PDNG</Cd></Sts>", "<Sts><Prtry>PDNG</Prtry></Sts>", "<Sts>\n  PDNG\n</Sts>"} {
+		var status EntryStatus
+		assert.NoError(t, xml.Unmarshal([]byte(input), &status))
+		assert.Equal(t, EntryStatus("PDNG"), status, input)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,229 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Version from which the BIC of a financial institution is called 'BICFI' instead of 'BIC'.
+const BICFI_VERSION = "001.05"
+
+// Version from which the status of an entry is a choice between 'Cd' and 'Prtry' instead of a code.
+const STATUS_CODE_VERSION = "001.08"
+
+// Elements of the statements, the reports and the notifications in each message type.
+var statementElements = map[string]string{
+	MESSAGE_TYPE_STATEMENT:    "Stmt",
+	MESSAGE_TYPE_REPORT:       "Rpt",
+	MESSAGE_TYPE_NOTIFICATION: "Ntfctn",
+}
+
+// writer writes the elements of a document in the order of the schema. The first error is kept
+// and every later write is skipped, so that the error only needs to be checked once.
+type writer struct {
+	encoder *xml.Encoder
+	version string
+	err     error
+}
+
+// Write writes a document as indented XML in the namespace of its message type and version.
+// The elements are written in the order of the schema and the elements that were renamed between
+// versions, such as 'BIC' and the entry status, are written the way the version of the document names them.
+// Fields that are not part of camt053, such as the URL reference of an entry, are not written.
+// Values are preserved rather than the source text, e.g. amounts are written with the decimals of their currency, see NewAmount.
+func Write(w io.Writer, doc Document) error {
+	messageType, namespace, err := documentNamespace(doc)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	wr := &writer{encoder: encoder, version: doc.Version}
+	if wr.version == "" {
+		wr.version = DEFAULT_VERSION
+	}
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "Document"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: namespace}},
+	}
+	wr.start(root)
+	wr.message(messageElements[messageType], statementElements[messageType], doc.Header(), doc.Statements())
+	wr.end(root)
+	if wr.err != nil {
+		return wr.err
+	}
+	return encoder.Close()
+}
+
+// Marshal returns a document as indented XML, see Write.
+func Marshal(doc Document) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := Write(buffer, doc); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// documentNamespace returns the message type of a document and its namespace. Documents without a message type
+// are written as camt.052 reports if they have an account report, camt.054 notifications if they have a notification
+// and as camt.053 statements otherwise.
+func documentNamespace(doc Document) (string, string, error) {
+	version := doc.Version
+	if version == "" {
+		version = DEFAULT_VERSION
+	}
+	messageType := doc.MessageType
+	if messageType == "" {
+		switch {
+		case doc.IsNotification():
+			messageType = MESSAGE_TYPE_NOTIFICATION
+		case doc.IsIntraday():
+			messageType = MESSAGE_TYPE_REPORT
+		default:
+			messageType = MESSAGE_TYPE_STATEMENT
+		}
+	}
+
+	var namespace string
+	switch messageType {
+	case MESSAGE_TYPE_STATEMENT:
+		namespace = Namespace(version)
+	case MESSAGE_TYPE_REPORT:
+		namespace = ReportNamespace(version)
+	case MESSAGE_TYPE_NOTIFICATION:
+		namespace = NotificationNamespace(version)
+	default:
+		return "", "", errors.New("message type '" + messageType + "' is not camt.053, camt.052 or camt.054")
+	}
+	if _, _, err := ParseNamespace(namespace); err != nil {
+		return "", "", err
+	}
+	return messageType, namespace, nil
+}
+
+// message writes the group header and the statements of a message, e.g. 'BkToCstmrStmt'.
+func (w *writer) message(name string, statementName string, header GroupHeader, statements []Statement) {
+	start := element(name)
+	w.start(start)
+	w.element("GrpHdr", header)
+	for _, stmt := range statements {
+		w.statement(statementName, stmt)
+	}
+	w.end(start)
+}
+
+// statement writes a 'Stmt', 'Rpt' or 'Ntfctn' element.
+func (w *writer) statement(name string, stmt Statement) {
+	start := element(name)
+	w.start(start)
+	w.element("Id", stmt.Id)
+	w.element("ElctrncSeqNb", stmt.ElectronicSequenceNumber)
+	w.element("LglSeqNb", stmt.LegalSequenceNumber)
+	w.element("CreDtTm", stmt.CreationDateTime)
+	w.element("FrToDt", stmt.FromDate)
+	w.account(stmt.Account)
+	for _, balance := range stmt.Balances {
+		w.element("Bal", balance)
+	}
+	w.element("TxsSummry", stmt.TransactionSummary)
+	if stmt.Entries != nil {
+		for _, entry := range *stmt.Entries {
+			w.entry(entry)
+		}
+	}
+	w.end(start)
+}
+
+// account writes the 'Acct' element of a statement.
+func (w *writer) account(acc Account) {
+	start := element("Acct")
+	w.start(start)
+	w.element("Id", acc.Id)
+	w.element("Tp", acc.Type)
+	w.element("Ccy", acc.Currency)
+	w.element("Nm", acc.Name)
+	w.element("Ownr", acc.Owner)
+	if acc.Servicer != nil {
+		w.servicer(*acc.Servicer)
+	}
+	w.end(start)
+}
+
+// servicer writes the 'Svcr' element of an account, the BIC is called 'BICFI' from BICFI_VERSION.
+func (w *writer) servicer(servicer Servicer) {
+	start, institution := element("Svcr"), element("FinInstnId")
+	bic := "BIC"
+	if w.version >= BICFI_VERSION {
+		bic = "BICFI"
+	}
+	w.start(start)
+	w.start(institution)
+	w.element(bic, servicer.FinancialInstitutionId.BIC)
+	w.element("Nm", servicer.FinancialInstitutionId.Name)
+	w.end(institution)
+	w.end(start)
+}
+
+// entry writes a 'Ntry' element, the status is wrapped in a 'Cd' element from STATUS_CODE_VERSION.
+func (w *writer) entry(entry Entry) {
+	start := element("Ntry")
+	w.start(start)
+	w.element("NtryRef", entry.Reference)
+	w.element("Amt", entry.Amount)
+	w.element("CdtDbtInd", entry.CreditDebitIndicator)
+	if w.version >= STATUS_CODE_VERSION {
+		w.element("Sts", struct {
+			Code EntryStatus `xml:"Cd"`
+		}{entry.Status})
+	} else {
+		w.element("Sts", entry.Status)
+	}
+	w.element("BookgDt", entry.BookingDate)
+	w.element("ValDt", entry.ValueDate)
+	w.element("AcctSvcrRef", entry.AccountServicerRef)
+	w.element("BkTxCd", entry.BankTransactionCode)
+	w.element("AmtDtls", entry.AmountDetails)
+	if entry.Charges != nil {
+		for _, charge := range *entry.Charges {
+			w.element("Chrgs", charge)
+		}
+	}
+	if entry.EntryDetails != nil {
+		for _, details := range *entry.EntryDetails {
+			w.element("NtryDtls", details)
+		}
+	}
+	w.element("AddtlNtryInf", entry.AdditionalInformation)
+	w.end(start)
+}
+
+// element encodes a value as an element using its XML struct tags. Nil values are not written.
+func (w *writer) element(name string, v any) {
+	if w.err == nil {
+		w.err = w.encoder.EncodeElement(v, element(name))
+	}
+}
+
+func (w *writer) start(start xml.StartElement) {
+	if w.err == nil {
+		w.err = w.encoder.EncodeToken(start)
+	}
+}
+
+func (w *writer) end(start xml.StartElement) {
+	if w.err == nil {
+		w.err = w.encoder.EncodeToken(start.End())
+	}
+}
+
+func element(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
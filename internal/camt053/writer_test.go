@@ -0,0 +1,129 @@
+// package camt053 models the camt053 to enable marshaling and unmarshaling of camt053 data, both JSON and XML.
+package camt053
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseSampleFile parses a sample file in the data directory.
+func parseSampleFile(t *testing.T, path string) Document {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open test data: %s", err)
+	}
+	defer file.Close()
+
+	doc, err := Parse(file)
+	if err != nil {
+		t.Fatalf("failed to parse test data: %s", err)
+	}
+	return doc
+}
+
+// TestWriteRoundTrip checks that writing the sample files and parsing them again gives the same documents.
+// The values are preserved, not the text of the files, see TestWriteAmountScale.
+func TestWriteRoundTrip(t *testing.T) {
+	for _, path := range []string{"../../data/camt053.xml", "../../data/goldman_sachs_camt053.xml"} {
+		t.Run(path, func(t *testing.T) {
+			doc := parseSampleFile(t, path)
+
+			buffer := &bytes.Buffer{}
+			if !assert.NoError(t, Write(buffer, doc)) {
+				return
+			}
+			written, err := Parse(buffer)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, doc, written)
+			assert.NoError(t, ValidateDocument(written))
+		})
+	}
+}
+
+// TestWriteAmountScale checks that amounts are written with the decimals of their currency rather than as they were read.
+func TestWriteAmountScale(t *testing.T) {
+	source, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	assert.Contains(t, string(source), `<Amt Ccy="SEK">2500000</Amt>`)
+
+	buffer := &bytes.Buffer{}
+	if !assert.NoError(t, Write(buffer, parseSampleFile(t, "../../data/camt053.xml"))) {
+		return
+	}
+	assert.Contains(t, buffer.String(), `<Amt Ccy="SEK">2500000.00</Amt>`)
+	assert.NotContains(t, buffer.String(), `<Amt Ccy="SEK">2500000</Amt>`)
+}
+
+// TestWriteVersions checks that the namespace and the renamed elements follow the version and message type of the document.
+func TestWriteVersions(t *testing.T) {
+	sample := parseSampleFile(t, "../../data/camt053.xml")
+
+	// Declare Tests
+	tests := []struct {
+		name              string
+		version           string
+		messageType       string
+		expectedNamespace string
+		expectedElements  []string
+		unexpected        []string
+	}{
+		{"camt053 001.02", "001.02", MESSAGE_TYPE_STATEMENT, Namespace("001.02"), []string{"<BkToCstmrStmt>", "<Stmt>", "<BIC>", "<Sts>BOOK</Sts>"}, []string{"<BICFI>", "<Cd>BOOK</Cd>"}},
+		{"camt053 001.05", "001.05", MESSAGE_TYPE_STATEMENT, Namespace("001.05"), []string{"<BICFI>", "<Sts>BOOK</Sts>"}, []string{"<BIC>"}},
+		{"camt053 001.08", "001.08", MESSAGE_TYPE_STATEMENT, Namespace("001.08"), []string{"<BICFI>", "<Cd>BOOK</Cd>"}, []string{"<Sts>BOOK</Sts>"}},
+		{"camt052", "001.02", MESSAGE_TYPE_REPORT, ReportNamespace("001.02"), []string{"<BkToCstmrAcctRpt>", "<Rpt>"}, []string{"<BkToCstmrStmt>", "<Stmt>"}},
+		{"camt054", "001.08", MESSAGE_TYPE_NOTIFICATION, NotificationNamespace("001.08"), []string{"<BkToCstmrDbtCdtNtfctn>", "<Ntfctn>"}, []string{"<BkToCstmrStmt>", "<Stmt>"}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := Document{Version: test.version, MessageType: test.messageType}
+			doc.XMLName.Space, doc.XMLName.Local = test.expectedNamespace, "Document"
+			switch test.messageType {
+			case MESSAGE_TYPE_REPORT:
+				doc.AccountReport = &BankToCustomerAccountReport{sample.BankStatement.GroupHeader, sample.BankStatement.Statements}
+			case MESSAGE_TYPE_NOTIFICATION:
+				notifications := make([]Statement, len(sample.BankStatement.Statements))
+				for i, stmt := range sample.BankStatement.Statements {
+					stmt.Balances = nil
+					notifications[i] = stmt
+				}
+				doc.Notification = &BankToCustomerDebitCreditNotification{sample.BankStatement.GroupHeader, notifications}
+			default:
+				doc.BankStatement = sample.BankStatement
+			}
+
+			data, err := Marshal(doc)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Contains(t, string(data), `<Document xmlns="`+test.expectedNamespace+`">`)
+			for _, element := range test.expectedElements {
+				assert.Contains(t, string(data), element)
+			}
+			for _, element := range test.unexpected {
+				assert.NotContains(t, string(data), element)
+			}
+
+			written, err := Parse(bytes.NewReader(data))
+			if assert.NoError(t, err) {
+				assert.Equal(t, doc, written)
+			}
+		})
+	}
+}
+
+// TestWriteUnsupportedVersion checks that documents are only written in supported versions.
+func TestWriteUnsupportedVersion(t *testing.T) {
+	_, err := Marshal(Document{Version: "001.01"})
+	assert.Error(t, err)
+	_, err = Marshal(Document{MessageType: "pain.001"})
+	assert.Error(t, err)
+}
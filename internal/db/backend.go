@@ -0,0 +1,54 @@
+// package db is a local mock database.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const DB_BACKEND_MEMORY = "memory"
+const DB_BACKEND_SQLITE = "sqlite"
+const DB_BACKEND_POSTGRES = "postgres"
+
+// Default name of the SQLite database file in the project directory.
+const DEFAULT_SQLITE_FILE = "bank.db"
+
+// OpenDataBase opens the database backend configured with the DB_BACKEND env variable. DB_BACKEND_MEMORY, the default,
+// is the in memory database DB, DB_BACKEND_SQLITE is a SQLite database in the file returned by SQLitePath and
+// DB_BACKEND_POSTGRES is the PostgreSQL database that the POSTGRES_DSN env variable points at.
+func OpenDataBase() (IDataBase, error) {
+	switch backend := strings.ToLower(os.Getenv("DB_BACKEND")); backend {
+	case "", DB_BACKEND_MEMORY:
+		return &DB, nil
+	case DB_BACKEND_SQLITE:
+		store, err := OpenSQLite(SQLitePath())
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case DB_BACKEND_POSTGRES:
+		dataSourceName := os.Getenv("POSTGRES_DSN")
+		if dataSourceName == "" {
+			return nil, errors.New("POSTGRES_DSN must be set to use the postgres backend")
+		}
+		store, err := OpenPostgres(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("DB_BACKEND must be '%s', '%s' or '%s', got '%s'", DB_BACKEND_MEMORY, DB_BACKEND_SQLITE, DB_BACKEND_POSTGRES, backend)
+	}
+}
+
+// SQLitePath returns the file of the SQLite database.
+// It is configured with the SQLITE_PATH env variable and defaults to $PROJECT_DIR/bank.db.
+func SQLitePath() string {
+	if path := os.Getenv("SQLITE_PATH"); path != "" {
+		return path
+	}
+	return filepath.Join(os.Getenv("PROJECT_DIR"), DEFAULT_SQLITE_FILE)
+}
@@ -0,0 +1,47 @@
+// package db is a local mock database.
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOpenDataBase checks that the backend is selected by the DB_BACKEND env variable.
+func TestOpenDataBase(t *testing.T) {
+	dir := t.TempDir()
+
+	// Declare Tests
+	tests := []struct {
+		backend       string
+		expected      IDataBase
+		expectedError string
+	}{
+		{"", &DB, ""},
+		{"memory", &DB, ""},
+		{"SQLite", &SQLiteData{}, ""},
+		{"postgres", nil, "POSTGRES_DSN must be set to use the postgres backend"},
+		{"mysql", nil, "DB_BACKEND must be 'memory', 'sqlite' or 'postgres', got 'mysql'"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.backend, func(t *testing.T) {
+			t.Setenv("DB_BACKEND", test.backend)
+			t.Setenv("SQLITE_PATH", filepath.Join(dir, "bank.db"))
+			t.Setenv("POSTGRES_DSN", "")
+
+			store, err := OpenDataBase()
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, test.expected, store)
+			if sqlite, ok := store.(*SQLiteData); ok {
+				assert.NoError(t, sqlite.Close())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// package db is a local mock database.
+package db
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Order of the common balance types within a day, other types are sorted after them by code.
+var balanceTypeOrder = map[string]int{"OPBD": 1, "ITBD": 2, "CLBD": 3, "CLAV": 4, "FWAV": 5}
+
+// BalanceQuery describes which of an accounts balances to fetch. Zero values disable a filter.
+type BalanceQuery struct {
+	Types    []string      // Balance type codes, e.g. CLBD, or proprietary types
+	FromDate *camt053.Date // Earliest balance date, inclusive
+	ToDate   *camt053.Date // Latest balance date, inclusive
+	AsOf     *camt053.Date // Return the latest balance of each type at or before this date
+}
+
+// BalancesResponse is the format for /balances request responses.
+type BalancesResponse struct {
+	Balances   []camt053.Balance `json:"balances"`
+	TotalCount int               `json:"totalCount"`
+}
+
+// Validate checks that the date range of a balance query is valid and not combined with AsOf.
+func (q BalanceQuery) Validate() error {
+	if q.FromDate != nil && q.ToDate != nil && q.FromDate.Compare(*q.ToDate) > 0 {
+		return errors.New("fromDate is after toDate")
+	}
+	if q.AsOf != nil && (q.FromDate != nil || q.ToDate != nil) {
+		return errors.New("asOf can not be combined with a date range")
+	}
+	return nil
+}
+
+// matches checks if a balance passes the type and date filters of the query.
+func (q BalanceQuery) matches(balance camt053.Balance) bool {
+	if len(q.Types) > 0 {
+		found := false
+		for _, balanceType := range q.Types {
+			found = found || strings.EqualFold(balanceType, balance.TypeCode())
+		}
+		if !found {
+			return false
+		}
+	}
+	date := balance.GetDate()
+	if (q.FromDate != nil && date.Compare(*q.FromDate) < 0) || (q.ToDate != nil && date.Compare(*q.ToDate) > 0) {
+		return false
+	}
+	if q.AsOf != nil && date.Compare(*q.AsOf) > 0 {
+		return false
+	}
+	return true
+}
+
+// GetAccountBalances gets an accounts balances sorted by date and type, filtered by the query.
+// AsOf queries only return the latest balance of each type at or before the AsOf date.
+func (db *BankData) GetAccountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.accountBalances(accountId, query)
+}
+
+func (db *BankData) accountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Fetch Account
+	account, err := db.account(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := query.selectBalances(account.Balances)
+	return &BalancesResponse{Balances: balances, TotalCount: len(balances)}, nil
+}
+
+// selectBalances returns the balances that match the query sorted by date and type,
+// or only the latest balance of each type for AsOf queries.
+func (q BalanceQuery) selectBalances(balances []camt053.Balance) []camt053.Balance {
+	selected := make([]camt053.Balance, 0)
+	for _, balance := range balances {
+		if q.matches(balance) {
+			selected = append(selected, balance)
+		}
+	}
+	sortBalances(selected)
+
+	// Keep the closest balance of each type, the balances are sorted so the last one is the closest
+	if q.AsOf != nil {
+		latest := make(map[string]int)
+		for i, balance := range selected {
+			latest[balanceKeyWithoutDate(balance)] = i
+		}
+		closest := make([]camt053.Balance, 0, len(latest))
+		for i, balance := range selected {
+			if latest[balanceKeyWithoutDate(balance)] == i {
+				closest = append(closest, balance)
+			}
+		}
+		selected = closest
+	}
+	return selected
+}
+
+// sortBalances sorts balances by date and then by type in the order they occur during a day.
+func sortBalances(balances []camt053.Balance) {
+	sort.SliceStable(balances, func(i, j int) bool {
+		a, b := balances[i], balances[j]
+		if cmp := a.Date.Day().Compare(b.Date.Day()); cmp != 0 {
+			return cmp < 0
+		}
+		orderA, orderB := balanceTypeOrder[a.TypeCode()], balanceTypeOrder[b.TypeCode()]
+		if orderA == 0 {
+			orderA = len(balanceTypeOrder) + 1
+		}
+		if orderB == 0 {
+			orderB = len(balanceTypeOrder) + 1
+		}
+		if orderA != orderB {
+			return orderA < orderB
+		}
+		return balanceKey(a) < balanceKey(b)
+	})
+}
+
+// balanceKeyWithoutDate identifies the type of a balance.
+func balanceKeyWithoutDate(balance camt053.Balance) string {
+	key := balanceKey(balance)
+	return key[:strings.LastIndex(key, "@")]
+}
@@ -0,0 +1,66 @@
+// package db is a local mock database.
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetAccountBalances checks the type and date filters of balance queries.
+func TestGetAccountBalances(t *testing.T) {
+	db := loadTwoDays(t)
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		query         BalanceQuery
+		expectedTypes []string
+		expectedDates []string
+	}{
+		{"All", BalanceQuery{}, []string{"OPBD", "CLBD", "CLAV", "OPBD", "CLBD", "CLAV", "FWAV"},
+			[]string{"2018-12-17", "2018-12-17", "2018-12-17", "2018-12-18", "2018-12-18", "2018-12-18", "2018-12-18"}},
+		{"Type", BalanceQuery{Types: []string{"clbd"}}, []string{"CLBD", "CLBD"}, []string{"2018-12-17", "2018-12-18"}},
+		{"Types and date", BalanceQuery{Types: []string{"OPBD", "CLBD"}, FromDate: date("2018-12-18"), ToDate: date("2018-12-18")},
+			[]string{"OPBD", "CLBD"}, []string{"2018-12-18", "2018-12-18"}},
+		{"Until date", BalanceQuery{ToDate: date("2018-12-17")}, []string{"OPBD", "CLBD", "CLAV"}, []string{"2018-12-17", "2018-12-17", "2018-12-17"}},
+		{"As of first day", BalanceQuery{AsOf: date("2018-12-17")}, []string{"OPBD", "CLBD", "CLAV"}, []string{"2018-12-17", "2018-12-17", "2018-12-17"}},
+		{"As of later day", BalanceQuery{AsOf: date("2019-01-31"), Types: []string{"CLBD", "FWAV"}}, []string{"CLBD", "FWAV"}, []string{"2018-12-18", "2018-12-18"}},
+		{"As of earlier day", BalanceQuery{AsOf: date("2018-12-01")}, []string{}, []string{}},
+		{"Unknown type", BalanceQuery{Types: []string{"ITBD"}}, []string{}, []string{}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := db.GetAccountBalances(testAccountId, test.query)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.expectedTypes), response.TotalCount)
+
+			types, dates := []string{}, []string{}
+			for _, balance := range response.Balances {
+				types = append(types, balance.TypeCode())
+				dates = append(dates, balance.GetDate().String())
+			}
+			assert.Equal(t, test.expectedTypes, types)
+			assert.Equal(t, test.expectedDates, dates)
+		})
+	}
+}
+
+// TestGetAccountBalancesInvalidQuery checks that invalid balance queries are rejected.
+func TestGetAccountBalancesInvalidQuery(t *testing.T) {
+	db := loadTwoDays(t)
+
+	queries := []BalanceQuery{
+		{FromDate: date("2018-12-18"), ToDate: date("2018-12-17")},
+		{AsOf: date("2018-12-18"), FromDate: date("2018-12-17")},
+	}
+	for _, query := range queries {
+		_, err := db.GetAccountBalances(testAccountId, query)
+		assert.Error(t, err)
+	}
+
+	_, err := db.GetAccountBalances("DD01100056869", BalanceQuery{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,380 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// openStore opens an empty database of the backend that the conformance suite is run against.
+type openStore func(t *testing.T) IDataBase
+
+// conformanceStep is a document that is loaded by the conformance suite, either parsed or streamed from a file.
+type conformanceStep struct {
+	name string
+	doc  camt053.Document
+	path string // Streamed with LoadCamt053File if set
+}
+
+// conformanceSteps returns documents that cover every way a document is merged into the data already loaded:
+// new accounts, pending and matched notifications, intraday entries that are updated and settled, reloaded
+// statements with duplicate entries, entries without dates and statements that do not reconcile.
+func conformanceSteps(t *testing.T) []conformanceStep {
+	dir := t.TempDir()
+	doc := loadTestDocument(t)
+
+	nextDay := loadTestDocument(t)
+	nextDay.BankStatement.GroupHeader.MessageId = "MSG-2018-12-18-001"
+	nextDay.BankStatement.Statements[0] = nextDayStatement(nextDay.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18")
+
+	unbalanced := loadTestDocument(t)
+	unbalanced.BankStatement.Statements[0] = nextDayStatement(unbalanced.BankStatement.Statements[0], "STMT-2018-12-19", "2018-12-19")
+	findBalance(unbalanced.BankStatement.Statements[0].Balances, "CLBD").Amount.Value = camt053.NewDecimal(100, 2)
+	undated := &(*unbalanced.BankStatement.Statements[0].Entries)[0]
+	undated.BookingDate, undated.ValueDate = nil, nil
+
+	steps := []conformanceStep{
+		{name: "Pending notification", path: writeTestDocument(t, dir, "notification.xml", notificationDocument(t))},
+		{name: "Statement", path: "../../data/camt053.xml"},
+		{name: "Intraday report", doc: intradayReport(nextDay, "RPT-2018-12-18-1")},
+		{name: "Second intraday report", doc: intradayReport(nextDay, "RPT-2018-12-18-2")},
+		{name: "Settling statement", doc: nextDay},
+		{name: "Other bank", path: "../../data/goldman_sachs_camt053.xml"},
+		{name: "Reloaded statement", doc: doc},
+		{name: "Matched notification", doc: notificationDocument(t)},
+		{name: "Unbalanced statement", doc: unbalanced},
+	}
+	for i, path := range generateTestFiles(t, dir, 3, 8, 3) {
+		steps = append(steps, conformanceStep{name: fmt.Sprintf("Generated %d", i+1), path: path})
+	}
+	return steps
+}
+
+// load loads a step into a database and into the BankData it is compared with.
+func (step conformanceStep) load(t *testing.T, expected *BankData, actual IDataBase) {
+	doc := step.doc
+	if step.path != "" {
+		parsed, err := ParseLocalCamt053(step.path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		doc = parsed
+	}
+	expectedSummary, expectedErr := expected.LoadCamt053(doc)
+
+	var summary LoadSummary
+	var err error
+	if step.path != "" {
+		summary, err = actual.LoadCamt053File(step.path)
+	} else {
+		summary, err = actual.LoadCamt053(doc)
+	}
+	assert.Equal(t, expectedErr, err, step.name)
+	assert.Equal(t, expectedSummary, summary, step.name)
+}
+
+// assertSameResult checks that a read returned the same result, or the same error, from both databases.
+func assertSameResult[T any](t *testing.T, expected T, expectedErr error, actual T, err error, msgAndArgs ...any) {
+	t.Helper()
+	if expectedErr != nil {
+		assert.EqualError(t, err, expectedErr.Error(), msgAndArgs...)
+		return
+	}
+	if assert.NoError(t, err, msgAndArgs...) {
+		assert.Equal(t, expected, actual, msgAndArgs...)
+	}
+}
+
+// conformanceTransactionQueries are the sort orders and filters the transactions of every account are read with.
+var conformanceTransactionQueries = func() []TransactionQuery {
+	filters := []TransactionQuery{
+		{},
+		{CreditDebitIndicator: "DBIT"},
+		{Status: "BOOK"},
+		{FromDate: date("2018-12-18"), ToDate: date("2024-03-02")},
+		{ToDate: date("2018-12-17")},
+		{MinAmount: amount("100"), MaxAmount: amount("91838.000")},
+		{BankTransactionCode: "pmnt"},
+		{BankTransactionCode: "PMNT-RCDT-DMCT"},
+		{BankTransactionCode: "msc miscellaneous"},
+	}
+	queries := make([]TransactionQuery, 0)
+	for _, sortBy := range []string{SORT_BOOKING_DATE, SORT_VALUE_DATE, SORT_AMOUNT} {
+		for _, descending := range []bool{false, true} {
+			for _, filter := range filters {
+				filter.SortBy, filter.Descending, filter.PerPage = sortBy, descending, 4
+				queries = append(queries, filter)
+			}
+		}
+	}
+	return queries
+}()
+
+// compareTransactions reads the pages of a transaction query by page number and by following the cursors of the pages both ways.
+func compareTransactions(t *testing.T, expected IDataReader, actual IDataReader, accountId string, query TransactionQuery) {
+	read := func(query TransactionQuery) *TransactionsResponse {
+		expectedResponse, expectedErr := expected.GetAccountTransactions(accountId, query)
+		response, err := actual.GetAccountTransactions(accountId, query)
+		assertSameResult(t, expectedResponse, expectedErr, response, err, "%s %+v", accountId, query)
+		return expectedResponse
+	}
+
+	first := read(query)
+	if first == nil {
+		return
+	}
+	for page := 2; page <= first.TotalPages+1; page++ {
+		query.Page = page
+		read(query)
+	}
+
+	query.Page = 0
+	last := first
+	for next := first; next.NextCursor != ""; next = read(query) {
+		query.Cursor, last = next.NextCursor, next
+	}
+	for prev := last; prev.PrevCursor != ""; prev = read(query) {
+		query.Cursor = prev.PrevCursor
+	}
+
+	query.Cursor = ""
+	expectedExport, expectedErr := expected.ExportAccountTransactions(accountId, query)
+	export, err := actual.ExportAccountTransactions(accountId, query)
+	assertSameResult(t, expectedExport, expectedErr, export, err, "export %s %+v", accountId, query)
+}
+
+// compareStores checks that every read gives the same result from both databases.
+func compareStores(t *testing.T, expected IDataReader, actual IDataReader) {
+
+	// Page through the accounts both by page number and by cursor
+	accounts, err := expected.GetAccounts(AccountQuery{PerPage: MAX_PER_PAGE})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, query := range []AccountQuery{{}, {PerPage: 2}, {PerPage: 2, Page: 2}, {PerPage: 2, Page: 99}, {Page: MAX_PAGE}, {Page: math.MaxInt}} {
+		for {
+			expectedResponse, expectedErr := expected.GetAccounts(query)
+			response, err := actual.GetAccounts(query)
+			assertSameResult(t, expectedResponse, expectedErr, response, err, "accounts %+v", query)
+			if expectedResponse == nil || expectedResponse.NextCursor == "" {
+				break
+			}
+			query = AccountQuery{PerPage: query.PerPage, Cursor: expectedResponse.NextCursor}
+		}
+	}
+
+	for _, summary := range accounts.Accounts {
+		id := summary.Account.GetId()
+		assert.True(t, actual.AccountExists(id), id)
+
+		expectedAccount, expectedErr := expected.GetAccount(id)
+		account, err := actual.GetAccount(id)
+		assertSameResult(t, expectedAccount, expectedErr, account, err, id)
+		if expectedAccount == nil {
+			continue
+		}
+
+		expectedStatements, expectedErr := expected.GetAccountStatements(id)
+		statements, err := actual.GetAccountStatements(id)
+		assertSameResult(t, expectedStatements, expectedErr, statements, err, id)
+		for _, expectedSummary := range expectedStatements.Statements {
+			statementId := expectedSummary.Id
+			expectedStatement, expectedErr := expected.GetAccountStatement(id, statementId)
+			statement, err := actual.GetAccountStatement(id, statementId)
+			assertSameResult(t, expectedStatement, expectedErr, statement, err, statementId)
+
+			expectedReconciliation, expectedErr := expected.GetStatementReconciliation(id, statementId)
+			reconciliation, err := actual.GetStatementReconciliation(id, statementId)
+			assertSameResult(t, expectedReconciliation, expectedErr, reconciliation, err, statementId)
+		}
+
+		for _, query := range []BalanceQuery{{}, {Types: []string{"clbd", "OPBD"}}, {FromDate: date("2018-12-18"), ToDate: date("2024-03-02")}, {AsOf: date("2018-12-18")}} {
+			expectedBalances, expectedErr := expected.GetAccountBalances(id, query)
+			balances, err := actual.GetAccountBalances(id, query)
+			assertSameResult(t, expectedBalances, expectedErr, balances, err, "%s %+v", id, query)
+		}
+
+		expectedExport, expectedErr := expected.ExportAccountTransactions(id, TransactionQuery{})
+		assert.NoError(t, expectedErr)
+		for _, expectedEntry := range expectedExport {
+			ref := *expectedEntry.URLReference
+			expectedTransaction, expectedErr := expected.GetAccountTransaction(id, ref)
+			transaction, err := actual.GetAccountTransaction(id, ref)
+			assertSameResult(t, expectedTransaction, expectedErr, transaction, err, ref)
+		}
+
+		for _, query := range conformanceTransactionQueries {
+			compareTransactions(t, expected, actual, id, query)
+		}
+	}
+}
+
+// testConformance runs the conformance suite against a database backend. Every backend has to behave the same way
+// as the in memory BankData, so the suite loads the same documents into the backend and into a BankData and checks
+// that the load summaries and every read, including errors, pages and cursors, are the same.
+func testConformance(t *testing.T, open openStore) {
+	t.Run("Load documents", func(t *testing.T) {
+		expected, actual := NewBankData(), open(t)
+		for _, step := range conformanceSteps(t) {
+			step.load(t, &expected, actual)
+		}
+		compareStores(t, &expected, actual)
+
+		// Reads through views are the same as direct reads
+		assert.NoError(t, expected.View(func(expectedView IDataReader) error {
+			return actual.View(func(view IDataReader) error {
+				compareStores(t, expectedView, view)
+				return nil
+			})
+		}))
+	})
+
+	t.Run("Rejected documents", func(t *testing.T) {
+		dir := t.TempDir()
+		unbalanced := loadTestDocument(t)
+		findBalance(unbalanced.BankStatement.Statements[0].Balances, "CLBD").Amount.Value = camt053.NewDecimal(100, 2)
+		invalid := loadTestDocument(t)
+		(*invalid.BankStatement.Statements[0].Entries)[6].CreditDebitIndicator = "CREDIT"
+		unmatched := notificationDocument(t)
+		(*unmatched.Notification.Notifications[0].Entries)[0].AccountServicerRef = nil
+
+		t.Setenv("RECONCILIATION_MODE", RECONCILIATION_MODE_STRICT)
+		expected, actual := NewBankData(), open(t)
+		for _, step := range []conformanceStep{
+			{name: "Unbalanced statement in strict mode", doc: unbalanced},
+			{name: "Streamed unbalanced statement in strict mode", path: writeTestDocument(t, dir, "unbalanced.xml", unbalanced)},
+			{name: "Invalid entry", doc: invalid},
+			{name: "Streamed invalid entry", path: writeTestDocument(t, dir, "invalid.xml", invalid)},
+			{name: "Notification entry without reference", doc: unmatched},
+		} {
+			step.load(t, &expected, actual)
+		}
+
+		accounts, err := actual.GetAccounts(AccountQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, accounts.TotalCount)
+		compareStores(t, &expected, actual)
+	})
+
+	t.Run("Create account", func(t *testing.T) {
+		expected, actual := NewBankData(), open(t)
+		doc := loadTestDocument(t)
+		for _, camtAcc := range []camt053.Account{doc.BankStatement.Statements[0].Account, doc.BankStatement.Statements[0].Account, {}} {
+			expectedAccount, expectedErr := expected.CreateAccount(&camtAcc)
+			account, err := actual.CreateAccount(&camtAcc)
+			assertSameResult(t, expectedAccount, expectedErr, account, err)
+		}
+
+		// Statements are loaded into accounts that have been created
+		step := conformanceStep{name: "Statement", doc: doc}
+		step.load(t, &expected, actual)
+		compareStores(t, &expected, actual)
+	})
+
+	t.Run("Missing resources and invalid queries", func(t *testing.T) {
+		expected, actual := NewBankData(), open(t)
+		step := conformanceStep{name: "Statement", doc: loadTestDocument(t)}
+		step.load(t, &expected, actual)
+
+		for _, accountId := range []string{testAccountId, "missing"} {
+			assert.Equal(t, expected.AccountExists(accountId), actual.AccountExists(accountId))
+
+			expectedAccount, expectedErr := expected.GetAccount(accountId)
+			account, err := actual.GetAccount(accountId)
+			assertSameResult(t, expectedAccount, expectedErr, account, err, accountId)
+
+			for _, query := range []TransactionQuery{{}, {Cursor: "invalid"}, {SortBy: "reference"}, {Page: 1, Cursor: "invalid"}, {MinAmount: amount("2"), MaxAmount: amount("1")},
+				{Page: MAX_PAGE, PerPage: MAX_PER_PAGE}, {Page: math.MaxInt}} {
+				expectedResponse, expectedErr := expected.GetAccountTransactions(accountId, query)
+				response, err := actual.GetAccountTransactions(accountId, query)
+				assertSameResult(t, expectedResponse, expectedErr, response, err, "%s %+v", accountId, query)
+			}
+			expectedExport, expectedErr := expected.ExportAccountTransactions(accountId, TransactionQuery{Page: 1})
+			export, err := actual.ExportAccountTransactions(accountId, TransactionQuery{Page: 1})
+			assertSameResult(t, expectedExport, expectedErr, export, err, accountId)
+
+			expectedTransaction, expectedErr := expected.GetAccountTransaction(accountId, "missing")
+			transaction, err := actual.GetAccountTransaction(accountId, "missing")
+			assertSameResult(t, expectedTransaction, expectedErr, transaction, err, accountId)
+
+			expectedBalances, expectedErr := expected.GetAccountBalances(accountId, BalanceQuery{AsOf: date("2018-12-17"), FromDate: date("2018-12-17")})
+			balances, err := actual.GetAccountBalances(accountId, BalanceQuery{AsOf: date("2018-12-17"), FromDate: date("2018-12-17")})
+			assertSameResult(t, expectedBalances, expectedErr, balances, err, accountId)
+
+			expectedStatement, expectedErr := expected.GetAccountStatement(accountId, "missing")
+			statement, err := actual.GetAccountStatement(accountId, "missing")
+			assertSameResult(t, expectedStatement, expectedErr, statement, err, accountId)
+
+			expectedReconciliation, expectedErr := expected.GetStatementReconciliation(accountId, "missing")
+			reconciliation, err := actual.GetStatementReconciliation(accountId, "missing")
+			assertSameResult(t, expectedReconciliation, expectedErr, reconciliation, err, accountId)
+		}
+
+		// Cursors are only valid for the query they were created for
+		response, err := actual.GetAccountTransactions(testAccountId, TransactionQuery{PerPage: 2})
+		if assert.NoError(t, err) {
+			_, err = actual.GetAccountTransactions(testAccountId, TransactionQuery{PerPage: 2, Cursor: response.NextCursor, Descending: true})
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+			_, err = actual.GetAccounts(AccountQuery{Cursor: response.NextCursor})
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		}
+	})
+
+	t.Run("Concurrent loads and reads", func(t *testing.T) {
+		store := open(t)
+		paths := generateTestFiles(t, t.TempDir(), 3, 5, 6)
+
+		done := make(chan struct{})
+		var writers, readers sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			writers.Add(1)
+			go func(first int) {
+				defer writers.Done()
+				for j := first; j < len(paths); j += 2 {
+					assert.NoError(t, ImportFile(store, paths[j]).Err)
+				}
+			}(i)
+		}
+		for i := 0; i < STRESS_READERS; i++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					assert.NoError(t, store.View(func(view IDataReader) error {
+						checkView(t, view)
+						return nil
+					}))
+				}
+			}()
+		}
+		writers.Wait()
+		close(done)
+		readers.Wait()
+
+		accounts, err := store.GetAccounts(AccountQuery{})
+		assert.NoError(t, err)
+		for _, summary := range accounts.Accounts {
+			statements, err := store.GetAccountStatements(summary.Account.GetId())
+			assert.NoError(t, err)
+			assert.Equal(t, len(paths), statements.TotalCount)
+		}
+	})
+}
+
+// TestConformanceBankData runs the conformance suite against the in memory database, which checks the suite itself.
+func TestConformanceBankData(t *testing.T) {
+	testConformance(t, func(t *testing.T) IDataBase {
+		db := NewBankData()
+		return &db
+	})
+}
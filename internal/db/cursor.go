@@ -0,0 +1,114 @@
+// package db is a local mock database.
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidCursor is returned when a cursor token has been tampered with, is malformed or
+// was created for a different query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the boundary item of a page in a sorted list of resources. Since a cursor
+// refers to an item rather than a position, pages stay stable when resources are added to the list.
+type Cursor struct {
+	Key    string `json:"k"` // Sort value of the boundary item
+	Ref    string `json:"r"` // Reference of the boundary item, used to order items with equal sort values
+	Before bool   `json:"b"` // Select the items before the boundary instead of after it
+	Query  string `json:"q"` // Fingerprint of the query the cursor was created for
+}
+
+var cursorSecret []byte
+var cursorSecretOnce sync.Once
+
+// getCursorSecret returns the key used to sign cursor tokens. It is read from the CURSOR_SECRET
+// env variable, if it is not set a random key is generated and cursors are only valid until restart.
+func getCursorSecret() []byte {
+	cursorSecretOnce.Do(func() {
+		if secret := os.Getenv("CURSOR_SECRET"); secret != "" {
+			cursorSecret = []byte(secret)
+			return
+		}
+		cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cursorSecret); err != nil {
+			panic(err)
+		}
+	})
+	return cursorSecret
+}
+
+// Encode converts the cursor into an opaque, URL safe and signed token.
+func (c Cursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signCursor(encodedPayload))
+}
+
+// DecodeCursor verifies and decodes a cursor token created with Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	var cursor Cursor
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return cursor, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signCursor(encodedPayload)) {
+		return cursor, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func signCursor(encodedPayload string) []byte {
+	mac := hmac.New(sha256.New, getCursorSecret())
+	mac.Write([]byte(encodedPayload))
+	return mac.Sum(nil)
+}
+
+// queryFingerprint hashes the parts of a query that determine the order and content of a list.
+func queryFingerprint(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:8])
+}
+
+// pageWindow selects the items of a page from a sorted list of n items, either by page number or by cursor.
+// compareToBoundary returns a negative number if the item at index i is before the cursor boundary,
+// zero if it is the boundary and a positive number if it is after it.
+func pageWindow(n int, page int, perPage int, cursor *Cursor, compareToBoundary func(i int) int) (start int, end int) {
+	switch {
+	case cursor == nil:
+		// Pages after the last item are empty, the position of their first item is not computed so that it can not overflow
+		start = n
+		if page <= 1 {
+			start = 0
+		} else if perPage > 0 && page-1 <= n/perPage {
+			start = min((page-1)*perPage, n)
+		}
+		end = start + min(perPage, n-start)
+	case cursor.Before:
+		end = sort.Search(n, func(i int) bool { return compareToBoundary(i) >= 0 })
+		start = max(end-perPage, 0)
+	default:
+		start = sort.Search(n, func(i int) bool { return compareToBoundary(i) > 0 })
+		end = min(start+perPage, n)
+	}
+	return start, end
+}
@@ -0,0 +1,198 @@
+// package db is a local mock database.
+package db
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadDay loads a copy of the mock statement with all entries booked on date.
+func loadDay(t *testing.T, db *BankData, id string, day string) {
+	doc := loadTestDocument(t)
+	stmt := nextDayStatement(doc.BankStatement.Statements[0], id, day)
+	bookingDate := date(day)
+	for i := range *stmt.Entries {
+		(*stmt.Entries)[i].BookingDate = &camt053.DateAndDateTime{Date: bookingDate}
+	}
+	doc.BankStatement.Statements = []camt053.Statement{stmt}
+
+	if _, err := db.LoadCamt053(doc); err != nil {
+		t.Fatalf("failed to load test data: %s", err)
+	}
+}
+
+// TestCursorEncoding checks that cursors survive encoding and that modified tokens are rejected.
+func TestCursorEncoding(t *testing.T) {
+	cursor := Cursor{Key: "2018-12-17", Ref: "JAMBO81518-0029248", Before: true, Query: "abc"}
+	token := cursor.Encode()
+
+	decoded, err := DecodeCursor(token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	// Combine the payload of one cursor with the signature of another
+	_, signature, _ := strings.Cut(token, ".")
+	forgedPayload, _, _ := strings.Cut(Cursor{Key: "2018-12-18", Query: "abc"}.Encode(), ".")
+
+	// Declare Tests
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"Empty", ""},
+		{"Not a token", "abc"},
+		{"Forged payload", forgedPayload + "." + signature},
+		{"Truncated signature", token[:len(token)-2]},
+		{"Invalid base64", "!!!." + signature},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeCursor(test.token)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+// TestTransactionCursorQuery checks that cursors can only be used with the query they were created for.
+func TestTransactionCursorQuery(t *testing.T) {
+	db := loadTwoDays(t)
+
+	first, err := db.GetAccountTransactions(testAccountId, TransactionQuery{PerPage: 5, SortBy: SORT_AMOUNT})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	queries := []TransactionQuery{
+		{Cursor: first.NextCursor, PerPage: 5},
+		{Cursor: first.NextCursor, PerPage: 5, SortBy: SORT_AMOUNT, Descending: true},
+		{Cursor: first.NextCursor, PerPage: 5, SortBy: SORT_AMOUNT, Status: "BOOK"},
+	}
+	for _, query := range queries {
+		_, err := db.GetAccountTransactions(testAccountId, query)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	}
+
+	_, err = db.GetAccountTransactions(testAccountId, TransactionQuery{Page: 2, Cursor: first.NextCursor})
+	assert.Error(t, err)
+
+	// The page size may change between requests
+	second, err := db.GetAccountTransactions(testAccountId, TransactionQuery{Cursor: first.NextCursor, PerPage: 3, SortBy: SORT_AMOUNT})
+	assert.NoError(t, err)
+	assert.Len(t, second.Transactions, 3)
+	assert.Equal(t, 0, second.Page)
+
+	// Going back from the second page returns the end of the first page
+	previous, err := db.GetAccountTransactions(testAccountId, TransactionQuery{Cursor: second.PrevCursor, PerPage: 5, SortBy: SORT_AMOUNT})
+	assert.NoError(t, err)
+	assert.Equal(t, first.Transactions, previous.Transactions)
+	assert.Empty(t, previous.PrevCursor)
+}
+
+// TestTransactionCursorStability checks that iterating with cursors neither skips nor repeats
+// transactions while new statements are loaded between the pages.
+func TestTransactionCursorStability(t *testing.T) {
+	db := loadTwoDays(t)
+
+	seen := make(map[string]int)
+	query := TransactionQuery{PerPage: 3}
+	for pages := 0; ; pages++ {
+		response, err := db.GetAccountTransactions(testAccountId, query)
+		assert.NoError(t, err)
+		for _, transaction := range response.Transactions {
+			seen[*transaction.URLReference]++
+		}
+
+		// Load transactions both before and after the current page
+		if pages == 1 {
+			loadDay(t, db, "STMT-2018-12-01", "2018-12-01")
+			loadDay(t, db, "STMT-2018-12-31", "2018-12-31")
+		}
+
+		if response.NextCursor == "" {
+			break
+		}
+		query.Cursor = response.NextCursor
+	}
+
+	// The original 14 transactions and the 7 loaded after the current page
+	assert.Len(t, seen, 21)
+	for ref, count := range seen {
+		assert.Equal(t, 1, count, ref)
+		assert.False(t, strings.HasPrefix(ref, "STMT-2018-12-01"), "transaction loaded before the cursor was returned")
+	}
+}
+
+// TestAccountCursor checks that accounts can be paged through with cursors.
+func TestAccountCursor(t *testing.T) {
+	db := NewBankData()
+	for _, id := range []string{"SE3550000000054910000003", "54400001111", "DE89370400440532013000"} {
+		_, err := db.CreateAccount(&camt053.Account{Id: camt053.AccountId{IBAN: &id}})
+		assert.NoError(t, err)
+	}
+
+	first, err := db.GetAccounts(AccountQuery{PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, first.TotalCount)
+	assert.Equal(t, "54400001111", first.Accounts[0].Account.GetId())
+	assert.Equal(t, "DE89370400440532013000", first.Accounts[1].Account.GetId())
+
+	// An account sorted before the cursor does not shift the next page
+	id := "AT611904300234573201"
+	_, err = db.CreateAccount(&camt053.Account{Id: camt053.AccountId{IBAN: &id}})
+	assert.NoError(t, err)
+
+	second, err := db.GetAccounts(AccountQuery{PerPage: 2, Cursor: first.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, second.Accounts, 1)
+	assert.Equal(t, "SE3550000000054910000003", second.Accounts[0].Account.GetId())
+	assert.Empty(t, second.NextCursor)
+
+	_, err = db.GetAccounts(AccountQuery{Cursor: "abc.def"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	// Cursors of other lists are rejected
+	transactionCursor := Cursor{Ref: "54400001111", Query: TransactionQuery{}.fingerprint(testAccountId)}.Encode()
+	_, err = db.GetAccounts(AccountQuery{Cursor: transactionCursor})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+// TestPageWindow checks that page numbers select their window and that large page numbers give an empty page.
+func TestPageWindow(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		page          int
+		perPage       int
+		expectedStart int
+		expectedEnd   int
+	}{
+		{1, 50, 0, 50},
+		{2, 50, 50, 100},
+		{3, 50, 100, 120},
+		{4, 50, 120, 120},
+		{0, 50, 0, 50},
+		{MAX_PAGE, MAX_PER_PAGE, 120, 120},
+		{math.MaxInt, 50, 120, 120},
+		{math.MaxInt, math.MaxInt, 120, 120},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		start, end := pageWindow(120, test.page, test.perPage, nil, nil)
+		assert.Equal(t, test.expectedStart, start, "page %d of %d", test.page, test.perPage)
+		assert.Equal(t, test.expectedEnd, end, "page %d of %d", test.page, test.perPage)
+	}
+
+	// Page numbers that could overflow are rejected before they reach the database
+	db := NewBankData()
+	_, err := db.GetAccountTransactions(testAccountId, TransactionQuery{Page: math.MaxInt})
+	assert.ErrorContains(t, err, "page can be at most")
+	_, err = db.GetAccounts(AccountQuery{Page: math.MaxInt})
+	assert.ErrorContains(t, err, "page can be at most")
+}
@@ -0,0 +1,188 @@
+// package db is a local mock database.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+const DB_LOG_STRING = "[DB]"
+const IMPORT_LOADED = "LOADED"
+const IMPORT_FAILED = "FAILED"
+
+// Default glob used to find statement files in the data directory.
+const DEFAULT_DATA_GLOB = "*.xml"
+
+// ImportResult describes the outcome of importing a single statement file.
+type ImportResult struct {
+	Path         string
+	Statements   int
+	Transactions int
+	Err          error
+	Warnings     []string  // Warnings of the load, e.g. statements that do not reconcile
+	ModTime      time.Time // Modification time of the file when it was imported
+	Size         int64
+	ImportedAt   time.Time
+}
+
+// Status returns LOADED if the file was imported and FAILED otherwise.
+func (r ImportResult) Status() string {
+	if r.Err != nil {
+		return IMPORT_FAILED
+	}
+	return IMPORT_LOADED
+}
+
+// DataDirectory returns the directory statement files are imported from.
+// It is configured with the DATA_DIR env variable and defaults to $PROJECT_DIR/data.
+func DataDirectory() string {
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("PROJECT_DIR"), "data")
+}
+
+// DataGlob returns the glob used to match statement files in the data directory.
+// It is configured with the DATA_GLOB env variable and defaults to *.xml.
+func DataGlob() string {
+	if glob := os.Getenv("DATA_GLOB"); glob != "" {
+		return glob
+	}
+	return DEFAULT_DATA_GLOB
+}
+
+// ImportDirectory parses and loads every file in dir matching the glob pattern into the database.
+// Files that fail to parse or load are reported in their ImportResult and do not stop the import.
+func ImportDirectory(store IDataBase, dir string, pattern string) ([]ImportResult, error) {
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, 0, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			continue
+		}
+		results = append(results, ImportFile(store, path))
+	}
+
+	return results, nil
+}
+
+// ImportFile parses and loads a single statement file into the database.
+func ImportFile(store IDataBase, path string) ImportResult {
+	result := ImportResult{Path: path, ImportedAt: time.Now()}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		result.Err = fmt.Errorf("unable to read file: %w", err)
+		return result
+	}
+	result.ModTime, result.Size = info.ModTime(), info.Size()
+
+	isXML, err := isXMLFile(path)
+	if err != nil {
+		result.Err = fmt.Errorf("unable to read file: %w", err)
+		return result
+	}
+
+	// XML documents are streamed so that large files can be imported with bounded memory
+	var summary LoadSummary
+	if isXML {
+		summary, err = store.LoadCamt053File(path)
+		var parseErr *camt053.ParseError
+		if errors.As(err, &parseErr) {
+			result.Err = fmt.Errorf("unable to parse file: %w", err)
+			return result
+		}
+	} else {
+		var data camt053.Document
+		data, err = ParseLocalCamt053(path)
+		if err != nil {
+			result.Err = fmt.Errorf("unable to parse file: %w", err)
+			return result
+		}
+		summary, err = store.LoadCamt053(data)
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("unable to load file: %w", err)
+		return result
+	}
+	result.Statements, result.Transactions = summary.Counts()
+	result.Warnings = summary.Warnings
+
+	return result
+}
+
+// logImportResult prints the outcome of a file import.
+func logImportResult(result ImportResult) {
+	if result.Err != nil {
+		fmt.Printf("%s %-20s | %-6s | %s | %s\n",
+			DB_LOG_STRING,
+			time.Now().Format(time.RFC822),
+			result.Status(),
+			result.Path,
+			result.Err,
+		)
+		return
+	}
+	fmt.Printf("%s %-20s | %-6s | %s | statements %d | transactions %d\n",
+		DB_LOG_STRING,
+		time.Now().Format(time.RFC822),
+		result.Status(),
+		result.Path,
+		result.Statements,
+		result.Transactions,
+	)
+	for _, warning := range result.Warnings {
+		fmt.Printf("%s %-20s | %-6s | %s | %s\n",
+			DB_LOG_STRING,
+			time.Now().Format(time.RFC822),
+			"WARN",
+			result.Path,
+			warning,
+		)
+	}
+}
+
+// InitializeLocalMockData imports every statement file in the data directory into the database and records them in the ingestion log.
+// An error is only returned if the data directory can not be read or no file could be loaded,
+// files that fail to load are reported and skipped.
+func InitializeLocalMockData(store IDataBase, log *IngestionLog) (err error) {
+	dir, pattern := DataDirectory(), DataGlob()
+	results, err := ImportDirectory(store, dir, pattern)
+	if err != nil {
+		return err
+	}
+
+	loaded := 0
+	for _, result := range results {
+		logImportResult(result)
+		log.Record(result)
+		if result.Err == nil {
+			loaded++
+		}
+	}
+	fmt.Printf("%s Loaded %d of %d files matching %s\n", DB_LOG_STRING, loaded, len(results), filepath.Join(dir, pattern))
+
+	if loaded == 0 {
+		return errors.New("no statement files could be loaded from " + dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+// package db is a local mock database.
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// copyTestFile copies a file from the data directory into dir.
+func copyTestFile(t *testing.T, dir string, name string, target string) {
+	data, err := os.ReadFile(filepath.Join("../../data", name))
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, target), data, 0o644); err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+}
+
+// TestImportDirectory checks that all matching files are imported and that bad files are reported and skipped.
+func TestImportDirectory(t *testing.T) {
+	db := NewBankData()
+	dir := t.TempDir()
+
+	copyTestFile(t, dir, "camt053.xml", "a_seb.xml")
+	copyTestFile(t, dir, "goldman_sachs_camt053.xml", "c_goldman_sachs.xml")
+	copyTestFile(t, dir, "camt053.xml", "d_not_matching.txt")
+	os.WriteFile(filepath.Join(dir, "b_broken.xml"), []byte("<Document><BkToCstmrStmt>"), 0o644)
+	os.WriteFile(filepath.Join(dir, "e_empty.xml"), []byte("<Document></Document>"), 0o644)
+
+	results, err := ImportDirectory(&db, dir, DEFAULT_DATA_GLOB)
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	// Results are ordered by file name
+	assert.Equal(t, IMPORT_LOADED, results[0].Status())
+	assert.Equal(t, 1, results[0].Statements)
+	assert.Equal(t, 7, results[0].Transactions)
+
+	assert.Equal(t, IMPORT_FAILED, results[1].Status())
+	assert.Contains(t, results[1].Err.Error(), "unable to parse file")
+
+	assert.Equal(t, IMPORT_LOADED, results[2].Status())
+	assert.Equal(t, 15, results[2].Transactions)
+
+	assert.Equal(t, IMPORT_FAILED, results[3].Status())
+	assert.Contains(t, results[3].Err.Error(), "unable to load file")
+
+	assert.True(t, db.AccountExists(testAccountId))
+	assert.True(t, db.AccountExists("DD01100056869"))
+	assert.Equal(t, uint64(2), db.TotalAccounts)
+}
+
+// TestImportDirectoryMissing checks that a missing data directory is reported.
+func TestImportDirectoryMissing(t *testing.T) {
+	db := NewBankData()
+
+	_, err := ImportDirectory(&db, filepath.Join(t.TempDir(), "missing"), DEFAULT_DATA_GLOB)
+	assert.Error(t, err)
+
+	_, err = ImportDirectory(&db, t.TempDir(), "[")
+	assert.Error(t, err)
+}
+
+// TestImportFileWarnings checks that the warnings of a load are kept in the import result.
+func TestImportFileWarnings(t *testing.T) {
+	db := NewBankData()
+	path := filepath.Join(t.TempDir(), "statement.bai")
+	os.WriteFile(path, []byte("01,S,R,181217,0600,F1,80,,2/\n02,R,B,1,181216,,USD,2/\n"+
+		"03,54400003333,,010,100,,,102,0,,/\n49,100,2/\n98,100,1,4/\n99,100,1,6/"), 0o644)
+
+	result := ImportFile(&db, path)
+	assert.NoError(t, result.Err)
+	if assert.Len(t, result.Warnings, 1) {
+		assert.Contains(t, result.Warnings[0], "summary type codes 102")
+	}
+}
@@ -0,0 +1,279 @@
+// package db is a local mock database.
+package db
+
+import (
+	"slices"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// loader stores the parts of a document while it is being loaded. It is implemented by the in memory database and by
+// the transactions of the SQL databases, so that every database merges documents into the data already loaded by
+// the same rules, see loadDocument. Lookups return nil when nothing has been stored.
+type loader interface {
+	accountDetails(accountId string) (*camt053.Account, error)
+	insertAccount(account camt053.Account) error
+	updateAccount(account camt053.Account) error
+	saveBalance(accountId string, balance camt053.Balance) error                    // Replaces the balance with the same balanceKey
+	saveStatement(accountId string, statement Statement) error                      // Keeps the transactions and reconciliation of a statement loaded again
+	addStatementTransaction(accountId string, statementId string, ref string) error // Adds the ref unless the statement already has it
+	saveReconciliation(accountId string, statementId string, reconciliation *Reconciliation) error
+	transaction(accountId string, ref string) (*camt053.Entry, error)
+	saveTransaction(accountId string, entry camt053.Entry) error
+	notifications(accountId string, entry camt053.Entry) ([]camt053.Entry, error) // Stored notification entries that may refer to the entry
+	saveNotification(accountId string, notification camt053.Entry) error          // Replaces the notification entry with the same entryKey
+	deleteNotification(accountId string, notification camt053.Entry) error        // Deletes the notification entry with the same entryKey
+	findTransaction(accountId string, notification camt053.Entry) (*camt053.Entry, error)
+}
+
+var _ loader = &BankData{}
+
+func (db *BankData) accountDetails(accountId string) (*camt053.Account, error) {
+	account, ok := db.Accounts[accountId]
+	if !ok {
+		return nil, nil
+	}
+	details := account.Account
+	return &details, nil
+}
+
+func (db *BankData) insertAccount(account camt053.Account) error {
+	_, err := db.createAccount(&account)
+	return err
+}
+
+func (db *BankData) updateAccount(account camt053.Account) error {
+	db.Accounts[account.GetId()].Account = account
+	return nil
+}
+
+func (db *BankData) saveBalance(accountId string, balance camt053.Balance) error {
+	db.Accounts[accountId].mergeBalance(balance)
+	return nil
+}
+
+func (db *BankData) saveStatement(accountId string, statement Statement) error {
+	account := db.Accounts[accountId]
+	if existing, ok := account.Statements[statement.Id]; ok {
+		statement.TransactionRefs, statement.Reconciliation = existing.TransactionRefs, existing.Reconciliation
+	}
+	account.Statements[statement.Id] = &statement
+	return nil
+}
+
+func (db *BankData) addStatementTransaction(accountId string, statementId string, ref string) error {
+	statement := db.Accounts[accountId].Statements[statementId]
+	if !slices.Contains(statement.TransactionRefs, ref) {
+		statement.TransactionRefs = append(statement.TransactionRefs, ref)
+	}
+	return nil
+}
+
+func (db *BankData) saveReconciliation(accountId string, statementId string, reconciliation *Reconciliation) error {
+	db.Accounts[accountId].Statements[statementId].Reconciliation = reconciliation
+	return nil
+}
+
+func (db *BankData) transaction(accountId string, ref string) (*camt053.Entry, error) {
+	transaction, ok := db.Accounts[accountId].Transactions[ref]
+	if !ok {
+		return nil, nil
+	}
+	return &transaction, nil
+}
+
+func (db *BankData) saveTransaction(accountId string, entry camt053.Entry) error {
+	if db.transactionRefs == nil {
+		db.transactionRefs = make(map[string]*entryIndex)
+	}
+	refs, ok := db.transactionRefs[accountId]
+	if !ok {
+		refs = newEntryIndex()
+		db.transactionRefs[accountId] = refs
+	}
+
+	transactions, ref := db.Accounts[accountId].Transactions, *entry.URLReference
+	if existing, ok := transactions[ref]; ok {
+		refs.remove(ref, existing)
+	}
+	transactions[ref] = entry
+	refs.add(ref, entry)
+	return nil
+}
+
+func (db *BankData) notifications(accountId string, entry camt053.Entry) ([]camt053.Entry, error) {
+	pending, ok := db.pending[accountId]
+	if !ok {
+		return nil, nil
+	}
+	notifications := make([]camt053.Entry, 0)
+	for _, key := range pending.refs.lookup(entry) {
+		notifications = append(notifications, pending.entries[key])
+	}
+	return notifications, nil
+}
+
+func (db *BankData) saveNotification(accountId string, notification camt053.Entry) error {
+	if db.pending == nil {
+		db.pending = make(map[string]*pendingNotifications)
+	}
+	pending, ok := db.pending[accountId]
+	if !ok {
+		pending = &pendingNotifications{entries: make(map[string]camt053.Entry), refs: newEntryIndex()}
+		db.pending[accountId] = pending
+	}
+
+	key := entryKey(notification)
+	if existing, ok := pending.entries[key]; ok {
+		pending.refs.remove(key, existing)
+	}
+	pending.entries[key] = notification
+	pending.refs.add(key, notification)
+	return nil
+}
+
+func (db *BankData) deleteNotification(accountId string, notification camt053.Entry) error {
+	pending, ok := db.pending[accountId]
+	if !ok {
+		return nil
+	}
+	key := entryKey(notification)
+	if existing, ok := pending.entries[key]; ok {
+		pending.refs.remove(key, existing)
+		delete(pending.entries, key)
+	}
+	if len(pending.entries) == 0 {
+		delete(db.pending, accountId)
+	}
+	return nil
+}
+
+// findTransaction returns the transaction that a notification entry refers to, the account is created when its first statement is loaded.
+func (db *BankData) findTransaction(accountId string, notification camt053.Entry) (*camt053.Entry, error) {
+	account, ok := db.Accounts[accountId]
+	if !ok {
+		return nil, nil
+	}
+	refs, ok := db.transactionRefs[accountId]
+	if !ok {
+		return nil, nil
+	}
+	for _, ref := range refs.lookup(notification) {
+		if transaction := account.Transactions[ref]; matchesNotification(transaction, notification) {
+			return &transaction, nil
+		}
+	}
+	return nil, nil
+}
+
+// journal is a loader that records how to undo each change it makes to the in memory database, so that a document
+// that fails part way through loading can be rolled back like the transaction of a SQL database. It must only be
+// used while holding the write lock, and it keeps an undo step for every change until the document has been loaded.
+type journal struct {
+	*BankData
+	undo []func()
+}
+
+var _ loader = &journal{}
+
+// rollback undoes every change in the reverse order they were made.
+func (j *journal) rollback() {
+	for i := len(j.undo) - 1; i >= 0; i-- {
+		j.undo[i]()
+	}
+	j.undo = nil
+}
+
+func (j *journal) insertAccount(account camt053.Account) error {
+	if err := j.BankData.insertAccount(account); err != nil {
+		return err
+	}
+	j.undo = append(j.undo, func() {
+		delete(j.Accounts, account.GetId())
+		delete(j.transactionRefs, account.GetId())
+		j.TotalAccounts--
+	})
+	return nil
+}
+
+func (j *journal) updateAccount(account camt053.Account) error {
+	previous := j.Accounts[account.GetId()].Account
+	j.undo = append(j.undo, func() { j.Accounts[account.GetId()].Account = previous })
+	return j.BankData.updateAccount(account)
+}
+
+func (j *journal) saveBalance(accountId string, balance camt053.Balance) error {
+	account := j.Accounts[accountId]
+	previous := slices.Clone(account.Balances)
+	j.undo = append(j.undo, func() { account.Balances = previous })
+	return j.BankData.saveBalance(accountId, balance)
+}
+
+func (j *journal) saveStatement(accountId string, statement Statement) error {
+	statements := j.Accounts[accountId].Statements
+	previous, ok := statements[statement.Id]
+	j.undo = append(j.undo, func() {
+		if ok {
+			statements[statement.Id] = previous
+		} else {
+			delete(statements, statement.Id)
+		}
+	})
+	return j.BankData.saveStatement(accountId, statement)
+}
+
+func (j *journal) addStatementTransaction(accountId string, statementId string, ref string) error {
+	statement := j.Accounts[accountId].Statements[statementId]
+	refs := len(statement.TransactionRefs)
+	j.undo = append(j.undo, func() { statement.TransactionRefs = statement.TransactionRefs[:refs] })
+	return j.BankData.addStatementTransaction(accountId, statementId, ref)
+}
+
+func (j *journal) saveReconciliation(accountId string, statementId string, reconciliation *Reconciliation) error {
+	statement := j.Accounts[accountId].Statements[statementId]
+	previous := statement.Reconciliation
+	j.undo = append(j.undo, func() { statement.Reconciliation = previous })
+	return j.BankData.saveReconciliation(accountId, statementId, reconciliation)
+}
+
+func (j *journal) saveTransaction(accountId string, entry camt053.Entry) error {
+	ref := *entry.URLReference
+	previous, ok := j.Accounts[accountId].Transactions[ref]
+	j.undo = append(j.undo, func() {
+		if ok {
+			j.BankData.saveTransaction(accountId, previous)
+			return
+		}
+		j.transactionRefs[accountId].remove(ref, entry)
+		delete(j.Accounts[accountId].Transactions, ref)
+	})
+	return j.BankData.saveTransaction(accountId, entry)
+}
+
+func (j *journal) saveNotification(accountId string, notification camt053.Entry) error {
+	previous, ok := j.pendingNotification(accountId, notification)
+	j.undo = append(j.undo, func() {
+		j.BankData.deleteNotification(accountId, notification)
+		if ok {
+			j.BankData.saveNotification(accountId, previous)
+		}
+	})
+	return j.BankData.saveNotification(accountId, notification)
+}
+
+func (j *journal) deleteNotification(accountId string, notification camt053.Entry) error {
+	if previous, ok := j.pendingNotification(accountId, notification); ok {
+		j.undo = append(j.undo, func() { j.BankData.saveNotification(accountId, previous) })
+	}
+	return j.BankData.deleteNotification(accountId, notification)
+}
+
+// pendingNotification returns the stored notification entry with the same entryKey as the notification.
+func (db *BankData) pendingNotification(accountId string, notification camt053.Entry) (camt053.Entry, bool) {
+	pending, ok := db.pending[accountId]
+	if !ok {
+		return camt053.Entry{}, false
+	}
+	entry, ok := pending.entries[entryKey(notification)]
+	return entry, ok
+}
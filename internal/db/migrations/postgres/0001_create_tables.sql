@@ -0,0 +1,79 @@
+-- Accounts, statements, balances and transactions are stored as JSONB together with the columns they are
+-- looked up, filtered and sorted by. Rows with an identity id keep the order they were added in.
+--
+-- Ids, refs and sort keys use the "C" collation, so that they sort byte by byte the same way as in the
+-- in memory and SQLite databases rather than by the rules of the language the database was created with.
+
+CREATE TABLE accounts (
+    id      TEXT COLLATE "C" PRIMARY KEY,
+    account JSONB NOT NULL -- camt053 account details
+);
+
+CREATE TABLE balances (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id  TEXT COLLATE "C" NOT NULL REFERENCES accounts (id),
+    balance_key TEXT NOT NULL, -- Type and date of the balance, a later balance with the same key replaces it
+    date        TEXT COLLATE "C" NOT NULL, -- YYYY-MM-DD
+    balance     JSONB NOT NULL,
+    UNIQUE (account_id, balance_key)
+);
+CREATE INDEX balances_account_date ON balances (account_id, date);
+
+CREATE TABLE statements (
+    account_id     TEXT COLLATE "C" NOT NULL REFERENCES accounts (id),
+    id             TEXT NOT NULL,
+    statement      JSONB NOT NULL, -- Statement metadata without its transaction refs
+    reconciliation JSONB,
+    PRIMARY KEY (account_id, id)
+);
+
+CREATE TABLE statement_transactions (
+    id              BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id      TEXT COLLATE "C" NOT NULL,
+    statement_id    TEXT NOT NULL,
+    transaction_ref TEXT NOT NULL,
+    UNIQUE (account_id, statement_id, transaction_ref),
+    FOREIGN KEY (account_id, statement_id) REFERENCES statements (account_id, id)
+);
+
+CREATE TABLE transactions (
+    id                     BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id             TEXT COLLATE "C" NOT NULL REFERENCES accounts (id),
+    ref                    TEXT COLLATE "C" NOT NULL, -- URL reference
+    booking_date           TEXT COLLATE "C" NOT NULL, -- Date or date time that sorts as a string, empty if missing
+    value_date             TEXT COLLATE "C" NOT NULL,
+    amount                 TEXT COLLATE "C" NOT NULL, -- Amount padded so that it sorts as a string
+    credit_debit_indicator TEXT NOT NULL,
+    status                 TEXT NOT NULL,
+    domain_code            TEXT COLLATE "C",          -- Upper case bank transaction domain code, e.g. PMNT-ICDT-ATXN
+    proprietary_code       TEXT,                      -- Upper case proprietary bank transaction code
+    reference              TEXT NOT NULL,             -- NtryRef, empty if missing
+    account_servicer_ref   TEXT NOT NULL,             -- AcctSvcrRef, empty if missing
+    entry                  JSONB NOT NULL,            -- The entry without its details
+    entry_details          JSONB,                     -- NtryDtls, the batches and transaction details of the entry
+    -- Remittance information of every transaction detail, e.g. to find the entries paying an invoice with
+    -- remittance @> '[{"unstructured": ["INV-1001"]}]'
+    remittance             JSONB GENERATED ALWAYS AS (
+        jsonb_path_query_array(entry_details, '$[*].transactionDetails[*].remittanceInformation ? (@ != null)')
+    ) STORED,
+    UNIQUE (account_id, ref)
+);
+CREATE INDEX transactions_booking_date ON transactions (account_id, booking_date, ref);
+CREATE INDEX transactions_value_date ON transactions (account_id, value_date, ref);
+CREATE INDEX transactions_amount ON transactions (account_id, amount, ref);
+CREATE INDEX transactions_reference ON transactions (account_id, reference);
+CREATE INDEX transactions_account_servicer_ref ON transactions (account_id, account_servicer_ref);
+CREATE INDEX transactions_remittance ON transactions USING GIN (remittance jsonb_path_ops);
+
+-- Entries of camt054 notifications, kept until the entry they refer to has been loaded
+CREATE TABLE notifications (
+    id                   BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id           TEXT NOT NULL,
+    entry_key            TEXT NOT NULL,
+    reference            TEXT NOT NULL,
+    account_servicer_ref TEXT NOT NULL,
+    entry                JSONB NOT NULL,
+    UNIQUE (account_id, entry_key)
+);
+CREATE INDEX notifications_reference ON notifications (account_id, reference);
+CREATE INDEX notifications_account_servicer_ref ON notifications (account_id, account_servicer_ref);
@@ -0,0 +1,9 @@
+-- API keys, see auth.PostgresKeyPool. Only a hash of each token is stored, so the tokens can not be read
+-- from the database.
+
+CREATE TABLE api_keys (
+    token_hash   TEXT PRIMARY KEY, -- Hex encoded SHA-256 hash of the token
+    role         TEXT NOT NULL,
+    account_id   TEXT NOT NULL,    -- Empty for admin keys
+    created_time BIGINT NOT NULL   -- Unix timestamp
+);
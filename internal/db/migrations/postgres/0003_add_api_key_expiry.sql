@@ -0,0 +1,6 @@
+-- API keys expire after API_KEY_TTL, see auth.CreateAPIKey. Keys created before keys could expire get the default
+-- lifetime of keys kept in the database, 30 days.
+
+ALTER TABLE api_keys ADD COLUMN expires_time BIGINT NOT NULL DEFAULT 0; -- Unix timestamp, 0 if the key does not expire
+
+UPDATE api_keys SET expires_time = created_time + 30 * 24 * 60 * 60;
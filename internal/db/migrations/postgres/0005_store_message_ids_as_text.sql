@@ -0,0 +1,5 @@
+-- Message ids are Max35Text, statements stored while they were read as numbers have their ids converted to text.
+
+UPDATE statements
+SET statement = jsonb_set(statement, '{messageId}', to_jsonb(statement ->> 'messageId'))
+WHERE jsonb_typeof(statement -> 'messageId') = 'number';
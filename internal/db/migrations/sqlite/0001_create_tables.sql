@@ -0,0 +1,69 @@
+-- Accounts, statements, balances and transactions are stored as JSON together with the columns they are
+-- looked up, filtered and sorted by. Rows with an INTEGER PRIMARY KEY keep the order they were added in.
+
+CREATE TABLE accounts (
+    id      TEXT PRIMARY KEY,
+    account TEXT NOT NULL -- camt053 account details
+);
+
+CREATE TABLE balances (
+    id          INTEGER PRIMARY KEY,
+    account_id  TEXT NOT NULL REFERENCES accounts (id),
+    balance_key TEXT NOT NULL, -- Type and date of the balance, a later balance with the same key replaces it
+    date        TEXT NOT NULL, -- YYYY-MM-DD
+    balance     TEXT NOT NULL,
+    UNIQUE (account_id, balance_key)
+);
+CREATE INDEX balances_account_date ON balances (account_id, date);
+
+CREATE TABLE statements (
+    account_id     TEXT NOT NULL REFERENCES accounts (id),
+    id             TEXT NOT NULL,
+    statement      TEXT NOT NULL, -- Statement metadata without its transaction refs
+    reconciliation TEXT,
+    PRIMARY KEY (account_id, id)
+);
+
+CREATE TABLE statement_transactions (
+    id              INTEGER PRIMARY KEY,
+    account_id      TEXT NOT NULL,
+    statement_id    TEXT NOT NULL,
+    transaction_ref TEXT NOT NULL,
+    UNIQUE (account_id, statement_id, transaction_ref),
+    FOREIGN KEY (account_id, statement_id) REFERENCES statements (account_id, id)
+);
+
+CREATE TABLE transactions (
+    id                     INTEGER PRIMARY KEY,
+    account_id             TEXT NOT NULL REFERENCES accounts (id),
+    ref                    TEXT NOT NULL, -- URL reference
+    booking_date           TEXT NOT NULL, -- Date or date time that sorts as a string, empty if missing
+    value_date             TEXT NOT NULL,
+    amount                 TEXT NOT NULL, -- Amount padded so that it sorts as a string
+    credit_debit_indicator TEXT NOT NULL,
+    status                 TEXT NOT NULL,
+    domain_code            TEXT,          -- Upper case bank transaction domain code, e.g. PMNT-ICDT-ATXN
+    proprietary_code       TEXT,          -- Upper case proprietary bank transaction code
+    reference              TEXT NOT NULL, -- NtryRef, empty if missing
+    account_servicer_ref   TEXT NOT NULL, -- AcctSvcrRef, empty if missing
+    entry                  TEXT NOT NULL,
+    UNIQUE (account_id, ref)
+);
+CREATE INDEX transactions_booking_date ON transactions (account_id, booking_date, ref);
+CREATE INDEX transactions_value_date ON transactions (account_id, value_date, ref);
+CREATE INDEX transactions_amount ON transactions (account_id, amount, ref);
+CREATE INDEX transactions_reference ON transactions (account_id, reference);
+CREATE INDEX transactions_account_servicer_ref ON transactions (account_id, account_servicer_ref);
+
+-- Entries of camt054 notifications, kept until the entry they refer to has been loaded
+CREATE TABLE notifications (
+    id                   INTEGER PRIMARY KEY,
+    account_id           TEXT NOT NULL,
+    entry_key            TEXT NOT NULL,
+    reference            TEXT NOT NULL,
+    account_servicer_ref TEXT NOT NULL,
+    entry                TEXT NOT NULL,
+    UNIQUE (account_id, entry_key)
+);
+CREATE INDEX notifications_reference ON notifications (account_id, reference);
+CREATE INDEX notifications_account_servicer_ref ON notifications (account_id, account_servicer_ref);
@@ -0,0 +1,8 @@
+-- Entry details are stored in a column of their own, as in the PostgreSQL database, so that both databases
+-- are loaded the same way. Entries are selected with their details merged back in, see sqliteDialect.
+
+ALTER TABLE transactions ADD COLUMN entry_details TEXT;
+
+UPDATE transactions
+SET entry_details = json_extract(entry, '$.entryDetails'),
+    entry = json_remove(entry, '$.entryDetails');
@@ -0,0 +1,5 @@
+-- Booking and value dates are sorted and filtered by keys that start with the date of the entry and end with the
+-- fixed width UTC time of date times, see dateSortKey. The keys of transactions stored with the earlier format are
+-- recomputed from their entries by the migration step of the same name, see migrationSteps.
+
+SELECT 1; -- The file itself changes nothing
@@ -0,0 +1,5 @@
+-- Message ids are Max35Text, statements stored while they were read as numbers have their ids converted to text.
+
+UPDATE statements
+SET statement = json_set(statement, '$.messageId', CAST(json_extract(statement, '$.messageId') AS TEXT))
+WHERE json_type(statement, '$.messageId') = 'integer';
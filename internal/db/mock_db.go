@@ -2,142 +2,367 @@
 package db
 
 import (
-	"encoding/xml"
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/justfredrik/bank-api/internal/bai2"
 	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/justfredrik/bank-api/internal/mt940"
 )
 
-// IDataBase represents the Mock Database
+// IDataReader reads accounts, statements and transactions from a database.
+type IDataReader interface {
+	AccountExists(accountId string) bool
+	GetAccounts(query AccountQuery) (*AccountsResponse, error)
+	GetAccount(accountId string) (*Account, error)
+	GetAccountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error)
+	ExportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error)
+	GetAccountTransaction(accountId string, transactionRef string) (*camt053.Entry, error)
+	GetAccountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error)
+	GetAccountStatements(accountId string) (*StatementsResponse, error)
+	GetAccountStatement(accountId string, statementId string) (*Statement, error)
+	GetStatementReconciliation(accountId string, statementId string) (*Reconciliation, error)
+}
+
+// IDataBase is the storage interface implemented by every database backend, the API and the importer
+// only use the database through it. Implementations have to be safe for concurrent use.
+// View must not be re-entered, fn reads only through the view it is given and never calls the database itself,
+// since loads wait for running views and a call from fn could wait for a load that is waiting for fn.
 type IDataBase interface {
-	AccountsExists(accountId uint64) bool
-	GetAccounts(perPage uint16, page uint64) (AccountsResponse, error)
-	GetAccount(accountId uint64) (*Account, error)
+	IDataReader
 	CreateAccount(camtAcc *camt053.Account) (*Account, error)
-	GetAccountTransactions(accountId uint64) (TransactionsResponse, error)
-	GetAccountTransaction(accountId uint64, transactionRef string) (TransactionsResponse, error)
+	LoadCamt053(data camt053.Document) (LoadSummary, error)
+	LoadCamt053File(path string) (LoadSummary, error) // Loads a camt053, camt052 or camt054 XML file
+	View(fn func(view IDataReader) error) error       // Calls fn with reads that all see the same state of the database
 }
 
-// BankData is used as the root of the database. Implements IDataBase
+// BankData is an in memory database and the root of the mock data. Implements IDataBase
+// It is safe for concurrent use, documents are loaded as a whole while holding a write lock and the accounts,
+// statements and transactions returned by its methods are copies. Use View for several reads of the same state.
 type BankData struct {
-	Accounts           map[uint64]*Account
-	TotalAccounts      uint64
-	LoadedTransactions map[string]bool
+	mu              sync.RWMutex // Guards the accounts and notifications
+	Accounts        map[string]*Account
+	TotalAccounts   uint64
+	pending         map[string]*pendingNotifications // Entries of camt054 notifications waiting for their entry, by account id
+	transactionRefs map[string]*entryIndex           // URL references of the transactions of each account by their references
 }
 
-// Account stores an account along with it's balances and transactions.
+// ErrAccountNotFound is returned when an account does not exist in the database.
+var ErrAccountNotFound = errors.New("account not found")
+
+// Account stores an account along with it's balances, statements and transactions.
 type Account struct {
 	Account      camt053.Account          `json:"account"`
 	Balances     []camt053.Balance        `json:"balances"`
+	Statements   map[string]*Statement    `json:"-"`
 	Transactions map[string]camt053.Entry `json:"-"`
 }
 
+// Statement stores the metadata of a camt053 statement that has been loaded into an account.
+type Statement struct {
+	Id                       string                      `json:"id"`
+	MessageId                string                      `json:"messageId"`
+	Version                  string                      `json:"version"`     // camt053 version of the document, e.g. 001.08
+	MessageType              string                      `json:"messageType"` // camt.053 for statements, camt.052 for intraday reports
+	ElectronicSequenceNumber *int                        `json:"electronicSequenceNumber,omitempty"`
+	LegalSequenceNumber      *int                        `json:"legalSequenceNumber,omitempty"`
+	CreationDateTime         camt053.DateTime            `json:"createdDateTime"`
+	FromDate                 *camt053.FromDate           `json:"fromDate,omitempty"`
+	Balances                 []camt053.Balance           `json:"balances"`
+	TransactionSummary       *camt053.TransactionSummary `json:"transactionSummary,omitempty"`
+	TransactionRefs          []string                    `json:"transactionRefs"`
+	Reconciliation           *Reconciliation             `json:"-"`
+}
+
 // AccountResponse is the format for /accounts request responses.
+// The accounts only contain the account details and balances, without statements and transactions.
 type AccountsResponse struct {
 	Accounts   []*Account `json:"accounts"`
 	TotalCount int        `json:"totalCount"`
-	Page       int        `json:"page"`
+	Page       int        `json:"page,omitempty"` // Not set when paging with a cursor
 	PerPage    int        `json:"perPage"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	PrevCursor string     `json:"prevCursor,omitempty"`
+	Links      PageLinks  `json:"links"`
+}
+
+// PageLinks contains URLs to the pages next to the current one, they are set by the API.
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// StatementsResponse is the format for /statements request responses.
+type StatementsResponse struct {
+	Statements []*Statement `json:"statements"`
+	TotalCount int          `json:"totalCount"`
 }
 
 // TransactionsResponse is the format for /transactions request responses.
 type TransactionsResponse struct {
 	Transactions []*camt053.Entry `json:"transactions"`
 	TotalCount   int              `json:"totalCount"`
-	Page         int              `json:"page"`
+	Page         int              `json:"page,omitempty"` // Not set when paging with a cursor
 	PerPage      int              `json:"perPage"`
+	TotalPages   int              `json:"totalPages"`
+	NextCursor   string           `json:"nextCursor,omitempty"`
+	PrevCursor   string           `json:"prevCursor,omitempty"`
+	Links        PageLinks        `json:"links"`
+}
+
+// NewBankData creates an empty database.
+func NewBankData() BankData {
+	return BankData{
+		Accounts:        make(map[string]*Account),
+		pending:         make(map[string]*pendingNotifications),
+		transactionRefs: make(map[string]*entryIndex),
+	}
 }
 
 // AccountExists checks if an account exists in the database.
-func (db BankData) AccountExists(accountId uint64) bool {
-	_, alreadyExists := DB.Accounts[accountId]
+func (db *BankData) AccountExists(accountId string) bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.accountExists(accountId)
+}
+
+func (db *BankData) accountExists(accountId string) bool {
+	_, alreadyExists := db.Accounts[accountId]
 	return alreadyExists
 }
 
-// CreateAccount creates an account in the database.
-func (db BankData) CreateAccount(camtAcc *camt053.Account) (*Account, error) {
+// CreateAccount creates an account in the database and returns a copy of its details.
+func (db *BankData) CreateAccount(camtAcc *camt053.Account) (*Account, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	account, err := db.createAccount(camtAcc)
+	if err != nil {
+		return nil, err
+	}
+	return account.details(), nil
+}
+
+// createAccount creates an account in the database.
+func (db *BankData) createAccount(camtAcc *camt053.Account) (*Account, error) {
 
 	accountId := (*camtAcc).GetId()
 
-	if DB.AccountExists(accountId) {
+	if accountId == "" {
+		return nil, errors.New("trying to create account without an account id")
+	}
+
+	if db.accountExists(accountId) {
 		return nil, errors.New("trying to create account that already exists")
 	}
 
-	DB.TotalAccounts++
+	db.TotalAccounts++
 
 	acc := Account{
 		Account:      *camtAcc,
+		Balances:     make([]camt053.Balance, 0),
+		Statements:   make(map[string]*Statement),
 		Transactions: make(map[string]camt053.Entry, 0),
 	}
 
-	DB.Accounts[accountId] = &acc
+	db.Accounts[accountId] = &acc
 
 	return &acc, nil
 }
 
-// GetAccounts gets the list of accounts in the database. (pagination is not implemented)
-func (db BankData) GetAccounts(perPage uint16, page uint64) (AccountsResponse, error) {
+// GetAccounts gets a page of the accounts in the database, sorted by account id.
+// The accounts are copies of the account details and balances, without statements and transactions.
+func (db *BankData) GetAccounts(query AccountQuery) (*AccountsResponse, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.accounts(query)
+}
+
+func (db *BankData) accounts(query AccountQuery) (*AccountsResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query = query.withDefaults()
+
+	// Decode the cursor, it needs to be created for an accounts query
+	var cursor *Cursor
+	if query.Cursor != "" {
+		decoded, err := DecodeCursor(query.Cursor)
+		if err != nil || decoded.Query != query.fingerprint() {
+			return nil, ErrInvalidCursor
+		}
+		cursor = &decoded
+	}
 
 	// While this may be slow while itterating over a large map of accounts
 	// This is just a moc and in prod you would use and query a real db not this
-	accounts := AccountsResponse{
-		Accounts: make([]*Account, 0),
+	ids := make([]string, 0, len(db.Accounts))
+	for id := range db.Accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	totalCount := len(ids)
+	start, end := pageWindow(totalCount, query.Page, query.PerPage, cursor, func(i int) int {
+		return strings.Compare(ids[i], cursor.Ref)
+	})
+
+	response := &AccountsResponse{
+		Accounts:   make([]*Account, 0, end-start),
+		TotalCount: totalCount,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+	}
+	for _, id := range ids[start:end] {
+		response.Accounts = append(response.Accounts, db.Accounts[id].details())
+	}
+	if cursor != nil {
+		response.Page = 0
 	}
 
-	// Populate slice with map values
-	for _, acc := range db.Accounts {
-		accounts.Accounts = append(accounts.Accounts, acc)
+	// Create cursors pointing at the first and last account of the page
+	if start > 0 && start < totalCount {
+		response.PrevCursor = Cursor{Ref: ids[start], Before: true, Query: query.fingerprint()}.Encode()
+	}
+	if end < totalCount && end > 0 {
+		response.NextCursor = Cursor{Ref: ids[end-1], Query: query.fingerprint()}.Encode()
 	}
 
-	// Populate accounts with correct data
-	accounts.TotalCount = len(accounts.Accounts)
-	accounts.PerPage = accounts.TotalCount
+	return response, nil
+}
+
+// ExportAccountTransactions gets every transaction of an account that matches the query, sorted by the query.
+// Exports are not paginated, the page and cursor of the query are not allowed.
+func (db *BankData) ExportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 
-	return accounts, nil
+	return db.exportAccountTransactions(accountId, query)
 }
 
-// GetAccount gets a specific account from the database.
-func (db BankData) GetAccount(accountId uint64) (*Account, error) {
+func (db *BankData) exportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.Page != 0 || query.Cursor != "" {
+		return nil, errors.New("exports contain every matching transaction and can not be paginated")
+	}
+	query = query.withDefaults()
+
+	account, err := db.account(accountId)
+	if err != nil {
+		return nil, err
+	}
+	return query.filterTransactions(account), nil
+}
+
+// GetAccount gets a copy of the details and balances of a specific account from the database.
+// Its statements and transactions are not copied, they are read with GetAccountStatements and GetAccountTransactions.
+func (db *BankData) GetAccount(accountId string) (*Account, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	account, err := db.account(accountId)
+	if err != nil {
+		return nil, err
+	}
+	return account.details(), nil
+}
+
+func (db *BankData) account(accountId string) (*Account, error) {
 	if account, ok := db.Accounts[accountId]; ok {
 		return account, nil
 	}
-	return nil, errors.New("account not found")
+	return nil, ErrAccountNotFound
+}
+
+// GetAccountTransactions gets a page of an accounts transactions from the database, filtered and sorted by the query.
+func (db *BankData) GetAccountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.accountTransactions(accountId, query)
 }
 
-// GetAccountTransactions gets a list of an accounts transactions from the database.
-func (db BankData) GetAccountTransactions(accountId uint64) (*TransactionsResponse, error) {
-	transactions := []*camt053.Entry{}
+func (db *BankData) accountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query = query.withDefaults()
 
 	// Fetch Account
-	account, err := db.GetAccount(accountId)
+	account, err := db.account(accountId)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 
-	// Convert Map data to slice since we don't use a real DB
-	for _, transaction := range account.Transactions {
-		transactions = append(transactions, &transaction)
+	// Decode the cursor, it needs to be created for the same account and query
+	var cursor *Cursor
+	if query.Cursor != "" {
+		decoded, err := DecodeCursor(query.Cursor)
+		if err != nil || decoded.Query != query.fingerprint(accountId) {
+			return nil, ErrInvalidCursor
+		}
+		cursor = &decoded
 	}
 
+	transactions := query.filterTransactions(account)
 	totalCount := len(transactions)
 
-	return &TransactionsResponse{
-		Transactions: transactions,
+	// Slice out the requested page
+	var cursorKey string
+	if cursor != nil {
+		cursorKey = query.cursorKey(*cursor)
+	}
+	start, end := pageWindow(totalCount, query.Page, query.PerPage, cursor, func(i int) int {
+		return query.compare(query.sortKey(transactions[i]), *transactions[i].URLReference, cursorKey, cursor.Ref)
+	})
+
+	response := &TransactionsResponse{
+		Transactions: transactions[start:end],
 		TotalCount:   totalCount,
-		Page:         1,
-		PerPage:      totalCount,
-	}, nil
+		Page:         query.Page,
+		PerPage:      query.PerPage,
+		TotalPages:   (totalCount + query.PerPage - 1) / query.PerPage,
+	}
+	if cursor != nil {
+		response.Page = 0
+	}
+
+	// Create cursors pointing at the first and last transaction of the page
+	fingerprint := query.fingerprint(accountId)
+	if start > 0 && start < totalCount {
+		first := transactions[start]
+		response.PrevCursor = Cursor{Key: query.sortValue(first), Ref: *first.URLReference, Before: true, Query: fingerprint}.Encode()
+	}
+	if end < totalCount && end > 0 {
+		last := transactions[end-1]
+		response.NextCursor = Cursor{Key: query.sortValue(last), Ref: *last.URLReference, Query: fingerprint}.Encode()
+	}
+
+	return response, nil
 }
 
 // GetAccountTransaction gets a specific transaction for an ccount from the database.
-func (db BankData) GetAccountTransaction(accountId uint64, transactionRef string) (*camt053.Entry, error) {
+func (db *BankData) GetAccountTransaction(accountId string, transactionRef string) (*camt053.Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.accountTransaction(accountId, transactionRef)
+}
+
+func (db *BankData) accountTransaction(accountId string, transactionRef string) (*camt053.Entry, error) {
 
 	// Fetch Account
-	account, err := db.GetAccount(accountId)
+	account, err := db.account(accountId)
 	if err != nil {
 		return nil, errors.New("unable to fetch account data")
 	}
@@ -150,99 +375,441 @@ func (db BankData) GetAccountTransaction(accountId uint64, transactionRef string
 	return &transaction, nil
 }
 
-// Instance of the BankData Database used as the database in the project.
-var DB BankData = BankData{
-	Accounts:           make(map[uint64]*Account),
-	LoadedTransactions: make(map[string]bool),
+// GetAccountStatements gets copies of the statements loaded into an account ordered by statement period and sequence number.
+func (db *BankData) GetAccountStatements(accountId string) (*StatementsResponse, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	response, err := db.accountStatements(accountId)
+	if err != nil {
+		return nil, err
+	}
+	for i, statement := range response.Statements {
+		response.Statements[i] = statement.snapshot()
+	}
+	return response, nil
 }
 
-// ParseLocalCamt053 opens and unmarshals a camt053 document.
-func ParseLocalCamt053(path string) (camt053.Document, error) {
+func (db *BankData) accountStatements(accountId string) (*StatementsResponse, error) {
 
-	var data camt053.Document
+	// Fetch Account
+	account, err := db.account(accountId)
+	if err != nil {
+		return nil, err
+	}
 
-	xmlFile, err := os.Open(path)
+	statements := make([]*Statement, 0, len(account.Statements))
+	for _, statement := range account.Statements {
+		statements = append(statements, statement)
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].less(statements[j])
+	})
+
+	return &StatementsResponse{
+		Statements: statements,
+		TotalCount: len(statements),
+	}, nil
+}
+
+// GetAccountStatement gets a copy of a specific statement for an account from the database.
+func (db *BankData) GetAccountStatement(accountId string, statementId string) (*Statement, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	statement, err := db.accountStatement(accountId, statementId)
 	if err != nil {
-		return data, err
+		return nil, err
 	}
-	defer xmlFile.Close()
+	return statement.snapshot(), nil
+}
 
-	byteData, _ := io.ReadAll(xmlFile)
+func (db *BankData) accountStatement(accountId string, statementId string) (*Statement, error) {
 
-	if err := xml.Unmarshal(byteData, &data); err != nil {
+	// Fetch Account
+	account, err := db.account(accountId)
+	if err != nil {
+		return nil, errors.New("unable to fetch account data")
+	}
 
-		return data, err
+	statement, ok := account.Statements[statementId]
+	if !ok {
+		return nil, errors.New("statement not found")
 	}
 
-	return data, err
+	return statement, nil
 }
 
-// Loads unmarshaled camt053 into the database.
-func LoadCamt053(data camt053.Document) error {
+// GetStatementReconciliation gets the reconciliation of a specific account statement from the database.
+// Reconciliations are not changed once they have been attached to a statement.
+func (db *BankData) GetStatementReconciliation(accountId string, statementId string) (*Reconciliation, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 
-	// Load Account data and Create Account
-	var camtAcc camt053.Account = data.BankStatement.Statement.Account
-	account, err := DB.CreateAccount(&camtAcc)
+	return db.statementReconciliation(accountId, statementId)
+}
+
+func (db *BankData) statementReconciliation(accountId string, statementId string) (*Reconciliation, error) {
+	statement, err := db.accountStatement(accountId, statementId)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if statement.Reconciliation == nil {
+		return nil, errors.New("statement has not been reconciled")
+	}
+	return statement.Reconciliation, nil
+}
+
+// less orders statements by the start of their period, sequence number and lastly id.
+func (s *Statement) less(other *Statement) bool {
+	if cmp := s.fromDateTime().Compare(other.fromDateTime()); cmp != 0 {
+		return cmp < 0
+	}
+	if seq, otherSeq := sequenceNumber(s.ElectronicSequenceNumber), sequenceNumber(other.ElectronicSequenceNumber); seq != otherSeq {
+		return seq < otherSeq
+	}
+	return s.Id < other.Id
+}
+
+func (s *Statement) fromDateTime() camt053.DateTime {
+	if s.FromDate == nil {
+		return s.CreationDateTime
+	}
+	return s.FromDate.FromDateTime
+}
+
+func sequenceNumber(seq *int) int {
+	if seq == nil {
+		return 0
+	}
+	return *seq
+}
+
+// Instance of the BankData Database used as the database in the project.
+var DB BankData = NewBankData()
+
+var _ IDataBase = &DB
+
+// ParseLocalCamt053 opens and unmarshals a camt053 document, a MT940 or MT942 statement file or a BAI2 file.
+func ParseLocalCamt053(path string) (camt053.Document, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return camt053.Document{}, err
+	}
+	defer file.Close()
+
+	return ParseDocument(file)
+}
+
+// Number of bytes read to sniff the format of a document.
+const SNIFF_SIZE = 512
+
+// ParseDocument parses a camt053, camt052 or camt054 XML document, SWIFT MT940 statements and MT942 reports
+// or a BAI2 file, which are converted into the camt053 model. The format is sniffed from the start of the document.
+func ParseDocument(r io.Reader) (camt053.Document, error) {
+	reader := bufio.NewReaderSize(r, SNIFF_SIZE)
+	start, _ := reader.Peek(SNIFF_SIZE) // Shorter documents return what they have together with an error
+
+	if mt940.IsMT940(start) {
+		return mt940.Parse(reader)
+	}
+	if bai2.IsBAI2(start) {
+		return bai2.Parse(reader)
+	}
+	return camt053.Parse(reader)
+}
+
+// LoadSummary describes what was added to the database when a document was loaded.
+type LoadSummary struct {
+	AccountsCreated      []string `json:"accountsCreated"`
+	StatementsLoaded     int      `json:"statementsLoaded"`
+	EntriesAdded         int      `json:"entriesAdded"`
+	DuplicatesSkipped    int      `json:"duplicatesSkipped"`
+	EntriesSettled       int      `json:"entriesSettled"`  // Intraday entries replaced by the entry of a camt053 statement
+	EntriesUpdated       int      `json:"entriesUpdated"`  // Intraday entries replaced by the entry of a later camt052 report
+	EntriesEnriched      int      `json:"entriesEnriched"` // Entries that the details of camt054 notifications were merged into
+	NotificationsLoaded  int      `json:"notificationsLoaded"`
+	NotificationsPending int      `json:"notificationsPending"` // Notification entries waiting for their entry to be loaded
+	Warnings             []string `json:"warnings,omitempty"`   // Statements that do not reconcile and parts of the document that were not loaded
+}
+
+// Counts returns the number of statements, reports and notifications, and the number of entries that were read.
+func (s LoadSummary) Counts() (int, int) {
+	entries := s.EntriesAdded + s.EntriesSettled + s.EntriesUpdated + s.DuplicatesSkipped
+	if s.NotificationsLoaded > 0 {
+		entries = s.EntriesEnriched + s.NotificationsPending // Documents contain either notifications or statements
+	}
+	return s.StatementsLoaded + s.NotificationsLoaded, entries
+}
+
+// LoadCamt053 loads unmarshaled camt053 into the database.
+func LoadCamt053(data camt053.Document) (LoadSummary, error) {
+	return DB.LoadCamt053(data)
+}
+
+// LoadCamt053 loads every statement of an unmarshaled camt053 document into the database.
+// Statements for accounts that already exist are appended to the account and their
+// balances and entries are merged with the ones already loaded. The details of camt054
+// notifications are merged into the entries they refer to.
+func (db *BankData) LoadCamt053(data camt053.Document) (LoadSummary, error) {
+	summary := LoadSummary{AccountsCreated: make([]string, 0)}
+
+	// Documents with structural violations are rejected as a whole
+	if err := camt053.ValidateDocument(data); err != nil {
+		return summary, err
 	}
 
-	// Load Transactions (Entries) into Account data struct
-	for _, entry := range *(data.BankStatement.Statement.Entries) {
+	// Readers see either none or all of the document
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err := loadDocument(db, data, &summary)
+	return summary, err
+}
+
+// loadDocument loads a validated document into a database, see LoadCamt053.
+func loadDocument(l loader, data camt053.Document, summary *LoadSummary) error {
+	summary.Warnings = append(summary.Warnings, data.Warnings...)
 
-		// Convert Ref to URL friendly string
-		{
-			URLSafeRef := convertEntryRef(*entry.Reference)
-			entry.URLReference = &URLSafeRef
+	// Notifications only add details to entries
+	if data.IsNotification() {
+		return loadNotifications(l, data, summary)
+	}
+
+	mode, err := ReconciliationMode()
+	if err != nil {
+		return err
+	}
+
+	// Make sure every statement can be loaded before loading any of them
+	statements := data.Statements()
+	reconciliations := make([]Reconciliation, len(statements))
+	for i, stmt := range statements {
+		reconciliations[i] = Reconcile(stmt)
+		if reconciliations[i].Status == RECONCILIATION_FAILED && mode == RECONCILIATION_MODE_STRICT {
+			return fmt.Errorf("statement %d: %s", i+1, reconciliations[i])
 		}
+	}
 
-		// Add transaction if no duplicate exists
-		if _, ok := DB.LoadedTransactions[*entry.URLReference]; !ok {
-			account.Transactions[*entry.URLReference] = entry
+	for i := range statements {
+		if err := loadStatement(l, data, &statements[i], summary); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		if err := summary.addReconciliation(l, &statements[i], &reconciliations[i]); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
 		}
 	}
 
 	return nil
 }
 
-// InitializeLocalMockData loads the /data/camt053.xml file for testing.
-func InitializeLocalMockData() (err error) {
-	if localMockIsInitialized == true {
+// addReconciliation attaches the reconciliation of a statement that has been loaded,
+// statements that do not reconcile are loaded with a warning.
+func (s *LoadSummary) addReconciliation(l loader, stmt *camt053.Statement, reconciliation *Reconciliation) error {
+	if err := l.saveReconciliation(stmt.Account.GetId(), stmt.Id, reconciliation); err != nil {
+		return err
+	}
+	if reconciliation.Status == RECONCILIATION_FAILED {
+		s.Warnings = append(s.Warnings, reconciliation.String())
+	}
+	return nil
+}
+
+// loadStatement loads a single statement into its account, creating the account if needed.
+// Entries of camt052 reports are marked as intraday, they are replaced when a statement or a later report contains the same entry.
+func loadStatement(l loader, doc camt053.Document, stmt *camt053.Statement, summary *LoadSummary) error {
+	if err := beginStatement(l, doc, stmt, summary); err != nil {
+		return err
+	}
+	if stmt.Entries == nil {
 		return nil
 	}
-	data, err := ParseLocalCamt053(os.Getenv("PROJECT_DIR") + "/data/camt053.xml")
+	for i, entry := range *stmt.Entries {
+		if err := loadEntry(l, doc, stmt, entry, i, summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// beginStatement loads the account, the metadata and the balances of a statement, before its entries are loaded.
+func beginStatement(l loader, doc camt053.Document, stmt *camt053.Statement, summary *LoadSummary) error {
+	accountId := stmt.Account.GetId()
+
+	// Load Account data and Create Account if it does not exist
+	account, err := l.accountDetails(accountId)
+	switch {
+	case err != nil:
+		return err
+	case account == nil:
+		if err := l.insertAccount(stmt.Account); err != nil {
+			return err
+		}
+		summary.AccountsCreated = append(summary.AccountsCreated, accountId)
+	default:
+		mergeAccountDetails(account, stmt.Account)
+		if err := l.updateAccount(*account); err != nil {
+			return err
+		}
+	}
+
+	// Keep per statement metadata, statements that are loaded again are merged
+	err = l.saveStatement(accountId, Statement{
+		Id:                       stmt.Id,
+		MessageId:                doc.Header().MessageId,
+		Version:                  doc.Version,
+		MessageType:              doc.MessageType,
+		ElectronicSequenceNumber: stmt.ElectronicSequenceNumber,
+		LegalSequenceNumber:      stmt.LegalSequenceNumber,
+		CreationDateTime:         stmt.CreationDateTime,
+		FromDate:                 stmt.FromDate,
+		Balances:                 stmt.Balances,
+		TransactionSummary:       stmt.TransactionSummary,
+		TransactionRefs:          make([]string, 0),
+	})
 	if err != nil {
 		return err
 	}
 
-	//fmt.Println("Parsed local data...")
+	summary.StatementsLoaded++
+
+	// Merge balances into the account
+	for _, balance := range stmt.Balances {
+		if err := l.saveBalance(accountId, balance); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadEntry loads the entry at index of a statement into its account.
+func loadEntry(l loader, doc camt053.Document, stmt *camt053.Statement, entry camt053.Entry, index int, summary *LoadSummary) error {
+	accountId := stmt.Account.GetId()
 
-	if err = LoadCamt053(data); err != nil {
+	// Intraday entries are settled by the entry with the same reference, which entries without one can not be matched by
+	if doc.IsIntraday() && !hasEntryRef(entry) {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("entry %d of report %s has no entry or account servicer reference "+
+			"and can not be settled by a later statement, it is not loaded", index+1, stmt.Id))
+		return nil
+	}
+
+	// Convert Ref to URL friendly string
+	{
+		URLSafeRef := convertEntryRef(entryRef(entry, stmt.Id, index))
+		entry.URLReference = &URLSafeRef
+	}
+
+	entry.Intraday = doc.IsIntraday()
+
+	// Add transaction if no duplicate exists, intraday entries are replaced by later reports and statements
+	existing, err := l.transaction(accountId, *entry.URLReference)
+	switch {
+	case err != nil:
 		return err
+	case existing != nil && !existing.Intraday:
+		summary.DuplicatesSkipped++
+		return nil
+	case existing != nil && entry.Intraday:
+		summary.EntriesUpdated++
+	case existing != nil:
+		summary.EntriesSettled++
+	default:
+		summary.EntriesAdded++
 	}
 
-	fmt.Println("Loaded local data!")
-	localMockIsInitialized = true
+	applied, err := applyNotifications(l, accountId, &entry)
+	if err != nil {
+		return err
+	}
+	if applied {
+		summary.EntriesEnriched++
+	}
+	if err := l.saveTransaction(accountId, entry); err != nil {
+		return err
+	}
+	return l.addStatementTransaction(accountId, stmt.Id, *entry.URLReference)
+}
 
-	return nil
+// mergeAccountDetails fills in account details that were missing from earlier statements.
+func mergeAccountDetails(account *camt053.Account, camtAcc camt053.Account) {
+	if account.Currency == nil {
+		account.Currency = camtAcc.Currency
+	}
+	if account.Owner == nil {
+		account.Owner = camtAcc.Owner
+	}
+	if account.Servicer == nil {
+		account.Servicer = camtAcc.Servicer
+	}
+	if account.Type == nil {
+		account.Type = camtAcc.Type
+	}
+	if account.Name == nil {
+		account.Name = camtAcc.Name
+	}
+}
+
+// mergeBalance adds a balance to the account, replacing any balance of the same type and date.
+func (acc *Account) mergeBalance(balance camt053.Balance) {
+	key := balanceKey(balance)
+	for i, existing := range acc.Balances {
+		if balanceKey(existing) == key {
+			acc.Balances[i] = balance
+			return
+		}
+	}
+	acc.Balances = append(acc.Balances, balance)
+}
+
+// balanceKey identifies a balance by its type, sub type and date.
+func balanceKey(balance camt053.Balance) string {
+	key := ""
+	if balance.Type.CodeOrProprietary.Code != nil {
+		key += *balance.Type.CodeOrProprietary.Code
+	}
+	if balance.Type.CodeOrProprietary.Proprietary != nil {
+		key += "/" + *balance.Type.CodeOrProprietary.Proprietary
+	}
+	if balance.Type.SubType != nil {
+		key += "/" + *balance.Type.SubType
+	}
+	return key + "@" + balance.GetDate().String()
+}
+
+// entryRef returns the reference used to identify an entry, falling back on the account servicer
+// reference and lastly the entries position in the statement.
+// Entries of intraday reports always have a reference, see loadEntry.
+func entryRef(entry camt053.Entry, statementId string, index int) string {
+	if entry.Reference != nil && *entry.Reference != "" {
+		return *entry.Reference
+	}
+	if entry.AccountServicerRef != nil && *entry.AccountServicerRef != "" {
+		return *entry.AccountServicerRef
+	}
+	return fmt.Sprintf("%s-%d", statementId, index+1)
+}
+
+// hasEntryRef checks if an entry has an entry or account servicer reference to be identified by.
+func hasEntryRef(entry camt053.Entry) bool {
+	return (entry.Reference != nil && *entry.Reference != "") || (entry.AccountServicerRef != nil && *entry.AccountServicerRef != "")
 }
 
 // Converts references to URL friendly references.
+// Earlier versions only removed spaces, so references containing any of the other unwanted characters,
+// e.g. RP/GS/CTFILERP0002 which is now RPGSCTFILERP0002, have changed and links to them no longer resolve.
 func convertEntryRef(rawRef string) string {
 	// These strings are not good to have in a resource name/Id/Ref in an URL
 	unwantedCharacters := []string{";", "/", "?", ":", "@", "=", "&", "\"",
 		"<", ">", "#", "%", "{", "}", "|", "\\", "^", "~", "[", "]", "`", " "}
 
-	// Tread spaces as -
-	resRef := strings.ReplaceAll(rawRef, " ", "-")
+	resRef := rawRef
 
 	// Remove all unwanted Characters
 	for _, char := range unwantedCharacters {
-		resRef = strings.ReplaceAll(rawRef, char, "")
+		resRef = strings.ReplaceAll(resRef, char, "")
 	}
 
 	return resRef
 }
-
-// Stores if mock data has already been initialized or not
-var localMockIsInitialized bool = false
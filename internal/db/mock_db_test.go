@@ -0,0 +1,312 @@
+// package db is a local mock database.
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccountId = "54400001111"
+
+// loadTestDocument parses the local camt053 mock data used in the tests.
+func loadTestDocument(t *testing.T) camt053.Document {
+	doc, err := ParseLocalCamt053("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to parse test data: %s", err)
+	}
+	return doc
+}
+
+// nextDayStatement copies a statement and moves it to a new id, day and set of entry references.
+// Entry references are prefixed with the compact date, e.g. S20181218-, to keep them within 35 characters.
+func nextDayStatement(stmt camt053.Statement, id string, date string) camt053.Statement {
+	next := stmt
+	next.Id = id
+	from, _ := camt053.ParseDateTime(date + "T00:00:00+01:00")
+	to, _ := camt053.ParseDateTime(date + "T23:59:59+01:00")
+	next.FromDate = &camt053.FromDate{FromDateTime: from, ToDateTime: to}
+
+	day, _ := camt053.ParseDate(date)
+	next.Balances = make([]camt053.Balance, len(stmt.Balances))
+	for i, balance := range stmt.Balances {
+		balance.Date = camt053.DateAndDateTime{Date: &day}
+		next.Balances[i] = balance
+	}
+
+	entries := make([]camt053.Entry, len(*stmt.Entries))
+	for i, entry := range *stmt.Entries {
+		ref := "S" + strings.ReplaceAll(date, "-", "") + "-" + *entry.Reference
+		entry.Reference = &ref
+		entries[i] = entry
+	}
+	next.Entries = &entries
+
+	return next
+}
+
+// TestLoadCamt053MultipleDocuments checks that statements from several documents are appended to the same account.
+func TestLoadCamt053MultipleDocuments(t *testing.T) {
+	db := NewBankData()
+
+	first := loadTestDocument(t)
+	second := loadTestDocument(t)
+	second.BankStatement.GroupHeader.MessageId = "MSG-2018-12-18-001"
+	second.BankStatement.Statements[0] = nextDayStatement(second.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18")
+
+	summary, err := db.LoadCamt053(first)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAccountId}, summary.AccountsCreated)
+
+	summary, err = db.LoadCamt053(second)
+	assert.NoError(t, err)
+	assert.Empty(t, summary.AccountsCreated)
+	assert.Equal(t, 1, summary.StatementsLoaded)
+	assert.Equal(t, 7, summary.EntriesAdded)
+
+	account, err := db.account(testAccountId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), db.TotalAccounts)
+	assert.Len(t, account.Statements, 2)
+	assert.Len(t, account.Transactions, 14)
+
+	// Balances are kept per date, the FWAV balance of the first statement overlaps with the second statements date.
+	assert.Len(t, account.Balances, 7)
+
+	statements, err := db.GetAccountStatements(testAccountId)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, statements.TotalCount)
+	assert.Equal(t, "STOIID65181218000000000007", statements.Statements[0].Id)
+	assert.Equal(t, "STMT-2018-12-18", statements.Statements[1].Id)
+	assert.Equal(t, "3131111", statements.Statements[0].MessageId)
+	assert.Equal(t, "MSG-2018-12-18-001", statements.Statements[1].MessageId)
+	assert.Len(t, statements.Statements[1].TransactionRefs, 7)
+}
+
+// TestLoadCamt053MultipleStatements checks that every 'Stmt' in a 'BkToCstmrStmt' is loaded.
+func TestLoadCamt053MultipleStatements(t *testing.T) {
+	db := NewBankData()
+
+	doc := loadTestDocument(t)
+	stmt := doc.BankStatement.Statements[0]
+	doc.BankStatement.Statements = append(doc.BankStatement.Statements,
+		nextDayStatement(stmt, "STMT-2018-12-18", "2018-12-18"),
+		nextDayStatement(stmt, "STMT-2018-12-19", "2018-12-19"),
+	)
+
+	summary, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, summary.StatementsLoaded)
+	assert.Equal(t, 21, summary.EntriesAdded)
+
+	statements, err := db.GetAccountStatements(testAccountId)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, statements.TotalCount)
+
+	statement, err := db.GetAccountStatement(testAccountId, "STMT-2018-12-19")
+	assert.NoError(t, err)
+	assert.Equal(t, "2018-12-19T00:00:00+01:00", statement.FromDate.FromDateTime.String())
+	assert.Equal(t, "001.02", statement.Version)
+	assert.Len(t, statement.Balances, 4)
+
+	_, err = db.GetAccountStatement(testAccountId, "STMT-2018-12-20")
+	assert.Error(t, err)
+}
+
+// TestLoadCamt053Reload checks that loading the same document twice does not duplicate any data.
+func TestLoadCamt053Reload(t *testing.T) {
+	db := NewBankData()
+
+	_, err := db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+
+	summary, err := db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.EntriesAdded)
+	assert.Equal(t, 7, summary.DuplicatesSkipped)
+
+	account, err := db.account(testAccountId)
+	assert.NoError(t, err)
+	assert.Len(t, account.Statements, 1)
+	assert.Len(t, account.Transactions, 7)
+	assert.Len(t, account.Balances, 4)
+	assert.Len(t, account.Statements["STOIID65181218000000000007"].TransactionRefs, 7)
+}
+
+// TestLoadCamt053WithoutStatements checks that documents without statements are rejected.
+func TestLoadCamt053WithoutStatements(t *testing.T) {
+	db := NewBankData()
+	_, err := db.LoadCamt053(camt053.Document{})
+	assert.Error(t, err)
+}
+
+// TestLoadCamt053Invalid checks that documents with structural violations are rejected without loading any statement.
+func TestLoadCamt053Invalid(t *testing.T) {
+	db := NewBankData()
+
+	doc := loadTestDocument(t)
+	second := nextDayStatement(doc.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18")
+	second.Balances = nil
+	doc.BankStatement.Statements = append(doc.BankStatement.Statements, second)
+
+	_, err := db.LoadCamt053(doc)
+	var validationErr *camt053.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "/Document/BkToCstmrStmt/Stmt[2]/Bal", validationErr.Violations[0].Path)
+
+	_, err = db.GetAccount(testAccountId)
+	assert.Error(t, err)
+}
+
+// intradayReport turns the statements of a document into a camt052 intraday report.
+func intradayReport(doc camt053.Document, reportId string) camt053.Document {
+	reports := doc.Statements()
+	for i := range reports {
+		reports[i].Id = reportId
+	}
+	doc.AccountReport = &camt053.BankToCustomerAccountReport{GroupHeader: doc.Header(), Reports: reports}
+	doc.BankStatement = camt053.BankToCustomerStatement{}
+	doc.MessageType = camt053.MESSAGE_TYPE_REPORT
+	return doc
+}
+
+// TestLoadCamt052 checks that intraday entries are replaced by later reports and settled by statements.
+func TestLoadCamt052(t *testing.T) {
+	db := NewBankData()
+
+	summary, err := db.LoadCamt053(intradayReport(loadTestDocument(t), "RPT-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.EntriesAdded)
+
+	account, err := db.account(testAccountId)
+	assert.NoError(t, err)
+	for _, entry := range account.Transactions {
+		assert.True(t, entry.Intraday)
+	}
+	report, err := db.GetAccountStatement(testAccountId, "RPT-1")
+	assert.NoError(t, err)
+	assert.Equal(t, camt053.MESSAGE_TYPE_REPORT, report.MessageType)
+
+	// A later report replaces the intraday entries
+	later := intradayReport(loadTestDocument(t), "RPT-2")
+	(*later.AccountReport.Reports[0].Entries)[0].Status = "PDNG"
+	summary, err = db.LoadCamt053(later)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.EntriesAdded)
+	assert.Equal(t, 7, summary.EntriesUpdated)
+	ref := convertEntryRef(*(*later.AccountReport.Reports[0].Entries)[0].Reference)
+	account, _ = db.account(testAccountId)
+	assert.Equal(t, camt053.EntryStatus("PDNG"), account.Transactions[ref].Status)
+
+	// The statement settles them
+	summary, err = db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.EntriesSettled)
+	account, _ = db.account(testAccountId)
+	assert.Len(t, account.Transactions, 7)
+	for _, entry := range account.Transactions {
+		assert.False(t, entry.Intraday)
+	}
+	assert.Equal(t, camt053.EntryStatus("BOOK"), account.Transactions[ref].Status)
+
+	statement, err := db.GetAccountStatement(testAccountId, "STOIID65181218000000000007")
+	assert.NoError(t, err)
+	assert.Equal(t, camt053.MESSAGE_TYPE_STATEMENT, statement.MessageType)
+	assert.Len(t, statement.TransactionRefs, 7)
+}
+
+// TestLoadCamt052WithoutReference checks that intraday entries without a reference are not loaded,
+// since no statement could settle them.
+func TestLoadCamt052WithoutReference(t *testing.T) {
+	db := NewBankData()
+
+	report := intradayReport(loadTestDocument(t), "RPT-1")
+	entry := &(*report.AccountReport.Reports[0].Entries)[0]
+	entry.Reference, entry.AccountServicerRef = nil, nil
+	summary, err := db.LoadCamt053(report)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, summary.EntriesAdded)
+	if assert.Len(t, summary.Warnings, 1) {
+		assert.Contains(t, summary.Warnings[0], "entry 1 of report RPT-1 has no entry or account servicer reference")
+	}
+
+	// The statement settles the loaded entries and adds the one that was left out
+	summary, err = db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, summary.EntriesSettled)
+	assert.Equal(t, 1, summary.EntriesAdded)
+	account, _ := db.account(testAccountId)
+	assert.Len(t, account.Transactions, 7)
+}
+
+// TestParseDocument checks that the format of a document is sniffed and that every format is converted into the camt053 model.
+func TestParseDocument(t *testing.T) {
+	xml, err := os.ReadFile("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+
+	// Declare Tests
+	tests := []struct {
+		name              string
+		input             string
+		expectedAccountId string
+	}{
+		{"camt053", string(xml), testAccountId},
+		{"MT940", ":20:STMT1\n:25:54400002222\n:28C:1/1\n:60F:C181216SEK1,00\n:62F:C181217SEK1,00\n-", "54400002222"},
+		{"BAI2", "01,S,R,181217,0600,F1,80,,2/\n02,R,B,1,181216,,USD,2/\n03,54400003333,,010,100,,/\n49,100,2/\n98,100,1,4/\n99,100,1,6/", "54400003333"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := ParseDocument(strings.NewReader(test.input))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, camt053.ValidateDocument(doc))
+			assert.Equal(t, test.expectedAccountId, doc.Statements()[0].Account.GetId())
+		})
+	}
+}
+
+// TestLoadWarnings checks that the parts of a converted document that were not loaded are reported in the load summary.
+func TestLoadWarnings(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader("01,S,R,181217,0600,F1,80,,2/\n02,R,B,1,181216,,USD,2/\n" +
+		"03,54400003333,,010,100,,,102,0,,/\n49,100,2/\n98,100,1,4/\n99,100,1,6/"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	db := NewBankData()
+	summary, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.StatementsLoaded)
+	if assert.Len(t, summary.Warnings, 1) {
+		assert.Contains(t, summary.Warnings[0], "summary type codes 102")
+	}
+}
+
+// TestConvertEntryRef checks that references are converted into URL friendly strings.
+func TestConvertEntryRef(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"LBE5419-0186-0029234", "LBE5419-0186-0029234"},
+		{"RP/GS/CTFILERP0002", "RPGSCTFILERP0002"},
+		{"JAMBO 81518-0029248", "JAMBO81518-0029248"},
+		{"REF 123?A=1&B=2", "REF123A1B2"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.expected, convertEntryRef(test.input))
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// loadNotifications stores the entries of every notification of a camt054 document and merges their details
+// into the matching entries that have already been loaded. Notification entries are kept until they have been
+// merged into an entry of a statement, see applyNotifications.
+func loadNotifications(l loader, data camt053.Document, summary *LoadSummary) error {
+
+	// Make sure every entry can be matched before loading any of them
+	for i, notification := range data.Statements() {
+		if notification.Entries == nil {
+			continue
+		}
+		for j, entry := range *notification.Entries {
+			if entryKey(entry) == "" {
+				return fmt.Errorf("notification %d: entry %d has neither an AcctSvcrRef nor a NtryRef", i+1, j+1)
+			}
+		}
+	}
+
+	for _, notification := range data.Statements() {
+		summary.NotificationsLoaded++
+		if notification.Entries == nil {
+			continue
+		}
+		for _, entry := range *notification.Entries {
+			if err := loadNotificationEntry(l, notification.Account.GetId(), entry, summary); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadNotificationEntry merges the details of a notification entry into the matching entry, if it has been loaded.
+// The notification entry is stored unless it has been merged into an entry of a statement, intraday entries are
+// replaced by the entry of a statement later on, which the details are merged into when it is loaded.
+func loadNotificationEntry(l loader, accountId string, entry camt053.Entry, summary *LoadSummary) error {
+	transaction, err := l.findTransaction(accountId, entry)
+	if err != nil {
+		return err
+	}
+	if transaction == nil || transaction.Intraday {
+		if err := l.saveNotification(accountId, entry); err != nil {
+			return err
+		}
+	}
+	if transaction == nil {
+		summary.NotificationsPending++
+		return nil
+	}
+	mergeNotification(transaction, entry)
+	summary.EntriesEnriched++
+	return l.saveTransaction(accountId, *transaction)
+}
+
+// applyNotifications merges the details of the stored notifications that match an entry into it.
+// Notifications merged into an entry of a statement are deleted, since the entry is not replaced anymore.
+func applyNotifications(l loader, accountId string, entry *camt053.Entry) (bool, error) {
+	notifications, err := l.notifications(accountId, *entry)
+	if err != nil {
+		return false, err
+	}
+	applied := false
+	for _, notification := range notifications {
+		if !matchesNotification(*entry, notification) {
+			continue
+		}
+		mergeNotification(entry, notification)
+		applied = true
+		if entry.Intraday {
+			continue
+		}
+		if err := l.deleteNotification(accountId, notification); err != nil {
+			return false, err
+		}
+	}
+	return applied, nil
+}
+
+// matchesNotification checks if a notification entry refers to an entry. Entries are matched by their
+// account servicer reference, or by their entry reference if either of them has no account servicer reference.
+func matchesNotification(entry camt053.Entry, notification camt053.Entry) bool {
+	entryServicerRef, notificationServicerRef := stringOrEmpty(entry.AccountServicerRef), stringOrEmpty(notification.AccountServicerRef)
+	if entryServicerRef != "" && notificationServicerRef != "" {
+		return entryServicerRef == notificationServicerRef
+	}
+	entryRef, notificationRef := stringOrEmpty(entry.Reference), stringOrEmpty(notification.Reference)
+	return entryRef != "" && entryRef == notificationRef
+}
+
+// mergeNotification adds the details of a notification entry to an entry. Entry details, e.g. the transactions
+// of a batch booking and their remittance information, are taken from the notification. Amount details and
+// charges are only taken from the notification if the entry has none.
+func mergeNotification(entry *camt053.Entry, notification camt053.Entry) {
+	if notification.EntryDetails != nil && len(*notification.EntryDetails) > 0 {
+		entry.EntryDetails = notification.EntryDetails
+	}
+	if entry.AmountDetails == nil {
+		entry.AmountDetails = notification.AmountDetails
+	}
+	if entry.Charges == nil {
+		entry.Charges = notification.Charges
+	}
+}
+
+// pendingNotifications are the notification entries of an account that are waiting for the entry they refer to.
+type pendingNotifications struct {
+	entries map[string]camt053.Entry // By entryKey
+	refs    *entryIndex
+}
+
+// entryIndex finds the keys of stored entries by the references that matchesNotification compares, so that
+// notifications and entries are matched without going through every entry of the account.
+type entryIndex struct {
+	servicerRefs map[string][]string // Keys of the entries by account servicer reference
+	references   map[string][]string // Keys of the entries by entry reference
+}
+
+func newEntryIndex() *entryIndex {
+	return &entryIndex{servicerRefs: make(map[string][]string), references: make(map[string][]string)}
+}
+
+// add indexes the entry stored with key.
+func (x *entryIndex) add(key string, entry camt053.Entry) {
+	if ref := stringOrEmpty(entry.AccountServicerRef); ref != "" && !slices.Contains(x.servicerRefs[ref], key) {
+		x.servicerRefs[ref] = append(x.servicerRefs[ref], key)
+	}
+	if ref := stringOrEmpty(entry.Reference); ref != "" && !slices.Contains(x.references[ref], key) {
+		x.references[ref] = append(x.references[ref], key)
+	}
+}
+
+// remove removes the entry stored with key from the index.
+func (x *entryIndex) remove(key string, entry camt053.Entry) {
+	removeKey(x.servicerRefs, stringOrEmpty(entry.AccountServicerRef), key)
+	removeKey(x.references, stringOrEmpty(entry.Reference), key)
+}
+
+// lookup returns the keys of the entries with the same account servicer reference or entry reference as an entry,
+// in the order they were indexed.
+func (x *entryIndex) lookup(entry camt053.Entry) []string {
+	keys := slices.Clone(x.servicerRefs[stringOrEmpty(entry.AccountServicerRef)])
+	for _, key := range x.references[stringOrEmpty(entry.Reference)] {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func removeKey(keys map[string][]string, ref string, key string) {
+	if ref == "" {
+		return
+	}
+	keys[ref] = slices.DeleteFunc(keys[ref], func(k string) bool { return k == key })
+	if len(keys[ref]) == 0 {
+		delete(keys, ref)
+	}
+}
+
+// entryKey identifies a notification entry by its account servicer reference, falling back on the entry reference.
+func entryKey(entry camt053.Entry) string {
+	if ref := stringOrEmpty(entry.AccountServicerRef); ref != "" {
+		return "AcctSvcrRef/" + ref
+	}
+	if ref := stringOrEmpty(entry.Reference); ref != "" {
+		return "NtryRef/" + ref
+	}
+	return ""
+}
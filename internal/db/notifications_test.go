@@ -0,0 +1,174 @@
+// package db is a local mock database.
+package db
+
+import (
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRemittance = "Invoice 4711"
+
+// notificationDocument creates a camt054 notification with remittance information for the first entry of the mock statement.
+func notificationDocument(t *testing.T) camt053.Document {
+	doc := loadTestDocument(t)
+	stmt := doc.Statements()[0]
+
+	entry := (*stmt.Entries)[0]
+	entry.Reference = nil // Matched by the account servicer reference
+	entry.EntryDetails = &[]camt053.EntryDetail{{TransactionDetails: &[]camt053.TransactionDetail{{
+		RemittanceInformation: &camt053.RemittanceInformation{Unstructured: &[]string{testRemittance}},
+	}}}}
+
+	doc.Notification = &camt053.BankToCustomerDebitCreditNotification{
+		GroupHeader: doc.Header(),
+		Notifications: []camt053.Statement{
+			{Id: "NTFCTN-1", CreationDateTime: stmt.CreationDateTime, Account: stmt.Account, Entries: &[]camt053.Entry{entry}},
+		},
+	}
+	doc.BankStatement = camt053.BankToCustomerStatement{}
+	doc.MessageType = camt053.MESSAGE_TYPE_NOTIFICATION
+	return doc
+}
+
+// remittance returns the unstructured remittance information of the first transaction of an entry.
+func remittance(entry *camt053.Entry) string {
+	details := (*entry.EntryDetails)[0].TransactionDetails
+	if details == nil || (*details)[0].RemittanceInformation == nil || (*details)[0].RemittanceInformation.Unstructured == nil {
+		return ""
+	}
+	return (*(*details)[0].RemittanceInformation.Unstructured)[0]
+}
+
+// TestLoadCamt054 checks that notifications are merged into entries that have already been loaded.
+func TestLoadCamt054(t *testing.T) {
+	db := NewBankData()
+	doc := loadTestDocument(t)
+	ref := convertEntryRef(*(*doc.Statements()[0].Entries)[0].Reference)
+
+	_, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	entry, err := db.GetAccountTransaction(testAccountId, ref)
+	assert.NoError(t, err)
+	assert.NotEqual(t, testRemittance, remittance(entry))
+
+	summary, err := db.LoadCamt053(notificationDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.NotificationsLoaded)
+	assert.Equal(t, 1, summary.EntriesEnriched)
+	assert.Equal(t, 0, summary.NotificationsPending)
+	assert.Equal(t, 0, summary.StatementsLoaded)
+
+	entry, err = db.GetAccountTransaction(testAccountId, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testRemittance, remittance(entry))
+
+	// Notifications are not statements
+	statements, err := db.GetAccountStatements(testAccountId)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statements.TotalCount)
+}
+
+// TestLoadCamt054Pending checks that notifications received before their entry are merged when the entry is loaded.
+func TestLoadCamt054Pending(t *testing.T) {
+	db := NewBankData()
+	doc := loadTestDocument(t)
+	ref := convertEntryRef(*(*doc.Statements()[0].Entries)[0].Reference)
+
+	summary, err := db.LoadCamt053(notificationDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.NotificationsPending)
+	assert.Equal(t, 0, summary.EntriesEnriched)
+	assert.False(t, db.AccountExists(testAccountId))
+
+	summary, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.EntriesAdded)
+	assert.Equal(t, 1, summary.EntriesEnriched)
+
+	entry, err := db.GetAccountTransaction(testAccountId, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testRemittance, remittance(entry))
+
+	// Merged notifications are deleted, and duplicates are not enriched again
+	assert.Empty(t, db.pending)
+	summary, err = db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.DuplicatesSkipped)
+	assert.Equal(t, 0, summary.EntriesEnriched)
+}
+
+// TestLoadCamt054Intraday checks that notifications merged into intraday entries are kept until the entry
+// of the statement replaces them.
+func TestLoadCamt054Intraday(t *testing.T) {
+	db := NewBankData()
+	doc := loadTestDocument(t)
+	ref := convertEntryRef(*(*doc.Statements()[0].Entries)[0].Reference)
+
+	_, err := db.LoadCamt053(intradayReport(loadTestDocument(t), "RPT-1"))
+	assert.NoError(t, err)
+	summary, err := db.LoadCamt053(notificationDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.EntriesEnriched)
+	assert.Equal(t, 0, summary.NotificationsPending)
+	assert.Len(t, db.pending[testAccountId].entries, 1)
+
+	entry, err := db.GetAccountTransaction(testAccountId, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testRemittance, remittance(entry))
+
+	summary, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.EntriesSettled)
+	assert.Equal(t, 1, summary.EntriesEnriched)
+	assert.Empty(t, db.pending)
+
+	entry, err = db.GetAccountTransaction(testAccountId, ref)
+	assert.NoError(t, err)
+	assert.False(t, entry.Intraday)
+	assert.Equal(t, testRemittance, remittance(entry))
+}
+
+// TestEntryIndex checks that entries are found by their account servicer reference and entry reference.
+func TestEntryIndex(t *testing.T) {
+	ref := func(s string) *string { return &s }
+	index := newEntryIndex()
+	index.add("1", camt053.Entry{AccountServicerRef: ref("A1"), Reference: ref("N1")})
+	index.add("2", camt053.Entry{Reference: ref("N1")})
+	index.add("3", camt053.Entry{AccountServicerRef: ref("A3")})
+
+	assert.Equal(t, []string{"1", "2"}, index.lookup(camt053.Entry{Reference: ref("N1")}))
+	assert.Equal(t, []string{"3", "1", "2"}, index.lookup(camt053.Entry{AccountServicerRef: ref("A3"), Reference: ref("N1")}))
+	assert.Empty(t, index.lookup(camt053.Entry{}))
+
+	index.remove("1", camt053.Entry{AccountServicerRef: ref("A1"), Reference: ref("N1")})
+	assert.Equal(t, []string{"2"}, index.lookup(camt053.Entry{AccountServicerRef: ref("A1"), Reference: ref("N1")}))
+	assert.NotContains(t, index.servicerRefs, "A1")
+}
+
+// TestMatchesNotification checks that notification entries are matched by account servicer reference and entry reference.
+func TestMatchesNotification(t *testing.T) {
+	ref := func(s string) *string { return &s }
+
+	// Declare Tests
+	tests := []struct {
+		name         string
+		entry        camt053.Entry
+		notification camt053.Entry
+		expected     bool
+	}{
+		{"Account servicer reference", camt053.Entry{AccountServicerRef: ref("A1")}, camt053.Entry{AccountServicerRef: ref("A1")}, true},
+		{"Different account servicer reference", camt053.Entry{AccountServicerRef: ref("A1"), Reference: ref("N1")}, camt053.Entry{AccountServicerRef: ref("A2"), Reference: ref("N1")}, false},
+		{"Entry reference", camt053.Entry{AccountServicerRef: ref("A1"), Reference: ref("N1")}, camt053.Entry{Reference: ref("N1")}, true},
+		{"Different entry reference", camt053.Entry{Reference: ref("N1")}, camt053.Entry{Reference: ref("N2")}, false},
+		{"No references", camt053.Entry{}, camt053.Entry{}, false},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, matchesNotification(test.entry, test.notification))
+		})
+	}
+}
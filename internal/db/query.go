@@ -0,0 +1,324 @@
+// package db is a local mock database.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+const SORT_BOOKING_DATE = "bookingDate"
+const SORT_VALUE_DATE = "valueDate"
+const SORT_AMOUNT = "amount"
+
+const DEFAULT_PER_PAGE = 50
+const MAX_PER_PAGE = 500
+
+// Largest page number, so that the position of the first item of a page, (page-1)*perPage, can not overflow.
+const MAX_PAGE = math.MaxInt / MAX_PER_PAGE
+
+// TransactionQuery describes which of an accounts transactions to fetch and in which order.
+// Zero values disable a filter.
+type TransactionQuery struct {
+	Page       int    // 1 based page number
+	PerPage    int    // Number of transactions per page
+	Cursor     string // Cursor token from a previous response, can not be combined with Page
+	SortBy     string // SORT_BOOKING_DATE, SORT_VALUE_DATE or SORT_AMOUNT
+	Descending bool
+
+	FromDate             *camt053.Date    // Earliest booking date, inclusive
+	ToDate               *camt053.Date    // Latest booking date, inclusive
+	CreditDebitIndicator string           // CRDT or DBIT
+	Status               string           // BOOK, PDNG or INFO
+	MinAmount            *camt053.Decimal // Smallest amount, inclusive
+	MaxAmount            *camt053.Decimal // Largest amount, inclusive
+	BankTransactionCode  string           // Domain code prefix, e.g. PMNT or PMNT-ICDT-ATXN, or a proprietary code
+}
+
+// withDefaults fills in the page, page size and sort order if they are missing.
+func (q TransactionQuery) withDefaults() TransactionQuery {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PerPage < 1 {
+		q.PerPage = DEFAULT_PER_PAGE
+	}
+	if q.PerPage > MAX_PER_PAGE {
+		q.PerPage = MAX_PER_PAGE
+	}
+	if q.SortBy == "" {
+		q.SortBy = SORT_BOOKING_DATE
+	}
+	return q
+}
+
+// Validate checks that the sort order and filters of a query are supported.
+func (q TransactionQuery) Validate() error {
+	switch q.SortBy {
+	case "", SORT_BOOKING_DATE, SORT_VALUE_DATE, SORT_AMOUNT:
+	default:
+		return errors.New("transactions can only be sorted by bookingDate, valueDate or amount")
+	}
+	switch q.CreditDebitIndicator {
+	case "", "CRDT", "DBIT":
+	default:
+		return errors.New("creditDebitIndicator must be CRDT or DBIT")
+	}
+	switch q.Status {
+	case "", "BOOK", "PDNG", "INFO":
+	default:
+		return errors.New("status must be BOOK, PDNG or INFO")
+	}
+	if q.FromDate != nil && q.ToDate != nil && q.FromDate.Compare(*q.ToDate) > 0 {
+		return errors.New("fromDate is after toDate")
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && q.MinAmount.Cmp(*q.MaxAmount) > 0 {
+		return errors.New("minAmount is larger than maxAmount")
+	}
+	if q.Page > MAX_PAGE {
+		return fmt.Errorf("page can be at most %d", MAX_PAGE)
+	}
+	if q.Page != 0 && q.Cursor != "" {
+		return errors.New("page and cursor can not be combined")
+	}
+	return nil
+}
+
+// FiltersEntries checks if the query filters the transactions by more than their booking date,
+// so that they no longer add up to the balances of the account.
+func (q TransactionQuery) FiltersEntries() bool {
+	return q.CreditDebitIndicator != "" || q.Status != "" || q.MinAmount != nil || q.MaxAmount != nil || q.BankTransactionCode != ""
+}
+
+// fingerprint identifies the order and filters of the query for an account, cursors are only valid for the same fingerprint.
+func (q TransactionQuery) fingerprint(accountId string) string {
+	amounts := make([]string, 2)
+	for i, amount := range []*camt053.Decimal{q.MinAmount, q.MaxAmount} {
+		if amount != nil {
+			amounts[i] = amount.Rat().RatString() // Equal amounts with different scales have the same fingerprint
+		}
+	}
+	return queryFingerprint("transactions", accountId, q.SortBy, strconv.FormatBool(q.Descending), dateOrEmpty(q.FromDate), dateOrEmpty(q.ToDate),
+		q.CreditDebitIndicator, q.Status, amounts[0], amounts[1], strings.ToUpper(q.BankTransactionCode))
+}
+
+// AccountQuery describes which page of accounts to fetch, accounts are always sorted by id.
+type AccountQuery struct {
+	Page    int    // 1 based page number
+	PerPage int    // Number of accounts per page
+	Cursor  string // Cursor token from a previous response, can not be combined with Page
+}
+
+// withDefaults fills in the page and page size if they are missing.
+func (q AccountQuery) withDefaults() AccountQuery {
+	paged := TransactionQuery{Page: q.Page, PerPage: q.PerPage}.withDefaults()
+	q.Page, q.PerPage = paged.Page, paged.PerPage
+	return q
+}
+
+// Validate checks that the query does not combine a page with a cursor.
+func (q AccountQuery) Validate() error {
+	if q.Page > MAX_PAGE {
+		return fmt.Errorf("page can be at most %d", MAX_PAGE)
+	}
+	if q.Page != 0 && q.Cursor != "" {
+		return errors.New("page and cursor can not be combined")
+	}
+	return nil
+}
+
+func (q AccountQuery) fingerprint() string {
+	return queryFingerprint("accounts")
+}
+
+// ParseAmount parses a positive decimal amount such as "1250.50" without losing precision.
+func ParseAmount(rawAmount string) (camt053.Decimal, error) {
+	amount, err := camt053.ParseDecimal(rawAmount)
+	if err != nil || amount.Sign() < 0 || strings.HasPrefix(strings.TrimSpace(rawAmount), "-") {
+		return camt053.Decimal{}, errors.New("amount is not a positive decimal number")
+	}
+	return amount, nil
+}
+
+// matches checks if an entry passes all filters of the query.
+func (q TransactionQuery) matches(entry *camt053.Entry) bool {
+	if q.CreditDebitIndicator != "" && entry.CreditDebitIndicator != q.CreditDebitIndicator {
+		return false
+	}
+	if q.Status != "" && string(entry.Status) != q.Status {
+		return false
+	}
+	if q.FromDate != nil || q.ToDate != nil {
+		if entry.BookingDate == nil {
+			return false
+		}
+		bookingDate := entry.BookingDate.Day()
+		if (q.FromDate != nil && bookingDate.Compare(*q.FromDate) < 0) || (q.ToDate != nil && bookingDate.Compare(*q.ToDate) > 0) {
+			return false
+		}
+	}
+	if q.MinAmount != nil || q.MaxAmount != nil {
+		amount := entry.Amount.Value
+		if (q.MinAmount != nil && amount.Cmp(*q.MinAmount) < 0) || (q.MaxAmount != nil && amount.Cmp(*q.MaxAmount) > 0) {
+			return false
+		}
+	}
+	if q.BankTransactionCode != "" && !matchesBankTransactionCode(entry.BankTransactionCode, q.BankTransactionCode) {
+		return false
+	}
+	return true
+}
+
+// filterTransactions returns the transactions of an account that match the query, sorted by the query.
+func (q TransactionQuery) filterTransactions(account *Account) []*camt053.Entry {
+
+	// Convert Map data to slice since we don't use a real DB
+	transactions := []*camt053.Entry{}
+	for _, transaction := range account.Transactions {
+		if q.matches(&transaction) {
+			transactions = append(transactions, &transaction)
+		}
+	}
+	q.sortTransactions(transactions)
+	return transactions
+}
+
+// matchesBankTransactionCode checks if code is a prefix of the entries domain code, e.g. PMNT-ICDT,
+// or equal to its proprietary code. The comparison is case insensitive.
+func matchesBankTransactionCode(btc camt053.BankTransactionCode, code string) bool {
+	code = strings.ToUpper(code)
+	if btc.Domain != nil {
+		domainCode := strings.ToUpper(strings.Join([]string{btc.Domain.Code, btc.Domain.Family.Code, btc.Domain.Family.SubFamilyCode}, "-"))
+		if domainCode == code || strings.HasPrefix(domainCode, code+"-") {
+			return true
+		}
+	}
+	if btc.ProprietaryCode != nil && strings.ToUpper(btc.ProprietaryCode.Code) == code {
+		return true
+	}
+	return false
+}
+
+// sortTransactions sorts entries by the sort field of the query. Entries with equal sort
+// values, or missing dates, are ordered by reference so that the order is stable between requests.
+// The sort key of each entry is computed once, rather than on every comparison.
+func (q TransactionQuery) sortTransactions(entries []*camt053.Entry) {
+	type sortedEntry struct {
+		entry *camt053.Entry
+		key   string
+		ref   string
+	}
+	sorted := make([]sortedEntry, len(entries))
+	for i, entry := range entries {
+		sorted[i] = sortedEntry{entry: entry, key: q.sortKey(entry), ref: stringOrEmpty(entry.URLReference)}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return q.compare(sorted[i].key, sorted[i].ref, sorted[j].key, sorted[j].ref) < 0
+	})
+	for i := range sorted {
+		entries[i] = sorted[i].entry
+	}
+}
+
+// sortValue returns the value of the field the query sorts entries by, as it is kept in cursors.
+func (q TransactionQuery) sortValue(entry *camt053.Entry) string {
+	if q.SortBy == SORT_AMOUNT {
+		return entry.Amount.Value.String()
+	}
+	return q.sortKey(entry)
+}
+
+// sortKey returns the value of the field the query sorts entries by, formatted so that it can be compared as a string.
+func (q TransactionQuery) sortKey(entry *camt053.Entry) string {
+	switch q.SortBy {
+	case SORT_AMOUNT:
+		return amountSortKey(entry.Amount.Value)
+	case SORT_VALUE_DATE:
+		return dateSortKey(entry.ValueDate)
+	default:
+		return dateSortKey(entry.BookingDate)
+	}
+}
+
+// cursorKey converts the sort value of a cursor, see sortValue, into a sort key.
+func (q TransactionQuery) cursorKey(cursor Cursor) string {
+	if q.SortBy == SORT_AMOUNT {
+		// Values that are not amounts, e.g. from an old cursor, are treated as zero
+		amount, _ := camt053.ParseDecimal(cursor.Key)
+		return amountSortKey(amount)
+	}
+	return cursor.Key
+}
+
+// compare orders two entries by their sort keys and references in the order of the query.
+func (q TransactionQuery) compare(aKey string, aRef string, bKey string, bRef string) int {
+	cmp := strings.Compare(aKey, bKey)
+	if cmp == 0 {
+		cmp = strings.Compare(aRef, bRef)
+	}
+	if q.Descending {
+		return -cmp
+	}
+	return cmp
+}
+
+// Layout of the UTC time of date times in date sort keys, fixed width so that e.g. 10:00:00.5 is sorted after 10:00:00.
+const DATE_TIME_KEY_LAYOUT = "2006-01-02T15:04:05.000000000Z"
+
+// dateSortKey formats a date or date time so that it can be compared as a string, e.g. in an index of a SQL database.
+// Keys start with the date, or the date of a date time in its own time zone offset, so that date filters can compare
+// the start of the key and dates are sorted before date times on the same day. Date times on the same day are
+// followed by their UTC time, e.g. "2018-12-17 2018-12-16T23:30:00.000000000Z" for 2018-12-17T00:30:00+01:00.
+func dateSortKey(date *camt053.DateAndDateTime) string {
+	if date == nil || date.IsZero() {
+		return ""
+	}
+	if date.DateTime != nil {
+		return date.Day().String() + " " + date.DateTime.Time().Format(DATE_TIME_KEY_LAYOUT)
+	}
+	return date.Day().String()
+}
+
+// Number of digits the integer part of amounts is padded to by amountSortKey, more than the 18 digits camt053 allows.
+const AMOUNT_KEY_DIGITS = 30
+
+// Number of decimals amounts are padded to by amountSortKey, the most that camt053 allows.
+const AMOUNT_KEY_DECIMALS = 5
+
+// amountSortKey formats an amount so that it can be compared as a string, e.g. in an index of a SQL database.
+// Trailing zeros after AMOUNT_KEY_DECIMALS are removed so that equal amounts with different scales have equal keys.
+// Amounts are never negative, negative values are sorted before every amount.
+func amountSortKey(amount camt053.Decimal) string {
+	if amount.Sign() < 0 {
+		return "-"
+	}
+	integer, fraction, _ := strings.Cut(amount.String(), ".")
+	if len(fraction) < AMOUNT_KEY_DECIMALS {
+		fraction += strings.Repeat("0", AMOUNT_KEY_DECIMALS-len(fraction))
+	} else {
+		fraction = fraction[:AMOUNT_KEY_DECIMALS] + strings.TrimRight(fraction[AMOUNT_KEY_DECIMALS:], "0")
+	}
+	if len(integer) < AMOUNT_KEY_DIGITS {
+		integer = strings.Repeat("0", AMOUNT_KEY_DIGITS-len(integer)) + integer
+	}
+	return integer + "." + fraction
+}
+
+func dateOrEmpty(date *camt053.Date) string {
+	if date == nil {
+		return ""
+	}
+	return date.String()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,204 @@
+// package db is a local mock database.
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadTwoDays loads the mock data along with a copy of it for the following day.
+func loadTwoDays(t *testing.T) *BankData {
+	db := NewBankData()
+	doc := loadTestDocument(t)
+	doc.BankStatement.Statements = append(doc.BankStatement.Statements,
+		nextDayStatement(doc.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18"))
+
+	nextDay := doc.BankStatement.Statements[1]
+	for i := range *nextDay.Entries {
+		(*nextDay.Entries)[i].BookingDate = &camt053.DateAndDateTime{Date: date("2018-12-18")}
+	}
+
+	if _, err := db.LoadCamt053(doc); err != nil {
+		t.Fatalf("failed to load test data: %s", err)
+	}
+	return &db
+}
+
+func amount(value string) *camt053.Decimal {
+	a, _ := ParseAmount(value)
+	return &a
+}
+
+func date(value string) *camt053.Date {
+	d, _ := camt053.ParseDate(value)
+	return &d
+}
+
+func dateTime(value string) *camt053.DateTime {
+	dt, _ := camt053.ParseDateTime(value)
+	return &dt
+}
+
+// TestGetAccountTransactionsQuery checks the filters and sort orders of transaction queries.
+func TestGetAccountTransactionsQuery(t *testing.T) {
+	db := loadTwoDays(t)
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		query         TransactionQuery
+		expectedCount int
+		expectedFirst string
+	}{
+		{"Default", TransactionQuery{}, 14, "46706206020-0029254"},
+		{"Booking date descending", TransactionQuery{Descending: true}, 14, "S20181218-LBE5419-0186-0029234"},
+		{"Amount ascending", TransactionQuery{SortBy: SORT_AMOUNT}, 14, "46706206020-0029254"},
+		{"Amount descending", TransactionQuery{SortBy: SORT_AMOUNT, Descending: true}, 14, "S20181218-INTERNÖVERF-0029240"},
+		{"Single day", TransactionQuery{FromDate: date("2018-12-18"), ToDate: date("2018-12-18")}, 7, "S20181218-46706206020-0029254"},
+		{"Before date", TransactionQuery{ToDate: date("2018-12-17")}, 7, "46706206020-0029254"},
+		{"Debits", TransactionQuery{CreditDebitIndicator: "DBIT", SortBy: SORT_AMOUNT}, 4, "JAMBO81518-0029248"},
+		{"Booked", TransactionQuery{Status: "BOOK"}, 14, "46706206020-0029254"},
+		{"Pending", TransactionQuery{Status: "PDNG"}, 0, ""},
+		{"Amount range", TransactionQuery{MinAmount: amount("72690"), MaxAmount: amount("91838.00")}, 4, "B81215944996-0029236"},
+		{"Domain code", TransactionQuery{BankTransactionCode: "pmnt-icdt"}, 4, "JAMBO81518-0029248"},
+		{"Sub family code", TransactionQuery{BankTransactionCode: "PMNT-RCDT-DMCT"}, 2, "46706206020-0029254"},
+		{"Proprietary code", TransactionQuery{BankTransactionCode: "msc miscellaneous"}, 2, "JS2320361767-0029249"},
+		{"Partial domain code", TransactionQuery{BankTransactionCode: "PMN"}, 0, ""},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := db.GetAccountTransactions(testAccountId, test.query)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCount, response.TotalCount)
+			if test.expectedFirst != "" {
+				assert.Equal(t, test.expectedFirst, *response.Transactions[0].URLReference)
+			}
+		})
+	}
+}
+
+// TestGetAccountTransactionsPagination checks that pages are stable and do not overlap.
+func TestGetAccountTransactionsPagination(t *testing.T) {
+	db := loadTwoDays(t)
+
+	seen := make(map[string]bool)
+	for page := 1; page <= 3; page++ {
+		response, err := db.GetAccountTransactions(testAccountId, TransactionQuery{Page: page, PerPage: 5, SortBy: SORT_AMOUNT})
+		assert.NoError(t, err)
+		assert.Equal(t, 14, response.TotalCount)
+		assert.Equal(t, 3, response.TotalPages)
+		assert.Equal(t, page, response.Page)
+
+		for _, transaction := range response.Transactions {
+			assert.False(t, seen[*transaction.URLReference], "transaction on more than one page")
+			seen[*transaction.URLReference] = true
+		}
+	}
+	assert.Len(t, seen, 14)
+
+	// Pages past the last page are empty
+	response, err := db.GetAccountTransactions(testAccountId, TransactionQuery{Page: 4, PerPage: 5})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Transactions)
+}
+
+// TestGetAccountTransactionsInvalidQuery checks that unsupported queries are rejected.
+func TestGetAccountTransactionsInvalidQuery(t *testing.T) {
+	db := loadTwoDays(t)
+
+	queries := []TransactionQuery{
+		{SortBy: "reference"},
+		{CreditDebitIndicator: "CREDIT"},
+		{Status: "DONE"},
+		{FromDate: date("2018-12-18"), ToDate: date("2018-12-17")},
+		{MinAmount: amount("10"), MaxAmount: amount("5")},
+	}
+	for _, query := range queries {
+		_, err := db.GetAccountTransactions(testAccountId, query)
+		assert.Error(t, err)
+	}
+
+	_, err := db.GetAccountTransactions("DD01100056869", TransactionQuery{})
+	assert.Error(t, err)
+}
+
+// TestFiltersEntries checks that only filters other than the booking date count as filtering the entries.
+func TestFiltersEntries(t *testing.T) {
+	// Declare Tests
+	tests := []struct {
+		query    TransactionQuery
+		expected bool
+	}{
+		{TransactionQuery{}, false},
+		{TransactionQuery{FromDate: date("2018-12-17"), ToDate: date("2018-12-18"), SortBy: SORT_AMOUNT}, false},
+		{TransactionQuery{CreditDebitIndicator: "DBIT"}, true},
+		{TransactionQuery{Status: "BOOK"}, true},
+		{TransactionQuery{MinAmount: amount("10")}, true},
+		{TransactionQuery{MaxAmount: amount("10")}, true},
+		{TransactionQuery{BankTransactionCode: "PMNT"}, true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.query.FiltersEntries())
+	}
+}
+
+// TestParseAmount checks that only positive decimal numbers are accepted as amounts.
+func TestParseAmount(t *testing.T) {
+	for _, valid := range []string{"0", "100", "100.5", "242041.00"} {
+		_, err := ParseAmount(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"", "-5", "1/3", "1e5", "10.", ".5", "abc"} {
+		_, err := ParseAmount(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// TestAmountSortKey checks that amount sort keys compare as strings the same way as the amounts compare as numbers.
+func TestAmountSortKey(t *testing.T) {
+	amounts := []string{"0", "0.00", "0.000001", "0.1", "0.10", "0.100000", "0.12345", "0.123451", "1", "9.99", "10", "242041.00", "99999999999999999.99", "123456789012345678901234567890123"}
+	for _, a := range amounts {
+		for _, b := range amounts {
+			aAmount, bAmount := *amount(a), *amount(b)
+			assert.Equal(t, aAmount.Cmp(bAmount), strings.Compare(amountSortKey(aAmount), amountSortKey(bAmount)), "%s and %s", a, b)
+		}
+	}
+	assert.Equal(t, "000000000000000000000000242041.00000", amountSortKey(*amount("242041.00")))
+}
+
+// TestDateSortKey checks that date sort keys compare as strings in the order of the dates and date times,
+// and that they start with the date that date filters compare.
+func TestDateSortKey(t *testing.T) {
+	sorted := []string{
+		"2018-12-16",
+		"2018-12-17",
+		"2018-12-17T00:30:00+01:00",
+		"2018-12-17T00:00:00Z",
+		"2018-12-17T00:00:00.5Z",
+		"2018-12-17T10:00:00Z",
+		"2018-12-18T00:30:00+01:00",
+	}
+	keys := make([]string, len(sorted))
+	for i, raw := range sorted {
+		var value camt053.DateAndDateTime
+		assert.NoError(t, value.UnmarshalJSON([]byte(`"`+raw+`"`)))
+		keys[i] = dateSortKey(&value)
+		assert.True(t, strings.HasPrefix(keys[i], raw[:10]), keys[i])
+	}
+	for i := 1; i < len(keys); i++ {
+		assert.Less(t, keys[i-1], keys[i])
+	}
+
+	// Equal instants on the same day have equal keys
+	a := camt053.DateAndDateTime{DateTime: dateTime("2018-12-17T11:00:00+01:00")}
+	b := camt053.DateAndDateTime{DateTime: dateTime("2018-12-17T10:00:00Z")}
+	assert.Equal(t, dateSortKey(&a), dateSortKey(&b))
+	assert.Equal(t, "", dateSortKey(nil))
+}
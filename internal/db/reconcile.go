@@ -0,0 +1,247 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+const RECONCILIATION_OK = "OK"
+const RECONCILIATION_FAILED = "FAILED"
+const RECONCILIATION_SKIPPED = "SKIPPED" // The statement does not contain the data needed for the check
+
+const RECONCILIATION_MODE_WARN = "warn"
+const RECONCILIATION_MODE_STRICT = "strict"
+
+// Reconciliation is the result of checking that the balances and transaction summary of a statement match its entries.
+type Reconciliation struct {
+	StatementId string                `json:"statementId"`
+	Status      string                `json:"status"`
+	Checks      []ReconciliationCheck `json:"checks"`
+}
+
+// ReconciliationCheck is the result of a single reconciliation check.
+type ReconciliationCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ReconciliationMode returns how statements that do not reconcile are handled, read from the RECONCILIATION_MODE env variable.
+// In RECONCILIATION_MODE_WARN, the default, they are loaded with a warning and in RECONCILIATION_MODE_STRICT they are rejected.
+func ReconciliationMode() (string, error) {
+	switch mode := strings.ToLower(os.Getenv("RECONCILIATION_MODE")); mode {
+	case "", RECONCILIATION_MODE_WARN:
+		return RECONCILIATION_MODE_WARN, nil
+	case RECONCILIATION_MODE_STRICT:
+		return RECONCILIATION_MODE_STRICT, nil
+	default:
+		return "", fmt.Errorf("RECONCILIATION_MODE must be '%s' or '%s', got '%s'", RECONCILIATION_MODE_WARN, RECONCILIATION_MODE_STRICT, mode)
+	}
+}
+
+// Failed returns the checks that failed.
+func (r Reconciliation) Failed() []ReconciliationCheck {
+	failed := make([]ReconciliationCheck, 0)
+	for _, check := range r.Checks {
+		if check.Status == RECONCILIATION_FAILED {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// String describes the failed checks of a reconciliation.
+func (r Reconciliation) String() string {
+	messages := make([]string, 0)
+	for _, check := range r.Failed() {
+		messages = append(messages, check.Message)
+	}
+	if len(messages) == 0 {
+		return "statement " + r.StatementId + " reconciles"
+	}
+	return "statement " + r.StatementId + " does not reconcile: " + strings.Join(messages, ", ")
+}
+
+// entryTotals sums up the entries of a statement. It is built one entry at a time so that statements
+// can be reconciled while they are streamed.
+type entryTotals struct {
+	booked camt053.Decimal // Signed sum of the booked entries
+	net    camt053.Decimal // Signed sum of all entries
+	all    countAndSum
+	credit countAndSum
+	debit  countAndSum
+}
+
+// countAndSum is the number of entries and the sum of their amounts.
+type countAndSum struct {
+	count int
+	sum   camt053.Decimal
+}
+
+func (c *countAndSum) add(entry camt053.Entry) {
+	c.count++
+	c.sum = c.sum.Add(entry.Amount.Value)
+}
+
+// add adds an entry to the totals.
+func (t *entryTotals) add(entry camt053.Entry) {
+	signed := entry.Amount.Signed(entry.CreditDebitIndicator)
+	if entry.Status == "" || entry.Status == "BOOK" {
+		t.booked = t.booked.Add(signed) // Only booked entries affect the booked balance
+	}
+	t.net = t.net.Add(signed)
+	t.all.add(entry)
+	switch entry.CreditDebitIndicator {
+	case "CRDT":
+		t.credit.add(entry)
+	case "DBIT":
+		t.debit.add(entry)
+	}
+}
+
+// Reconcile checks that the opening balance plus the booked credit entries minus the booked debit entries
+// equals the closing balance, and that the counts and sums of the transaction summary match the entries.
+func Reconcile(stmt camt053.Statement) Reconciliation {
+	totals := entryTotals{}
+	if stmt.Entries != nil {
+		for _, entry := range *stmt.Entries {
+			totals.add(entry)
+		}
+	}
+	return reconcileTotals(stmt, totals)
+}
+
+// reconcileTotals reconciles a statement against the totals of its entries.
+func reconcileTotals(stmt camt053.Statement, totals entryTotals) Reconciliation {
+	reconciliation := Reconciliation{StatementId: stmt.Id, Status: RECONCILIATION_OK}
+	reconciliation.Checks = append(reconciliation.Checks, reconcileBalances(stmt.Balances, totals))
+	if stmt.TransactionSummary == nil {
+		reconciliation.Checks = append(reconciliation.Checks, ReconciliationCheck{
+			Name: "transactionSummary", Status: RECONCILIATION_SKIPPED, Message: "statement has no transaction summary",
+		})
+	} else {
+		summary := stmt.TransactionSummary
+		reconciliation.Checks = append(reconciliation.Checks,
+			reconcileSummary("totalEntries", totalEntriesSummary(summary.TotalEntries), totals.all),
+			reconcileSummary("totalCreditEntries", summary.TotalCreditEntries, totals.credit),
+			reconcileSummary("totalDebitEntries", summary.TotalDebitEntries, totals.debit),
+		)
+		if summary.TotalEntries != nil && summary.TotalEntries.TotalNetEntryAmount != nil {
+			reconciliation.Checks = append(reconciliation.Checks, reconcileNetAmount(summary.TotalEntries, totals))
+		}
+	}
+
+	for _, check := range reconciliation.Checks {
+		if check.Status == RECONCILIATION_FAILED {
+			reconciliation.Status = RECONCILIATION_FAILED
+		}
+	}
+	return reconciliation
+}
+
+// reconcileBalances checks that OPBD + booked credits - booked debits = CLBD.
+func reconcileBalances(balances []camt053.Balance, totals entryTotals) ReconciliationCheck {
+	check := ReconciliationCheck{Name: "balance"}
+
+	opening, closing := findBalance(balances, "OPBD"), findBalance(balances, "CLBD")
+	if opening == nil || closing == nil {
+		check.Status, check.Message = RECONCILIATION_SKIPPED, "statement is missing an OPBD or CLBD balance"
+		return check
+	}
+
+	expected := opening.Amount.Signed(opening.CreditDebitIndicator).Add(totals.booked)
+	actual := closing.Amount.Signed(closing.CreditDebitIndicator)
+
+	check.Expected, check.Actual = expected.String(), actual.String()
+	if expected.Cmp(actual) != 0 {
+		check.Status = RECONCILIATION_FAILED
+		check.Message = fmt.Sprintf("OPBD plus booked entries is %s but CLBD is %s", check.Expected, check.Actual)
+		return check
+	}
+	check.Status = RECONCILIATION_OK
+	return check
+}
+
+// reconcileSummary checks the number and sum of the entries against a part of the transaction summary.
+func reconcileSummary(name string, summary *camt053.CreditDebitEntry, entries countAndSum) ReconciliationCheck {
+	check := ReconciliationCheck{Name: name}
+	if summary == nil {
+		check.Status, check.Message = RECONCILIATION_SKIPPED, "transaction summary has no "+name
+		return check
+	}
+
+	count, sum := entries.count, entries.sum
+
+	// The sum is optional, only the number of entries is checked without it
+	if summary.Sum == nil {
+		check.Expected, check.Actual = fmt.Sprintf("%d entries", summary.NumberOfEntries), fmt.Sprintf("%d entries", count)
+	} else {
+		// Format both sums with the same number of decimals
+		expectedSum := withMinScale(*summary.Sum, sum.Scale())
+		sum = withMinScale(sum, expectedSum.Scale())
+		check.Expected = fmt.Sprintf("%d entries, sum %s", summary.NumberOfEntries, expectedSum)
+		check.Actual = fmt.Sprintf("%d entries, sum %s", count, sum)
+	}
+
+	if count != summary.NumberOfEntries || (summary.Sum != nil && sum.Cmp(*summary.Sum) != 0) {
+		check.Status = RECONCILIATION_FAILED
+		check.Message = fmt.Sprintf("%s is %s but the statement has %s", name, check.Expected, check.Actual)
+		return check
+	}
+	check.Status = RECONCILIATION_OK
+	return check
+}
+
+// reconcileNetAmount checks that the net amount of the transaction summary equals credits minus debits.
+func reconcileNetAmount(summary *camt053.TotalEntries, totals entryTotals) ReconciliationCheck {
+	check := ReconciliationCheck{Name: "totalNetEntryAmount"}
+
+	expected := *summary.TotalNetEntryAmount
+	if summary.CreditDebitIndicator == "DBIT" {
+		expected = expected.Neg()
+	}
+	actual := camt053.NewDecimal(0, expected.Scale()).Add(totals.net)
+	expected = withMinScale(expected, actual.Scale())
+
+	check.Expected, check.Actual = expected.String(), actual.String()
+	if expected.Cmp(actual) != 0 {
+		check.Status = RECONCILIATION_FAILED
+		check.Message = fmt.Sprintf("totalNetEntryAmount is %s but the entries sum to %s", check.Expected, check.Actual)
+		return check
+	}
+	check.Status = RECONCILIATION_OK
+	return check
+}
+
+// totalEntriesSummary returns the number and sum of all entries in the transaction summary, if there are any.
+func totalEntriesSummary(totalEntries *camt053.TotalEntries) *camt053.CreditDebitEntry {
+	if totalEntries == nil {
+		return nil
+	}
+	return &camt053.CreditDebitEntry{NumberOfEntries: totalEntries.NumberOfEntries, Sum: totalEntries.Sum}
+}
+
+// findBalance returns the first balance of a type.
+func findBalance(balances []camt053.Balance, balanceType string) *camt053.Balance {
+	for i := range balances {
+		if balances[i].TypeCode() == balanceType {
+			return &balances[i]
+		}
+	}
+	return nil
+}
+
+// withMinScale increases the number of decimals of a decimal to at least scale.
+func withMinScale(d camt053.Decimal, scale int) camt053.Decimal {
+	if d.Scale() >= scale {
+		return d
+	}
+	scaled, _ := d.Rescale(scale) // Increasing the scale can not fail
+	return scaled
+}
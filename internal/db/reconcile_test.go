@@ -0,0 +1,113 @@
+// package db is a local mock database.
+package db
+
+import (
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkStatuses maps the name of each reconciliation check to its status.
+func checkStatuses(reconciliation Reconciliation) map[string]string {
+	statuses := make(map[string]string)
+	for _, check := range reconciliation.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+// TestReconcile checks that statements are reconciled against their balances and transaction summary.
+func TestReconcile(t *testing.T) {
+	closingBalance := func(stmt *camt053.Statement) *camt053.Balance {
+		return findBalance(stmt.Balances, "CLBD")
+	}
+
+	// Declare Tests
+	tests := []struct {
+		name             string
+		modify           func(stmt *camt053.Statement)
+		expectedStatus   string
+		expectedStatuses map[string]string
+	}{
+		{"Mock data", func(stmt *camt053.Statement) {}, RECONCILIATION_OK,
+			map[string]string{"balance": RECONCILIATION_OK, "totalCreditEntries": RECONCILIATION_OK, "totalDebitEntries": RECONCILIATION_OK}},
+		{"Wrong closing balance", func(stmt *camt053.Statement) { closingBalance(stmt).Amount.Value = camt053.NewDecimal(440832032, 2) }, RECONCILIATION_FAILED,
+			map[string]string{"balance": RECONCILIATION_FAILED, "totalCreditEntries": RECONCILIATION_OK}},
+		{"Closing balance sign", func(stmt *camt053.Statement) { closingBalance(stmt).CreditDebitIndicator = "DBIT" }, RECONCILIATION_FAILED,
+			map[string]string{"balance": RECONCILIATION_FAILED}},
+		{"Missing entry", func(stmt *camt053.Statement) { *stmt.Entries = (*stmt.Entries)[1:] }, RECONCILIATION_FAILED,
+			map[string]string{"balance": RECONCILIATION_FAILED}},
+		{"Pending entry", func(stmt *camt053.Statement) { (*stmt.Entries)[0].Status = "PDNG" }, RECONCILIATION_FAILED,
+			map[string]string{"balance": RECONCILIATION_FAILED, "totalCreditEntries": RECONCILIATION_OK, "totalDebitEntries": RECONCILIATION_OK}},
+		{"Wrong summary count", func(stmt *camt053.Statement) { stmt.TransactionSummary.TotalDebitEntries.NumberOfEntries++ }, RECONCILIATION_FAILED,
+			map[string]string{"balance": RECONCILIATION_OK, "totalDebitEntries": RECONCILIATION_FAILED}},
+		{"Without summary", func(stmt *camt053.Statement) { stmt.TransactionSummary = nil }, RECONCILIATION_OK,
+			map[string]string{"balance": RECONCILIATION_OK, "transactionSummary": RECONCILIATION_SKIPPED}},
+		{"Without opening balance", func(stmt *camt053.Statement) { stmt.Balances = stmt.Balances[1:] }, RECONCILIATION_OK,
+			map[string]string{"balance": RECONCILIATION_SKIPPED}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt := loadTestDocument(t).BankStatement.Statements[0]
+			test.modify(&stmt)
+
+			reconciliation := Reconcile(stmt)
+			assert.Equal(t, test.expectedStatus, reconciliation.Status, reconciliation.String())
+			statuses := checkStatuses(reconciliation)
+			for name, status := range test.expectedStatuses {
+				assert.Equal(t, status, statuses[name], name)
+			}
+		})
+	}
+}
+
+// TestLoadCamt053Reconciliation checks that statements which do not reconcile are loaded with a warning,
+// or rejected in strict mode.
+func TestLoadCamt053Reconciliation(t *testing.T) {
+	doc := loadTestDocument(t)
+	stmt := &doc.BankStatement.Statements[0]
+	findBalance(stmt.Balances, "CLBD").Amount.Value = camt053.NewDecimal(100, 2)
+
+	// Warn mode
+	db := NewBankData()
+	summary, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Len(t, summary.Warnings, 1)
+
+	reconciliation, err := db.GetStatementReconciliation(testAccountId, stmt.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, RECONCILIATION_FAILED, reconciliation.Status)
+	assert.Equal(t, "4408320.31", checkByName(reconciliation, "balance").Expected)
+	assert.Equal(t, "1.00", checkByName(reconciliation, "balance").Actual)
+
+	// Strict mode
+	t.Setenv("RECONCILIATION_MODE", RECONCILIATION_MODE_STRICT)
+	db = NewBankData()
+	_, err = db.LoadCamt053(doc)
+	assert.ErrorContains(t, err, "does not reconcile")
+	assert.Empty(t, db.Accounts)
+
+	// Statements that reconcile are loaded in strict mode
+	_, err = db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	reconciliation, err = db.GetStatementReconciliation(testAccountId, stmt.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, RECONCILIATION_OK, reconciliation.Status)
+
+	// Unknown modes are rejected
+	t.Setenv("RECONCILIATION_MODE", "lenient")
+	_, err = db.LoadCamt053(loadTestDocument(t))
+	assert.Error(t, err)
+}
+
+func checkByName(reconciliation *Reconciliation, name string) ReconciliationCheck {
+	for _, check := range reconciliation.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return ReconciliationCheck{}
+}
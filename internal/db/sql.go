@@ -0,0 +1,390 @@
+// package db is a local mock database.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// sqlDialect describes how a SQL database differs from the others. The queries are written with ? placeholders
+// and otherwise in SQL that every database understands.
+type sqlDialect struct {
+	name                 string
+	migrations           fs.FS         // Migration files, applied in the order of their names
+	numberedPlaceholders bool          // Placeholders are written $1, $2 and so on rather than ?
+	lock                 string        // Statement run first in every write transaction, if any
+	readOptions          sql.TxOptions // Options of read transactions, which need to read from a single snapshot
+	entry                string        // Expression selecting the entries of the transactions table
+}
+
+// rebind rewrites the ? placeholders of a query in the placeholder style of the dialect.
+// Placeholders are not allowed in string literals, which are left as they are.
+func (d *sqlDialect) rebind(query string) string {
+	if !d.numberedPlaceholders {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	quoted := false
+	for _, char := range query {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case char == '?' && !quoted:
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+// sqlTx is a transaction that rewrites its queries for the dialect of the database.
+type sqlTx struct {
+	tx      *sql.Tx
+	dialect *sqlDialect
+}
+
+func (t sqlTx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.tx.Exec(t.dialect.rebind(query), args...)
+}
+
+func (t sqlTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.tx.Query(t.dialect.rebind(query), args...)
+}
+
+func (t sqlTx) QueryRow(query string, args ...any) *sql.Row {
+	return t.tx.QueryRow(t.dialect.rebind(query), args...)
+}
+
+// entry returns the expression that selects the entries of the transactions table.
+func (t sqlTx) entry() string {
+	return t.dialect.entry
+}
+
+// sqlData is a database stored in a SQL database, the SQLite and PostgreSQL databases embed it
+// to implement IDataBase. Documents are loaded in a single transaction, so readers see either none or all of
+// a document, the same way as with BankData. Every read runs in a read transaction and returns data that the caller owns.
+type sqlData struct {
+	db      *sql.DB
+	dialect sqlDialect
+	mu      sync.Mutex // Loads wait for each other here rather than for the write lock of the database
+}
+
+// openSQL opens a database with a database/sql driver and migrates it to the latest schema.
+func openSQL(driverName string, dataSourceName string, dialect sqlDialect) (*sqlData, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	data := &sqlData{db: db, dialect: dialect}
+	if err := data.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to migrate the %s database: %w", dialect.name, err)
+	}
+	return data, nil
+}
+
+// Close closes the connections to the database.
+func (s *sqlData) Close() error {
+	return s.db.Close()
+}
+
+// transaction runs fn in a write transaction, which is committed if fn succeeds and rolled back otherwise.
+func (s *sqlData) transaction(fn func(tx sqlTx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lock != "" {
+		if _, err := tx.Exec(s.dialect.lock); err != nil {
+			return err
+		}
+	}
+	if err := fn(sqlTx{tx: tx, dialect: &s.dialect}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrate applies the migrations of the dialect that have not been applied yet, in the order of their file names.
+// Each migration is applied in a transaction together with recording it in schema_migrations.
+func (s *sqlData) migrate() error {
+	err := s.transaction(func(tx sqlTx) error {
+		_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT PRIMARY KEY,
+			applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(s.dialect.migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		migration, err := fs.ReadFile(s.dialect.migrations, name)
+		if err != nil {
+			return err
+		}
+		if err := s.applyMigration(name, string(migration)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration applies a single migration unless it has already been applied.
+// Migrations that need Go, such as recomputing a column with the code that writes it, run their migration step
+// after the SQL of their file, which documents the step. See migrationSteps.
+func (s *sqlData) applyMigration(version string, migration string) error {
+	return s.transaction(func(tx sqlTx) error {
+		var applied int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			return nil
+		}
+		if _, err := tx.tx.Exec(migration); err != nil { // Migrations are run as they are written
+			return err
+		}
+		if step, ok := migrationSteps[migrationName(version)]; ok {
+			if err := step(tx); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version)
+		return err
+	})
+}
+
+// migrationSteps are the parts of migrations that are written in Go, by the name of the migration file without its number,
+// so that the same step can be part of a migration of every dialect.
+var migrationSteps = map[string]func(tx sqlTx) error{
+	"recompute_date_sort_keys": recomputeDateSortKeys,
+}
+
+// migrationName returns the name of a migration file without its number and extension, e.g. create_tables for 0001_create_tables.sql.
+func migrationName(version string) string {
+	_, name, _ := strings.Cut(strings.TrimSuffix(version, ".sql"), "_")
+	return name
+}
+
+// recomputeDateSortKeys writes the booking and value dates of every transaction again with dateSortKey,
+// for transactions that were stored with an earlier format of the keys.
+func recomputeDateSortKeys(tx sqlTx) error {
+	type dates struct {
+		id          int64
+		BookingDate *camt053.DateAndDateTime `json:"bookingDate"`
+		ValueDate   *camt053.DateAndDateTime `json:"valueDate"`
+	}
+
+	// The rows are read before they are updated, since a connection can not run a query while reading rows
+	rows, err := tx.Query(`SELECT id, entry FROM transactions`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	transactions := make([]dates, 0)
+	for rows.Next() {
+		var id int64
+		var entry []byte
+		if err := rows.Scan(&id, &entry); err != nil {
+			return err
+		}
+		transaction := dates{id: id}
+		if err := json.Unmarshal(entry, &transaction); err != nil {
+			return fmt.Errorf("transaction %d: %w", id, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, transaction := range transactions {
+		_, err := tx.Exec(`UPDATE transactions SET booking_date = ?, value_date = ? WHERE id = ?`,
+			dateSortKey(transaction.BookingDate), dateSortKey(transaction.ValueDate), transaction.id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write runs fn in a write transaction after the loads that are already running.
+func (s *sqlData) write(fn func(l sqlLoader) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.transaction(func(tx sqlTx) error {
+		return fn(sqlLoader{tx: tx})
+	})
+}
+
+// CreateAccount creates an account in the database and returns it.
+func (s *sqlData) CreateAccount(camtAcc *camt053.Account) (*Account, error) {
+	err := s.write(func(l sqlLoader) error {
+		if camtAcc.GetId() == "" {
+			return errors.New("trying to create account without an account id")
+		}
+		account, err := l.accountDetails(camtAcc.GetId())
+		if err != nil {
+			return err
+		}
+		if account != nil {
+			return errors.New("trying to create account that already exists")
+		}
+		return l.insertAccount(*camtAcc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccount(camtAcc.GetId())
+}
+
+// LoadCamt053 loads every statement of an unmarshaled camt053 document into the database, see BankData.LoadCamt053.
+// Documents that fail to load are rolled back as a whole.
+func (s *sqlData) LoadCamt053(data camt053.Document) (LoadSummary, error) {
+	summary := LoadSummary{AccountsCreated: make([]string, 0)}
+
+	// Documents with structural violations are rejected as a whole
+	if err := camt053.ValidateDocument(data); err != nil {
+		return summary, err
+	}
+
+	err := s.write(func(l sqlLoader) error {
+		return loadDocument(l, data, &summary)
+	})
+	return summary, err
+}
+
+// LoadCamt053File streams a camt053, camt052 or camt054 XML file into the database, see BankData.LoadCamt053File.
+func (s *sqlData) LoadCamt053File(path string) (LoadSummary, error) {
+	summary := LoadSummary{AccountsCreated: make([]string, 0)}
+
+	reconciliations, err := checkCamt053File(path)
+	if err != nil {
+		return summary, err
+	}
+
+	err = s.write(func(l sqlLoader) error {
+		return streamFile(path, loadHandler(l, reconciliations, &summary))
+	})
+	return summary, err
+}
+
+// View calls fn with a view of the database in a read transaction, so every read through the view sees the same state.
+// fn must only read through the view, see IDataBase.
+func (s *sqlData) View(fn func(view IDataReader) error) error {
+	tx, err := s.db.BeginTx(context.Background(), &s.dialect.readOptions)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return fn(sqlReader{tx: sqlTx{tx: tx, dialect: &s.dialect}})
+}
+
+// read runs a single read in a read transaction, so that e.g. a page and its total count are consistent.
+func read[T any](s *sqlData, fn func(r sqlReader) (T, error)) (T, error) {
+	var result T
+	err := s.View(func(view IDataReader) error {
+		var err error
+		result, err = fn(view.(sqlReader))
+		return err
+	})
+	return result, err
+}
+
+// AccountExists checks if an account exists in the database.
+func (s *sqlData) AccountExists(accountId string) bool {
+	exists, err := read(s, func(r sqlReader) (bool, error) {
+		return r.AccountExists(accountId), nil
+	})
+	return err == nil && exists
+}
+
+// GetAccounts gets a page of the accounts in the database, sorted by account id.
+func (s *sqlData) GetAccounts(query AccountQuery) (*AccountsResponse, error) {
+	return read(s, func(r sqlReader) (*AccountsResponse, error) {
+		return r.GetAccounts(query)
+	})
+}
+
+// GetAccount gets the details and balances of a specific account from the database, see BankData.GetAccount.
+func (s *sqlData) GetAccount(accountId string) (*Account, error) {
+	return read(s, func(r sqlReader) (*Account, error) {
+		return r.GetAccount(accountId)
+	})
+}
+
+// GetAccountTransactions gets a page of an accounts transactions from the database, filtered and sorted by the query.
+func (s *sqlData) GetAccountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error) {
+	return read(s, func(r sqlReader) (*TransactionsResponse, error) {
+		return r.GetAccountTransactions(accountId, query)
+	})
+}
+
+// ExportAccountTransactions gets every transaction of an account that matches the query, sorted by the query.
+func (s *sqlData) ExportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error) {
+	return read(s, func(r sqlReader) ([]*camt053.Entry, error) {
+		return r.ExportAccountTransactions(accountId, query)
+	})
+}
+
+// GetAccountTransaction gets a specific transaction for an account from the database.
+func (s *sqlData) GetAccountTransaction(accountId string, transactionRef string) (*camt053.Entry, error) {
+	return read(s, func(r sqlReader) (*camt053.Entry, error) {
+		return r.GetAccountTransaction(accountId, transactionRef)
+	})
+}
+
+// GetAccountBalances gets an accounts balances sorted by date and type, filtered by the query.
+func (s *sqlData) GetAccountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error) {
+	return read(s, func(r sqlReader) (*BalancesResponse, error) {
+		return r.GetAccountBalances(accountId, query)
+	})
+}
+
+// GetAccountStatements gets the statements loaded into an account ordered by statement period and sequence number.
+func (s *sqlData) GetAccountStatements(accountId string) (*StatementsResponse, error) {
+	return read(s, func(r sqlReader) (*StatementsResponse, error) {
+		return r.GetAccountStatements(accountId)
+	})
+}
+
+// GetAccountStatement gets a specific statement for an account from the database.
+func (s *sqlData) GetAccountStatement(accountId string, statementId string) (*Statement, error) {
+	return read(s, func(r sqlReader) (*Statement, error) {
+		return r.GetAccountStatement(accountId, statementId)
+	})
+}
+
+// GetStatementReconciliation gets the reconciliation of a specific account statement from the database.
+func (s *sqlData) GetStatementReconciliation(accountId string, statementId string) (*Reconciliation, error) {
+	return read(s, func(r sqlReader) (*Reconciliation, error) {
+		return r.GetStatementReconciliation(accountId, statementId)
+	})
+}
@@ -0,0 +1,165 @@
+// package db is a local mock database.
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// sqlLoader loads documents into a SQL database within a write transaction.
+type sqlLoader struct {
+	tx sqlTx
+}
+
+var _ loader = sqlLoader{}
+
+func (l sqlLoader) accountDetails(accountId string) (*camt053.Account, error) {
+	var account camt053.Account
+	if err := scanJSON(l.tx.QueryRow(`SELECT account FROM accounts WHERE id = ?`, accountId), &account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (l sqlLoader) insertAccount(account camt053.Account) error {
+	_, err := l.tx.Exec(`INSERT INTO accounts (id, account) VALUES (?, ?)`, account.GetId(), jsonColumn{account})
+	return err
+}
+
+func (l sqlLoader) updateAccount(account camt053.Account) error {
+	_, err := l.tx.Exec(`UPDATE accounts SET account = ? WHERE id = ?`, jsonColumn{account}, account.GetId())
+	return err
+}
+
+func (l sqlLoader) saveBalance(accountId string, balance camt053.Balance) error {
+	_, err := l.tx.Exec(`INSERT INTO balances (account_id, balance_key, date, balance) VALUES (?, ?, ?, ?)
+		ON CONFLICT (account_id, balance_key) DO UPDATE SET balance = excluded.balance`,
+		accountId, balanceKey(balance), balance.GetDate().String(), jsonColumn{balance})
+	return err
+}
+
+func (l sqlLoader) saveStatement(accountId string, statement Statement) error {
+	statement.TransactionRefs = nil // Stored in statement_transactions
+	_, err := l.tx.Exec(`INSERT INTO statements (account_id, id, statement) VALUES (?, ?, ?)
+		ON CONFLICT (account_id, id) DO UPDATE SET statement = excluded.statement`,
+		accountId, statement.Id, jsonColumn{statement})
+	return err
+}
+
+func (l sqlLoader) addStatementTransaction(accountId string, statementId string, ref string) error {
+	_, err := l.tx.Exec(`INSERT INTO statement_transactions (account_id, statement_id, transaction_ref) VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING`, accountId, statementId, ref)
+	return err
+}
+
+func (l sqlLoader) saveReconciliation(accountId string, statementId string, reconciliation *Reconciliation) error {
+	_, err := l.tx.Exec(`UPDATE statements SET reconciliation = ? WHERE account_id = ? AND id = ?`,
+		jsonColumn{reconciliation}, accountId, statementId)
+	return err
+}
+
+func (l sqlLoader) transaction(accountId string, ref string) (*camt053.Entry, error) {
+	var entry camt053.Entry
+	if err := scanJSON(l.tx.QueryRow(`SELECT `+l.tx.entry()+` FROM transactions WHERE account_id = ? AND ref = ?`, accountId, ref), &entry); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (l sqlLoader) saveTransaction(accountId string, entry camt053.Entry) error {
+	domainCode, proprietaryCode := bankTransactionCodes(entry.BankTransactionCode)
+	entryDetails := entry.EntryDetails
+	entry.EntryDetails = nil // Stored in entry_details
+	_, err := l.tx.Exec(`INSERT INTO transactions (account_id, ref, booking_date, value_date, amount, credit_debit_indicator, status,
+			domain_code, proprietary_code, reference, account_servicer_ref, entry, entry_details)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (account_id, ref) DO UPDATE SET booking_date = excluded.booking_date, value_date = excluded.value_date,
+			amount = excluded.amount, credit_debit_indicator = excluded.credit_debit_indicator, status = excluded.status,
+			domain_code = excluded.domain_code, proprietary_code = excluded.proprietary_code, reference = excluded.reference,
+			account_servicer_ref = excluded.account_servicer_ref, entry = excluded.entry, entry_details = excluded.entry_details`,
+		accountId, *entry.URLReference, dateSortKey(entry.BookingDate), dateSortKey(entry.ValueDate), amountSortKey(entry.Amount.Value),
+		entry.CreditDebitIndicator, string(entry.Status), domainCode, proprietaryCode,
+		stringOrEmpty(entry.Reference), stringOrEmpty(entry.AccountServicerRef), jsonColumn{entry}, jsonColumn{entryDetails})
+	return err
+}
+
+func (l sqlLoader) notifications(accountId string, entry camt053.Entry) ([]camt053.Entry, error) {
+	return queryEntries(l.tx, `SELECT entry FROM notifications
+		WHERE account_id = ? AND ((account_servicer_ref = ? AND account_servicer_ref <> '') OR (reference = ? AND reference <> ''))
+		ORDER BY id`, accountId, stringOrEmpty(entry.AccountServicerRef), stringOrEmpty(entry.Reference))
+}
+
+func (l sqlLoader) saveNotification(accountId string, notification camt053.Entry) error {
+	_, err := l.tx.Exec(`INSERT INTO notifications (account_id, entry_key, reference, account_servicer_ref, entry) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (account_id, entry_key) DO UPDATE SET reference = excluded.reference,
+			account_servicer_ref = excluded.account_servicer_ref, entry = excluded.entry`,
+		accountId, entryKey(notification), stringOrEmpty(notification.Reference), stringOrEmpty(notification.AccountServicerRef), jsonColumn{notification})
+	return err
+}
+
+func (l sqlLoader) deleteNotification(accountId string, notification camt053.Entry) error {
+	_, err := l.tx.Exec(`DELETE FROM notifications WHERE account_id = ? AND entry_key = ?`, accountId, entryKey(notification))
+	return err
+}
+
+// findTransaction returns the transaction that a notification entry refers to, the candidates found
+// by the indexed reference columns are matched the same way as by the in memory database.
+func (l sqlLoader) findTransaction(accountId string, notification camt053.Entry) (*camt053.Entry, error) {
+	candidates, err := queryEntries(l.tx, `SELECT `+l.tx.entry()+` FROM transactions
+		WHERE account_id = ? AND ((account_servicer_ref = ? AND account_servicer_ref <> '') OR (reference = ? AND reference <> ''))
+		ORDER BY id`, accountId, stringOrEmpty(notification.AccountServicerRef), stringOrEmpty(notification.Reference))
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if matchesNotification(candidate, notification) {
+			return &candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// bankTransactionCodes returns the upper case domain code, e.g. PMNT-ICDT-ATXN, and proprietary code that
+// transactions are filtered by, see matchesBankTransactionCode. Codes that are missing are nil.
+func bankTransactionCodes(btc camt053.BankTransactionCode) (*string, *string) {
+	var domainCode, proprietaryCode *string
+	if btc.Domain != nil {
+		code := strings.ToUpper(strings.Join([]string{btc.Domain.Code, btc.Domain.Family.Code, btc.Domain.Family.SubFamilyCode}, "-"))
+		domainCode = &code
+	}
+	if btc.ProprietaryCode != nil {
+		code := strings.ToUpper(btc.ProprietaryCode.Code)
+		proprietaryCode = &code
+	}
+	return domainCode, proprietaryCode
+}
+
+// jsonColumn stores a value as JSON, e.g. an entry together with the columns it is filtered and sorted by.
+type jsonColumn struct {
+	v any
+}
+
+func (c jsonColumn) Value() (driver.Value, error) {
+	data, err := json.Marshal(c.v)
+	return string(data), err
+}
+
+// scanJSON unmarshals the JSON column of a single row.
+func scanJSON(row *sql.Row, v any) error {
+	var data string
+	if err := row.Scan(&data); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
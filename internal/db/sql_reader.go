@@ -0,0 +1,558 @@
+// package db is a local mock database.
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// sqlReader reads from a SQL database within a read transaction, see sqlData.View.
+// The results are decoded from the database and owned by the caller.
+type sqlReader struct {
+	tx sqlTx
+}
+
+var _ IDataReader = sqlReader{}
+
+// AccountExists checks if an account exists in the database.
+func (r sqlReader) AccountExists(accountId string) bool {
+	var count int
+	err := r.tx.QueryRow(`SELECT COUNT(*) FROM accounts WHERE id = ?`, accountId).Scan(&count)
+	return err == nil && count > 0
+}
+
+// GetAccounts gets a page of the accounts in the database, sorted by account id.
+func (r sqlReader) GetAccounts(query AccountQuery) (*AccountsResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query = query.withDefaults()
+
+	// Decode the cursor, it needs to be created for an accounts query
+	var cursor *Cursor
+	if query.Cursor != "" {
+		decoded, err := DecodeCursor(query.Cursor)
+		if err != nil || decoded.Query != query.fingerprint() {
+			return nil, ErrInvalidCursor
+		}
+		cursor = &decoded
+	}
+
+	accounts := sqlList{tx: r.tx, from: `FROM accounts`, order: []string{"id"}}
+	totalCount, err := accounts.count()
+	if err != nil {
+		return nil, err
+	}
+	var boundary []any
+	if cursor != nil {
+		boundary = []any{cursor.Ref}
+	}
+	ids, start, err := accounts.page("id", query.Page, query.PerPage, boundary, cursor != nil && cursor.Before)
+	if err != nil {
+		return nil, err
+	}
+	end := start + len(ids)
+
+	response := &AccountsResponse{
+		Accounts:   make([]*Account, 0, len(ids)),
+		TotalCount: totalCount,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+	}
+	for _, id := range ids {
+		account, err := r.account(id)
+		if err != nil {
+			return nil, err
+		}
+		response.Accounts = append(response.Accounts, account)
+	}
+	if cursor != nil {
+		response.Page = 0
+	}
+
+	// Create cursors pointing at the first and last account of the page
+	if start > 0 && start < totalCount {
+		response.PrevCursor = Cursor{Ref: ids[0], Before: true, Query: query.fingerprint()}.Encode()
+	}
+	if end < totalCount && end > 0 {
+		response.NextCursor = Cursor{Ref: ids[len(ids)-1], Query: query.fingerprint()}.Encode()
+	}
+
+	return response, nil
+}
+
+// account gets the details and balances of an account.
+func (r sqlReader) account(accountId string) (*Account, error) {
+	account := &Account{Balances: make([]camt053.Balance, 0)}
+	if err := scanJSON(r.tx.QueryRow(`SELECT account FROM accounts WHERE id = ?`, accountId), &account.Account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.tx.Query(`SELECT balance FROM balances WHERE account_id = ? ORDER BY id`, accountId)
+	if err != nil {
+		return nil, err
+	}
+	account.Balances, err = scanRows(rows, account.Balances)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetAccount gets the details and balances of a specific account from the database.
+func (r sqlReader) GetAccount(accountId string) (*Account, error) {
+	return r.account(accountId)
+}
+
+// transactionList is the list of an accounts transactions that match the query, sorted by the query.
+func (r sqlReader) transactionList(accountId string, query TransactionQuery) sqlList {
+	conditions := []string{"account_id = ?"}
+	args := []any{accountId}
+
+	if query.CreditDebitIndicator != "" {
+		conditions = append(conditions, "credit_debit_indicator = ?")
+		args = append(args, query.CreditDebitIndicator)
+	}
+	if query.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, query.Status)
+	}
+	if query.FromDate != nil || query.ToDate != nil {
+		conditions = append(conditions, "booking_date <> ''") // Entries without a booking date are not in any date range
+	}
+	if query.FromDate != nil {
+		conditions = append(conditions, "booking_date >= ?")
+		args = append(args, query.FromDate.String())
+	}
+	if query.ToDate != nil {
+		// Date times on the last day sort after the date, so compare with the day after it
+		dayAfter := camt053.NewDate(query.ToDate.Time().AddDate(0, 0, 1).Date())
+		conditions = append(conditions, "booking_date < ?")
+		args = append(args, dayAfter.String())
+	}
+	if query.MinAmount != nil {
+		conditions = append(conditions, "amount >= ?")
+		args = append(args, amountSortKey(*query.MinAmount))
+	}
+	if query.MaxAmount != nil {
+		conditions = append(conditions, "amount <= ?")
+		args = append(args, amountSortKey(*query.MaxAmount))
+	}
+	if query.BankTransactionCode != "" {
+		code := strings.ToUpper(query.BankTransactionCode)
+		conditions = append(conditions, "(domain_code = ? OR substr(domain_code, 1, ?) = ? OR proprietary_code = ?)")
+		args = append(args, code, len(code)+1, code+"-", code)
+	}
+
+	sortColumn := "booking_date"
+	switch query.SortBy {
+	case SORT_AMOUNT:
+		sortColumn = "amount"
+	case SORT_VALUE_DATE:
+		sortColumn = "value_date"
+	}
+
+	return sqlList{
+		tx:         r.tx,
+		from:       "FROM transactions WHERE " + strings.Join(conditions, " AND "),
+		args:       args,
+		order:      []string{sortColumn, "ref"},
+		descending: query.Descending,
+	}
+}
+
+// GetAccountTransactions gets a page of an accounts transactions from the database, filtered and sorted by the query.
+func (r sqlReader) GetAccountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query = query.withDefaults()
+
+	if !r.AccountExists(accountId) {
+		return nil, ErrAccountNotFound
+	}
+
+	// Decode the cursor, it needs to be created for the same account and query
+	var cursor *Cursor
+	if query.Cursor != "" {
+		decoded, err := DecodeCursor(query.Cursor)
+		if err != nil || decoded.Query != query.fingerprint(accountId) {
+			return nil, ErrInvalidCursor
+		}
+		cursor = &decoded
+	}
+
+	transactions := r.transactionList(accountId, query)
+	totalCount, err := transactions.count()
+	if err != nil {
+		return nil, err
+	}
+
+	// Select the requested page
+	var boundary []any
+	if cursor != nil {
+		boundary = []any{query.cursorKey(*cursor), cursor.Ref}
+	}
+	values, start, err := transactions.page(r.tx.entry(), query.Page, query.PerPage, boundary, cursor != nil && cursor.Before)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decodeEntries(values)
+	if err != nil {
+		return nil, err
+	}
+	end := start + len(entries)
+
+	response := &TransactionsResponse{
+		Transactions: entries,
+		TotalCount:   totalCount,
+		Page:         query.Page,
+		PerPage:      query.PerPage,
+		TotalPages:   (totalCount + query.PerPage - 1) / query.PerPage,
+	}
+	if cursor != nil {
+		response.Page = 0
+	}
+
+	// Create cursors pointing at the first and last transaction of the page
+	fingerprint := query.fingerprint(accountId)
+	if start > 0 && start < totalCount {
+		first := entries[0]
+		response.PrevCursor = Cursor{Key: query.sortValue(first), Ref: *first.URLReference, Before: true, Query: fingerprint}.Encode()
+	}
+	if end < totalCount && end > 0 {
+		last := entries[len(entries)-1]
+		response.NextCursor = Cursor{Key: query.sortValue(last), Ref: *last.URLReference, Query: fingerprint}.Encode()
+	}
+
+	return response, nil
+}
+
+// ExportAccountTransactions gets every transaction of an account that matches the query, sorted by the query.
+// Exports are not paginated, the page and cursor of the query are not allowed.
+func (r sqlReader) ExportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.Page != 0 || query.Cursor != "" {
+		return nil, errors.New("exports contain every matching transaction and can not be paginated")
+	}
+	query = query.withDefaults()
+
+	if !r.AccountExists(accountId) {
+		return nil, ErrAccountNotFound
+	}
+	values, err := r.transactionList(accountId, query).all(r.tx.entry())
+	if err != nil {
+		return nil, err
+	}
+	return decodeEntries(values)
+}
+
+// GetAccountTransaction gets a specific transaction for an account from the database.
+func (r sqlReader) GetAccountTransaction(accountId string, transactionRef string) (*camt053.Entry, error) {
+	if !r.AccountExists(accountId) {
+		return nil, errors.New("unable to fetch account data")
+	}
+
+	var entry camt053.Entry
+	if err := scanJSON(r.tx.QueryRow(`SELECT `+r.tx.entry()+` FROM transactions WHERE account_id = ? AND ref = ?`, accountId, transactionRef), &entry); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetAccountBalances gets an accounts balances sorted by date and type, filtered by the query.
+// The date filters select the balances in the database, the types and AsOf are applied the same way as by BankData.
+func (r sqlReader) GetAccountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if !r.AccountExists(accountId) {
+		return nil, ErrAccountNotFound
+	}
+
+	conditions := []string{"account_id = ?"}
+	args := []any{accountId}
+	for _, filter := range []struct {
+		condition string
+		date      *camt053.Date
+	}{{"date >= ?", query.FromDate}, {"date <= ?", query.ToDate}, {"date <= ?", query.AsOf}} {
+		if filter.date != nil {
+			conditions = append(conditions, filter.condition)
+			args = append(args, filter.date.String())
+		}
+	}
+
+	rows, err := r.tx.Query(`SELECT balance FROM balances WHERE `+strings.Join(conditions, " AND ")+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := scanRows(rows, make([]camt053.Balance, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	balances = query.selectBalances(balances)
+	return &BalancesResponse{Balances: balances, TotalCount: len(balances)}, nil
+}
+
+// statements gets the statements of an account, or the statement with the id if one is given, with their transaction refs and reconciliations.
+func (r sqlReader) statements(accountId string, statementIds ...string) ([]*Statement, error) {
+	statementsQuery := `SELECT statement, reconciliation FROM statements WHERE account_id = ?`
+	refsQuery := `SELECT statement_id, transaction_ref FROM statement_transactions WHERE account_id = ?`
+	args := []any{accountId}
+	if len(statementIds) > 0 {
+		statementsQuery += ` AND id = ?`
+		refsQuery += ` AND statement_id = ?`
+		args = append(args, statementIds[0])
+	}
+
+	rows, err := r.tx.Query(statementsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statements := make([]*Statement, 0)
+	byId := make(map[string]*Statement)
+	for rows.Next() {
+		var data string
+		var reconciliation sql.NullString
+		if err := rows.Scan(&data, &reconciliation); err != nil {
+			return nil, err
+		}
+		statement := &Statement{}
+		if err := json.Unmarshal([]byte(data), statement); err != nil {
+			return nil, err
+		}
+		if reconciliation.Valid {
+			statement.Reconciliation = &Reconciliation{}
+			if err := json.Unmarshal([]byte(reconciliation.String), statement.Reconciliation); err != nil {
+				return nil, err
+			}
+		}
+		statement.TransactionRefs = make([]string, 0)
+		statements = append(statements, statement)
+		byId[statement.Id] = statement
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	refs, err := r.tx.Query(refsQuery+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+	for refs.Next() {
+		var statementId, ref string
+		if err := refs.Scan(&statementId, &ref); err != nil {
+			return nil, err
+		}
+		if statement, ok := byId[statementId]; ok {
+			statement.TransactionRefs = append(statement.TransactionRefs, ref)
+		}
+	}
+	return statements, refs.Err()
+}
+
+// GetAccountStatements gets the statements loaded into an account ordered by statement period and sequence number.
+func (r sqlReader) GetAccountStatements(accountId string) (*StatementsResponse, error) {
+	if !r.AccountExists(accountId) {
+		return nil, ErrAccountNotFound
+	}
+
+	statements, err := r.statements(accountId)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].less(statements[j])
+	})
+
+	return &StatementsResponse{
+		Statements: statements,
+		TotalCount: len(statements),
+	}, nil
+}
+
+// GetAccountStatement gets a specific statement for an account from the database.
+func (r sqlReader) GetAccountStatement(accountId string, statementId string) (*Statement, error) {
+	if !r.AccountExists(accountId) {
+		return nil, errors.New("unable to fetch account data")
+	}
+
+	statements, err := r.statements(accountId, statementId)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("statement not found")
+	}
+	return statements[0], nil
+}
+
+// GetStatementReconciliation gets the reconciliation of a specific account statement from the database.
+func (r sqlReader) GetStatementReconciliation(accountId string, statementId string) (*Reconciliation, error) {
+	statement, err := r.GetAccountStatement(accountId, statementId)
+	if err != nil {
+		return nil, err
+	}
+	if statement.Reconciliation == nil {
+		return nil, errors.New("statement has not been reconciled")
+	}
+	return statement.Reconciliation, nil
+}
+
+// sqlList is a sorted list of rows in a SQL database, which is paged through the same way as lists in memory, see pageWindow.
+type sqlList struct {
+	tx         sqlTx
+	from       string   // FROM and WHERE clauses selecting the rows of the list
+	args       []any    // Arguments of the WHERE clause
+	order      []string // Columns the rows are sorted by, the values of the last one are unique
+	descending bool
+}
+
+// where adds a condition to the WHERE clause of the list.
+func (l sqlList) where(condition string) string {
+	if strings.Contains(l.from, " WHERE ") {
+		return l.from + " AND " + condition
+	}
+	return l.from + " WHERE " + condition
+}
+
+// orderBy returns the ORDER BY clause of the list, or of the list in reverse.
+func (l sqlList) orderBy(reverse bool) string {
+	direction := "ASC"
+	if l.descending != reverse {
+		direction = "DESC"
+	}
+	columns := make([]string, len(l.order))
+	for i, column := range l.order {
+		columns[i] = column + " " + direction
+	}
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
+
+// compareTo returns a condition selecting the rows before or after the row with the values of the order columns in boundary.
+// orEqual includes the boundary row.
+func (l sqlList) compareTo(before bool, orEqual bool) string {
+	operator := ">"
+	if before != l.descending {
+		operator = "<"
+	}
+	if orEqual {
+		operator += "="
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(l.order)), ", ")
+	return "(" + strings.Join(l.order, ", ") + ") " + operator + " (" + placeholders + ")"
+}
+
+// count counts the rows of the list.
+func (l sqlList) count() (int, error) {
+	var count int
+	err := l.tx.QueryRow("SELECT COUNT(*) "+l.from, l.args...).Scan(&count)
+	return count, err
+}
+
+// page selects a column of the rows in a page of the list, either by page number or after or before the boundary
+// row of a cursor, and returns the index of the first row of the page in the list.
+func (l sqlList) page(column string, page int, perPage int, boundary []any, before bool) ([]string, int, error) {
+	args := slices.Concat(l.args, boundary)
+
+	if boundary == nil {
+		start := (page - 1) * perPage
+		values, err := l.query("SELECT "+column+" "+l.from+l.orderBy(false)+" LIMIT ? OFFSET ?", slices.Concat(l.args, []any{perPage, start})...)
+		return values, start, err
+	}
+
+	// Rows before the boundary are selected in reverse, so that the limit keeps the rows closest to it
+	var position int
+	if err := l.tx.QueryRow("SELECT COUNT(*) "+l.where(l.compareTo(true, !before)), args...).Scan(&position); err != nil {
+		return nil, 0, err
+	}
+	values, err := l.query("SELECT "+column+" "+l.where(l.compareTo(before, false))+l.orderBy(before)+" LIMIT ?", append(args, perPage)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if before {
+		slices.Reverse(values)
+		return values, position - len(values), nil
+	}
+	return values, position, nil
+}
+
+// all selects a column of every row in the list.
+func (l sqlList) all(column string) ([]string, error) {
+	return l.query("SELECT "+column+" "+l.from+l.orderBy(false), l.args...)
+}
+
+func (l sqlList) query(query string, args ...any) ([]string, error) {
+	rows, err := l.tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// queryEntries selects entries stored as JSON.
+func queryEntries(tx sqlTx, query string, args ...any) ([]camt053.Entry, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, make([]camt053.Entry, 0))
+}
+
+// decodeEntries decodes entries stored as JSON.
+func decodeEntries(values []string) ([]*camt053.Entry, error) {
+	entries := make([]*camt053.Entry, len(values))
+	for i, value := range values {
+		entries[i] = &camt053.Entry{}
+		if err := json.Unmarshal([]byte(value), entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// scanRows appends the values of rows with a single JSON column to a slice and closes the rows.
+func scanRows[T any](rows *sql.Rows, values []T) ([]T, error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var value T
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
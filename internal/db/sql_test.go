@@ -0,0 +1,33 @@
+// package db is a local mock database.
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRebind checks that ? placeholders are rewritten in the placeholder style of the dialect.
+func TestRebind(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name     string
+		dialect  sqlDialect
+		query    string
+		expected string
+	}{
+		{"SQLite", sqliteDialect(), "SELECT entry FROM transactions WHERE account_id = ? AND ref = ?", "SELECT entry FROM transactions WHERE account_id = ? AND ref = ?"},
+		{"PostgreSQL", postgresDialect(), "SELECT entry FROM transactions WHERE account_id = ? AND ref = ?", "SELECT entry FROM transactions WHERE account_id = $1 AND ref = $2"},
+		{"Row values", postgresDialect(), "(amount, ref) > (?, ?) LIMIT ? OFFSET ?", "(amount, ref) > ($1, $2) LIMIT $3 OFFSET $4"},
+		{"String literal", postgresDialect(), "booking_date <> '' AND path = '$.a ? (@ != null)' AND ref = ?", "booking_date <> '' AND path = '$.a ? (@ != null)' AND ref = $1"},
+		{"No placeholders", postgresDialect(), "SELECT COUNT(*) FROM accounts", "SELECT COUNT(*) FROM accounts"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.dialect.rebind(test.query))
+		})
+	}
+}
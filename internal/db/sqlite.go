@@ -0,0 +1,52 @@
+// package db is a local mock database.
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver, no cgo or external service needed
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// How long a connection waits for another process that is writing to the database file, in milliseconds.
+const SQLITE_BUSY_TIMEOUT = 10000
+
+// SQLiteData is a database stored in a SQLite file. Implements IDataBase
+type SQLiteData struct {
+	*sqlData
+}
+
+var _ IDataBase = &SQLiteData{}
+
+// sqliteDialect returns the dialect of SQLite databases.
+func sqliteDialect() sqlDialect {
+	migrations, _ := fs.Sub(sqliteMigrations, "migrations/sqlite")
+	return sqlDialect{
+		name:        "SQLite",
+		migrations:  migrations,
+		readOptions: sql.TxOptions{ReadOnly: true}, // Read transactions take a snapshot when they first read
+		entry:       "json_set(entry, '$.entryDetails', json(entry_details))",
+	}
+}
+
+// OpenSQLite opens the SQLite database in a file, creating it if it does not exist,
+// and migrates it to the latest schema.
+func OpenSQLite(path string) (*SQLiteData, error) {
+	options := url.Values{}
+	options.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", SQLITE_BUSY_TIMEOUT))
+	options.Add("_pragma", "journal_mode(WAL)") // Readers do not block the writer and the writer does not block readers
+	options.Add("_pragma", "foreign_keys(1)")
+	options.Add("_txlock", "immediate") // Write transactions lock the database when they begin, read transactions when they read
+
+	data, err := openSQL("sqlite", "file:"+path+"?"+options.Encode(), sqliteDialect())
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", path, err)
+	}
+	return &SQLiteData{sqlData: data}, nil
+}
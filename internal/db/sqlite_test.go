@@ -0,0 +1,261 @@
+// package db is a local mock database.
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// openTestSQLite opens an empty SQLite database in a temporary directory.
+func openTestSQLite(t *testing.T) *SQLiteData {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "bank.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestConformanceSQLite runs the conformance suite against a SQLite database.
+func TestConformanceSQLite(t *testing.T) {
+	testConformance(t, func(t *testing.T) IDataBase {
+		return openTestSQLite(t)
+	})
+}
+
+// TestOpenSQLite checks that the data is kept when a database is opened again and that migrations are only applied once.
+func TestOpenSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	expected := NewBankData()
+	doc := loadTestDocument(t)
+	_, err := expected.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	db, err := OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	compareStores(t, &expected, db)
+
+	migrations, err := sqliteMigrations.ReadDir("migrations/sqlite")
+	assert.NoError(t, err)
+	var applied int
+	assert.NoError(t, db.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+
+	// Reloading the document only finds duplicates
+	summary, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.EntriesAdded)
+	assert.Equal(t, 7, summary.DuplicatesSkipped)
+}
+
+// TestSQLiteQueryPlan checks that transactions are filtered, sorted and paged with the indexes of the transactions table.
+func TestSQLiteQueryPlan(t *testing.T) {
+	db := openTestSQLite(t)
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		query         TransactionQuery
+		expectedIndex string
+	}{
+		{"Booking date", TransactionQuery{}, "transactions_booking_date"},
+		{"Date range", TransactionQuery{FromDate: date("2018-12-17"), ToDate: date("2018-12-18"), Descending: true}, "transactions_booking_date"},
+		{"Value date", TransactionQuery{SortBy: SORT_VALUE_DATE}, "transactions_value_date"},
+		{"Amount range", TransactionQuery{SortBy: SORT_AMOUNT, MinAmount: amount("100"), MaxAmount: amount("1000")}, "transactions_amount"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.NoError(t, db.View(func(view IDataReader) error {
+				list := view.(sqlReader).transactionList(testAccountId, test.query.withDefaults())
+				rows, err := list.tx.Query("EXPLAIN QUERY PLAN SELECT entry "+list.where(list.compareTo(false, false))+list.orderBy(false)+" LIMIT 50",
+					append(list.args, "2018-12-17", "ref")...)
+				if err != nil {
+					return err
+				}
+				defer rows.Close()
+
+				plan := make([]string, 0)
+				for rows.Next() {
+					var id, parent, notUsed int
+					var detail string
+					if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+						return err
+					}
+					plan = append(plan, detail)
+				}
+				assert.Contains(t, strings.Join(plan, "\n"), "USING INDEX "+test.expectedIndex)
+				assert.NotContains(t, strings.Join(plan, "\n"), "TEMP B-TREE", "sorted without the index")
+				return rows.Err()
+			}))
+		})
+	}
+}
+
+// TestSQLiteNotifications checks that notifications are deleted once they have been merged into the entry of a statement.
+func TestSQLiteNotifications(t *testing.T) {
+	db := openTestSQLite(t)
+	countNotifications := func() int {
+		var count int
+		assert.NoError(t, db.db.QueryRow(`SELECT COUNT(*) FROM notifications`).Scan(&count))
+		return count
+	}
+
+	_, err := db.LoadCamt053(notificationDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countNotifications())
+
+	summary, err := db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.EntriesEnriched)
+	assert.Equal(t, 0, countNotifications())
+}
+
+// TestSQLiteRecomputeDateSortKeys checks that the date sort keys of transactions stored with an earlier format are recomputed.
+func TestSQLiteRecomputeDateSortKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	expected := NewBankData()
+	doc := loadTestDocument(t)
+	entry := &(*doc.BankStatement.Statements[0].Entries)[0]
+	bookingDate := camt053.NewDateTime(time.Date(2018, 12, 17, 0, 30, 0, 0, time.FixedZone("", 3600)))
+	entry.BookingDate = &camt053.DateAndDateTime{DateTime: &bookingDate}
+	_, err := expected.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	db, err := OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	// Store the keys the way they were written before the migration, the date time as it was written in UTC
+	_, err = db.db.Exec(`UPDATE transactions SET booking_date = '2018-12-16T23:30:00Z' WHERE booking_date LIKE '% %'`)
+	assert.NoError(t, err)
+	_, err = db.db.Exec(`DELETE FROM schema_migrations WHERE version = '0003_recompute_date_sort_keys.sql'`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	var key string
+	assert.NoError(t, db.db.QueryRow(`SELECT booking_date FROM transactions WHERE booking_date LIKE '% %'`).Scan(&key))
+	assert.Equal(t, "2018-12-17 2018-12-16T23:30:00.000000000Z", key)
+	compareStores(t, &expected, db)
+}
+
+// TestSQLiteMessageIdsAsText checks that the message ids of statements stored as numbers are converted to text.
+func TestSQLiteMessageIdsAsText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	expected := NewBankData()
+	doc := loadTestDocument(t)
+	_, err := expected.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	db, err := OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	// Store the message id the way it was written before the migration
+	_, err = db.db.Exec(`UPDATE statements SET statement = json_set(statement, '$.messageId', 3131111)`)
+	assert.NoError(t, err)
+	_, err = db.db.Exec(`DELETE FROM schema_migrations WHERE version = '0004_store_message_ids_as_text.sql'`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	statements, err := db.GetAccountStatements(testAccountId)
+	if assert.NoError(t, err) && assert.Len(t, statements.Statements, 1) {
+		assert.Equal(t, "3131111", statements.Statements[0].MessageId)
+	}
+	compareStores(t, &expected, db)
+}
+
+// TestSQLiteSplitEntryDetails checks that the entry details of transactions stored within their entries are moved to a column of their own.
+func TestSQLiteSplitEntryDetails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.db")
+	expected := NewBankData()
+	doc := loadTestDocument(t)
+	_, err := expected.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	db, err := OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	// Store the details the way they were written before the migration, within the entry
+	_, err = db.db.Exec(`UPDATE transactions SET entry = json_set(entry, '$.entryDetails', json(entry_details))`)
+	assert.NoError(t, err)
+	_, err = db.db.Exec(`ALTER TABLE transactions DROP COLUMN entry_details`)
+	assert.NoError(t, err)
+	_, err = db.db.Exec(`DELETE FROM schema_migrations WHERE version = '0002_split_entry_details.sql'`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenSQLite(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	var merged, split int
+	assert.NoError(t, db.db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE json_type(entry, '$.entryDetails') IS NOT NULL`).Scan(&merged))
+	assert.NoError(t, db.db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE entry_details IS NOT NULL`).Scan(&split))
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, 7, split)
+	compareStores(t, &expected, db)
+}
+
+// TestSQLiteGetAccount checks that an account is read without reading its statements and transactions.
+func TestSQLiteGetAccount(t *testing.T) {
+	db := openTestSQLite(t)
+	_, err := db.LoadCamt053(loadTestDocument(t))
+	assert.NoError(t, err)
+
+	// Entries that can not be read only fail the reads of transactions
+	_, err = db.db.Exec(`UPDATE transactions SET entry = 'not json'`)
+	assert.NoError(t, err)
+	_, err = db.db.Exec(`UPDATE statements SET statement = 'not json'`)
+	assert.NoError(t, err)
+
+	account, err := db.GetAccount(testAccountId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, testAccountId, account.Account.GetId())
+		assert.Len(t, account.Balances, 4)
+		assert.Nil(t, account.Statements)
+		assert.Nil(t, account.Transactions)
+	}
+	_, err = db.GetAccountTransactions(testAccountId, TransactionQuery{})
+	assert.Error(t, err)
+	_, err = db.GetAccountStatements(testAccountId)
+	assert.Error(t, err)
+}
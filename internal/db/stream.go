@@ -0,0 +1,176 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/justfredrik/bank-api/internal/bai2"
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/justfredrik/bank-api/internal/mt940"
+)
+
+// LoadCamt053File loads a camt053, camt052 or camt054 XML file one entry at a time, without reading the whole
+// file into memory. The file is streamed twice, the first pass validates the document and reconciles its
+// statements so that documents are accepted or rejected as a whole, the same way as by LoadCamt053, and the
+// second pass loads the statements and their entries. The second pass holds the write lock of the database
+// until the whole file has been read, so reads wait for it, and if it fails, e.g. because the file was changed
+// after the first pass, the entries it has loaded are rolled back.
+func (db *BankData) LoadCamt053File(path string) (LoadSummary, error) {
+	summary := LoadSummary{AccountsCreated: make([]string, 0)}
+
+	reconciliations, err := checkCamt053File(path)
+	if err != nil {
+		return summary, err
+	}
+
+	err = db.loadFile(path, reconciliations, &summary)
+	return summary, err
+}
+
+// loadFile streams a checked document into the database, see LoadCamt053File.
+func (db *BankData) loadFile(path string, reconciliations []Reconciliation, summary *LoadSummary) error {
+
+	// Readers see either none or all of the document
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	changes := &journal{BankData: db}
+	if err := streamFile(path, loadHandler(changes, reconciliations, summary)); err != nil {
+		changes.rollback()
+		return err
+	}
+	return nil
+}
+
+// checkCamt053File validates a streamed document and reconciles each of its statements, like LoadCamt053
+// does before loading a document. Only the totals of the statement being read are kept in memory.
+func checkCamt053File(path string) ([]Reconciliation, error) {
+	validator := &camt053.StreamValidator{}
+	reconciliations := make([]Reconciliation, 0)
+	isNotification := false
+	var totals entryTotals
+	var unmatched error // First notification entry that can not be matched to an entry
+	entries := 0
+
+	err := streamFile(path, camt053.StreamHandler{
+		Header: func(doc camt053.Document) error {
+			validator.Header(doc)
+			isNotification = doc.IsNotification()
+			return nil
+		},
+		Statement: func(stmt camt053.Statement) error {
+			validator.Statement(stmt)
+			totals, entries = entryTotals{}, 0
+			return nil
+		},
+		Entry: func(entry camt053.Entry) error {
+			validator.Entry(entry)
+			totals.add(entry)
+			entries++
+			if isNotification && unmatched == nil && entryKey(entry) == "" {
+				unmatched = fmt.Errorf("notification %d: entry %d has neither an AcctSvcrRef nor a NtryRef", len(reconciliations)+1, entries)
+			}
+			return nil
+		},
+		EndStatement: func(stmt camt053.Statement) error {
+			reconciliations = append(reconciliations, reconcileTotals(stmt, totals))
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Documents with structural violations are rejected as a whole
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
+	if isNotification {
+		return nil, unmatched
+	}
+
+	mode, err := ReconciliationMode()
+	if err != nil {
+		return nil, err
+	}
+	for i, reconciliation := range reconciliations {
+		if reconciliation.Status == RECONCILIATION_FAILED && mode == RECONCILIATION_MODE_STRICT {
+			return nil, fmt.Errorf("statement %d: %s", i+1, reconciliation)
+		}
+	}
+	return reconciliations, nil
+}
+
+// loadHandler loads the statements of a streamed document and attaches the reconciliations found by checkCamt053File.
+func loadHandler(l loader, reconciliations []Reconciliation, summary *LoadSummary) camt053.StreamHandler {
+	var doc camt053.Document
+	var current camt053.Statement
+	statements, index := 0, 0
+
+	return camt053.StreamHandler{
+		Header: func(header camt053.Document) error {
+			doc = header
+			return nil
+		},
+		Statement: func(stmt camt053.Statement) error {
+			current, index = stmt, 0
+			if doc.IsNotification() {
+				summary.NotificationsLoaded++
+				return nil
+			}
+			if err := beginStatement(l, doc, &current, summary); err != nil {
+				return fmt.Errorf("statement %d: %w", statements+1, err)
+			}
+			return nil
+		},
+		Entry: func(entry camt053.Entry) error {
+			if doc.IsNotification() {
+				return loadNotificationEntry(l, current.Account.GetId(), entry, summary)
+			}
+			if err := loadEntry(l, doc, &current, entry, index, summary); err != nil {
+				return fmt.Errorf("statement %d: %w", statements+1, err)
+			}
+			index++
+			return nil
+		},
+		EndStatement: func(stmt camt053.Statement) error {
+			if !doc.IsNotification() && statements < len(reconciliations) {
+				if err := summary.addReconciliation(l, &current, &reconciliations[statements]); err != nil {
+					return fmt.Errorf("statement %d: %w", statements+1, err)
+				}
+			}
+			statements++
+			return nil
+		},
+	}
+}
+
+// streamFile streams the document in a file.
+func streamFile(path string, handler camt053.StreamHandler) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return camt053.Stream(file, handler)
+}
+
+// isXMLFile checks if a file is a XML document rather than a MT940, MT942 or BAI2 file, see ParseDocument.
+func isXMLFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	start := make([]byte, SNIFF_SIZE)
+	n, err := io.ReadFull(file, start)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	start = start[:n]
+	return !mt940.IsMT940(start) && !bai2.IsBAI2(start), nil
+}
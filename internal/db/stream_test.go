@@ -0,0 +1,211 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/justfredrik/bank-api/internal/generate"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestDocument writes a document to a file in dir.
+func writeTestDocument(t testing.TB, dir string, name string, doc camt053.Document) string {
+	data, err := camt053.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+	return path
+}
+
+// generateTestFiles writes generated documents with several statements and days to dir.
+func generateTestFiles(t testing.TB, dir string, accounts int, transactions int, days int) []string {
+	config := generate.DefaultConfig()
+	config.Accounts, config.TransactionsPerDay = accounts, transactions
+	config.From = camt053.NewDate(2024, time.March, 1)
+	config.To = camt053.NewDate(2024, time.March, days)
+	docs, err := generate.Generate(config)
+	if err != nil {
+		t.Fatalf("failed to generate test data: %s", err)
+	}
+	paths := make([]string, len(docs))
+	for i, doc := range docs {
+		paths[i] = writeTestDocument(t, dir, fmt.Sprintf("generated_%d.xml", i), doc)
+	}
+	return paths
+}
+
+// TestLoadCamt053File checks that streaming files into the database gives the same data as parsing and loading them.
+func TestLoadCamt053File(t *testing.T) {
+	dir := t.TempDir()
+	doc := loadTestDocument(t)
+	report := camt053.Document{Version: "001.08", MessageType: camt053.MESSAGE_TYPE_REPORT, AccountReport: &camt053.BankToCustomerAccountReport{GroupHeader: doc.BankStatement.GroupHeader, Reports: doc.BankStatement.Statements}}
+	notification := camt053.Document{Version: "001.08", MessageType: camt053.MESSAGE_TYPE_NOTIFICATION, Notification: &camt053.BankToCustomerDebitCreditNotification{GroupHeader: doc.BankStatement.GroupHeader, Notifications: doc.BankStatement.Statements}}
+
+	// Declare Tests
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{"Sample files", []string{"../../data/camt053.xml", "../../data/goldman_sachs_camt053.xml"}},
+		{"Generated files", generateTestFiles(t, dir, 3, 20, 3)},
+		{"Report then statement", []string{writeTestDocument(t, dir, "report.xml", report), "../../data/camt053.xml"}},
+		{"Notification then statement", []string{writeTestDocument(t, dir, "notification.xml", notification), "../../data/camt053.xml"}},
+		{"Statement then notification", []string{"../../data/camt053.xml", filepath.Join(dir, "notification.xml")}},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, streamed := NewBankData(), NewBankData()
+			for _, path := range test.paths {
+				data, err := ParseLocalCamt053(path)
+				if !assert.NoError(t, err) {
+					return
+				}
+				expected, err := parsed.LoadCamt053(data)
+				assert.NoError(t, err)
+
+				summary, err := streamed.LoadCamt053File(path)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, summary, path)
+			}
+			assert.Equal(t, &parsed, &streamed)
+		})
+	}
+}
+
+// TestLoadCamt053FileRejected checks that streamed documents are rejected as a whole, before anything is loaded.
+func TestLoadCamt053FileRejected(t *testing.T) {
+	dir := t.TempDir()
+
+	unbalanced := loadTestDocument(t)
+	findBalance(unbalanced.BankStatement.Statements[0].Balances, "CLBD").Amount.Value = camt053.NewDecimal(100, 2)
+	invalid := loadTestDocument(t)
+	(*invalid.BankStatement.Statements[0].Entries)[6].CreditDebitIndicator = "CREDIT"
+	notification := loadTestDocument(t)
+	(*notification.BankStatement.Statements[0].Entries)[3].AccountServicerRef = nil
+	(*notification.BankStatement.Statements[0].Entries)[3].Reference = nil
+	notification = camt053.Document{Version: "001.08", MessageType: camt053.MESSAGE_TYPE_NOTIFICATION, Notification: &camt053.BankToCustomerDebitCreditNotification{GroupHeader: notification.BankStatement.GroupHeader, Notifications: notification.BankStatement.Statements}}
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		path          string
+		strict        bool
+		expectedError string
+	}{
+		{"Unbalanced statement in strict mode", writeTestDocument(t, dir, "unbalanced.xml", unbalanced), true, "statement 1: "},
+		{"Invalid entry", writeTestDocument(t, dir, "invalid.xml", invalid), false, "CdtDbtInd"},
+		{"Notification entry without reference", writeTestDocument(t, dir, "notification.xml", notification), false, "notification 1: entry 4 has neither an AcctSvcrRef nor a NtryRef"},
+		{"Missing file", filepath.Join(dir, "missing.xml"), false, "no such file"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.strict {
+				t.Setenv("RECONCILIATION_MODE", RECONCILIATION_MODE_STRICT)
+			}
+			db := NewBankData()
+			_, err := db.LoadCamt053File(test.path)
+			assert.ErrorContains(t, err, test.expectedError)
+			assert.Empty(t, db.Accounts)
+			assert.Empty(t, db.pending)
+		})
+	}
+
+	// Statements that do not reconcile are loaded with a warning outside of strict mode
+	db := NewBankData()
+	summary, err := db.LoadCamt053File(filepath.Join(dir, "unbalanced.xml"))
+	assert.NoError(t, err)
+	assert.Len(t, summary.Warnings, 1)
+	reconciliation, err := db.GetStatementReconciliation(testAccountId, unbalanced.BankStatement.Statements[0].Id)
+	assert.NoError(t, err)
+	assert.Equal(t, RECONCILIATION_FAILED, reconciliation.Status)
+}
+
+// TestLoadCamt053FileRollback checks that a streamed document that fails part way through the second pass, e.g. because
+// the file was changed after it was checked, is rolled back and leaves the database as it was.
+func TestLoadCamt053FileRollback(t *testing.T) {
+	dir := t.TempDir()
+	expected, db := NewBankData(), NewBankData()
+	for _, doc := range []camt053.Document{notificationDocument(t), intradayReport(loadTestDocument(t), "RPT-1")} {
+		_, err := expected.LoadCamt053(doc)
+		assert.NoError(t, err)
+		_, err = db.LoadCamt053(doc)
+		assert.NoError(t, err)
+	}
+
+	// The statement settles the intraday entries and the notification, the file ends within the next days statement
+	doc := loadTestDocument(t)
+	doc.BankStatement.Statements = append(doc.BankStatement.Statements, nextDayStatement(doc.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18"))
+	path := writeTestDocument(t, dir, "statements.xml", doc)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	truncated := filepath.Join(dir, "truncated.xml")
+	if err := os.WriteFile(truncated, data[:strings.LastIndex(string(data), "<Ntry>")], 0o644); err != nil {
+		t.Fatalf("failed to write test data: %s", err)
+	}
+
+	summary := LoadSummary{AccountsCreated: make([]string, 0)}
+	err = db.loadFile(truncated, nil, &summary)
+	assert.Error(t, err)
+	assert.Equal(t, 7, summary.EntriesSettled)
+	compareStores(t, &expected, &db)
+	assert.Equal(t, expected.pending, db.pending)
+
+	// The database loads the whole file the same way afterwards
+	_, err = expected.LoadCamt053File(path)
+	assert.NoError(t, err)
+	_, err = db.LoadCamt053File(path)
+	assert.NoError(t, err)
+	compareStores(t, &expected, &db)
+	assert.Equal(t, expected.pending, db.pending)
+	assert.Equal(t, expected.transactionRefs, db.transactionRefs)
+}
+
+// BenchmarkImportFile imports a large generated file by parsing it and by streaming it.
+func BenchmarkImportFile(b *testing.B) {
+	path := generateTestFiles(b, b.TempDir(), 10, 1000, 1)[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	load := map[string]func(db *BankData) (LoadSummary, error){
+		"parse": func(db *BankData) (LoadSummary, error) {
+			doc, err := ParseLocalCamt053(path)
+			if err != nil {
+				return LoadSummary{}, err
+			}
+			return db.LoadCamt053(doc)
+		},
+		"stream": func(db *BankData) (LoadSummary, error) {
+			return db.LoadCamt053File(path)
+		},
+	}
+	for _, name := range []string{"parse", "stream"} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(info.Size())
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				db := NewBankData()
+				if _, err := load[name](&db); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
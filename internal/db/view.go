@@ -0,0 +1,109 @@
+// package db is a local mock database.
+package db
+
+import (
+	"slices"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// unlockedReader is the part of BankData that reads without taking its lock, which is all a view can reach.
+type unlockedReader interface {
+	accountExists(accountId string) bool
+	accounts(query AccountQuery) (*AccountsResponse, error)
+	account(accountId string) (*Account, error)
+	accountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error)
+	exportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error)
+	accountTransaction(accountId string, transactionRef string) (*camt053.Entry, error)
+	accountStatements(accountId string) (*StatementsResponse, error)
+	accountStatement(accountId string, statementId string) (*Statement, error)
+	statementReconciliation(accountId string, statementId string) (*Reconciliation, error)
+	accountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error)
+}
+
+// view is a read only view of the database, see BankData.View. Apart from the accounts, which are copies of their
+// details and balances, the statements it returns are the ones stored in the database, they must not be modified
+// or used after the view has been closed. The view only holds the reads that do not lock, so it can not take the lock again.
+type view struct {
+	db unlockedReader
+}
+
+// View calls fn with a view of the database. Documents are not loaded while fn is running, so every read
+// through the view sees the same state of the database, e.g. an account and its transactions.
+// fn must only read through the view, calling the database again would wait for a load waiting for the view.
+func (db *BankData) View(fn func(view IDataReader) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return fn(view{db: db})
+}
+
+// AccountExists checks if an account exists in the database.
+func (v view) AccountExists(accountId string) bool {
+	return v.db.accountExists(accountId)
+}
+
+// GetAccounts gets a page of the accounts in the database, sorted by account id.
+func (v view) GetAccounts(query AccountQuery) (*AccountsResponse, error) {
+	return v.db.accounts(query)
+}
+
+// GetAccount gets the details and balances of a specific account from the database.
+func (v view) GetAccount(accountId string) (*Account, error) {
+	account, err := v.db.account(accountId)
+	if err != nil {
+		return nil, err
+	}
+	return account.details(), nil
+}
+
+// GetAccountTransactions gets a page of an accounts transactions from the database, filtered and sorted by the query.
+func (v view) GetAccountTransactions(accountId string, query TransactionQuery) (*TransactionsResponse, error) {
+	return v.db.accountTransactions(accountId, query)
+}
+
+// ExportAccountTransactions gets every transaction of an account that matches the query, sorted by the query.
+func (v view) ExportAccountTransactions(accountId string, query TransactionQuery) ([]*camt053.Entry, error) {
+	return v.db.exportAccountTransactions(accountId, query)
+}
+
+// GetAccountTransaction gets a specific transaction for an account from the database.
+func (v view) GetAccountTransaction(accountId string, transactionRef string) (*camt053.Entry, error) {
+	return v.db.accountTransaction(accountId, transactionRef)
+}
+
+// GetAccountStatements gets the statements loaded into an account ordered by statement period and sequence number.
+func (v view) GetAccountStatements(accountId string) (*StatementsResponse, error) {
+	return v.db.accountStatements(accountId)
+}
+
+// GetAccountStatement gets a specific statement for an account from the database.
+func (v view) GetAccountStatement(accountId string, statementId string) (*Statement, error) {
+	return v.db.accountStatement(accountId, statementId)
+}
+
+// GetStatementReconciliation gets the reconciliation of a specific account statement from the database.
+func (v view) GetStatementReconciliation(accountId string, statementId string) (*Reconciliation, error) {
+	return v.db.statementReconciliation(accountId, statementId)
+}
+
+// GetAccountBalances gets an accounts balances sorted by date and type, filtered by the query.
+func (v view) GetAccountBalances(accountId string, query BalanceQuery) (*BalancesResponse, error) {
+	return v.db.accountBalances(accountId, query)
+}
+
+// details copies an account and its balances without its statements and transactions, so that it can be used after
+// the lock has been released.
+func (acc *Account) details() *Account {
+	return &Account{
+		Account:  acc.Account,
+		Balances: slices.Clone(acc.Balances),
+	}
+}
+
+// snapshot copies a statement, its reconciliation is never changed once attached and is shared.
+func (s *Statement) snapshot() *Statement {
+	snapshot := *s
+	snapshot.TransactionRefs = slices.Clone(s.TransactionRefs)
+	return &snapshot
+}
@@ -0,0 +1,163 @@
+// package db is a local mock database.
+package db
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Number of goroutines reading while the documents are loaded.
+const STRESS_READERS = 4
+
+// checkView checks that a view sees whole documents, every generated document has a statement for every account.
+func checkView(t *testing.T, view IDataReader) {
+	accounts, err := view.GetAccounts(AccountQuery{PerPage: MAX_PER_PAGE})
+	if !assert.NoError(t, err) {
+		return
+	}
+	statements := -1
+	for _, account := range accounts.Accounts {
+		id := account.Account.GetId()
+		response, err := view.GetAccountStatements(id)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		if statements == -1 {
+			statements = response.TotalCount
+		}
+		assert.Equal(t, statements, response.TotalCount, "statements of %s", id)
+
+		refs := 0
+		for _, statement := range response.Statements {
+			refs += len(statement.TransactionRefs)
+		}
+		transactions, err := view.GetAccountTransactions(id, TransactionQuery{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, refs, transactions.TotalCount, "transactions of %s", id)
+		}
+	}
+}
+
+// TestConcurrentLoadAndRead loads documents, both parsed and streamed, while other goroutines read from the database.
+// Run with -race to find reads and writes that are not synchronised.
+func TestConcurrentLoadAndRead(t *testing.T) {
+	db := NewBankData()
+	paths := generateTestFiles(t, t.TempDir(), 4, 10, 8)
+
+	done := make(chan struct{})
+	var writers, readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		writers.Add(1)
+		go func(stream bool) {
+			defer writers.Done()
+			for j, path := range paths {
+				if (j%2 == 0) != stream {
+					continue
+				}
+				if stream {
+					assert.NoError(t, ImportFile(&db, path).Err)
+					continue
+				}
+				doc, err := ParseLocalCamt053(path)
+				if assert.NoError(t, err) {
+					_, err = db.LoadCamt053(doc)
+					assert.NoError(t, err)
+				}
+			}
+		}(i == 0)
+	}
+
+	for i := 0; i < STRESS_READERS; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				assert.NoError(t, db.View(func(view IDataReader) error {
+					checkView(t, view)
+					return nil
+				}))
+
+				// Copies can be read while documents are being loaded
+				accounts, err := db.GetAccounts(AccountQuery{})
+				assert.NoError(t, err)
+				for _, summary := range accounts.Accounts {
+					id := summary.Account.GetId()
+					_, err := db.GetAccount(id)
+					assert.NoError(t, err)
+					_, err = db.GetAccountStatements(id)
+					assert.NoError(t, err)
+					_, err = db.GetAccountTransactions(id, TransactionQuery{SortBy: SORT_AMOUNT})
+					assert.NoError(t, err)
+					_, err = db.GetAccountBalances(id, BalanceQuery{})
+					assert.NoError(t, err)
+				}
+			}
+		}()
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	assert.NoError(t, db.View(func(view IDataReader) error {
+		checkView(t, view)
+		return nil
+	}))
+	accounts, err := db.GetAccounts(AccountQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, accounts.TotalCount)
+	for _, summary := range accounts.Accounts {
+		statements, err := db.GetAccountStatements(summary.Account.GetId())
+		assert.NoError(t, err)
+		assert.Equal(t, len(paths), statements.TotalCount)
+	}
+}
+
+// TestSnapshot checks that accounts and statements returned by the database are copies that later loads do not change,
+// and that accounts are returned with their details and balances only.
+func TestSnapshot(t *testing.T) {
+	db := NewBankData()
+	doc := loadTestDocument(t)
+	_, err := db.LoadCamt053(doc)
+	assert.NoError(t, err)
+
+	account, err := db.GetAccount(testAccountId)
+	assert.NoError(t, err)
+	assert.Nil(t, account.Statements)
+	assert.Nil(t, account.Transactions)
+	statement, err := db.GetAccountStatement(testAccountId, "STOIID65181218000000000007")
+	assert.NoError(t, err)
+	balances := len(account.Balances)
+
+	// Changing a copy does not change the database
+	account.Balances = account.Balances[:0]
+	statement.TransactionRefs[0] = "changed"
+
+	// Loading another day does not change the copies
+	doc.BankStatement.Statements[0] = nextDayStatement(doc.BankStatement.Statements[0], "STMT-2018-12-18", "2018-12-18")
+	_, err = db.LoadCamt053(doc)
+	assert.NoError(t, err)
+	assert.Empty(t, account.Balances)
+	assert.Len(t, statement.TransactionRefs, 7)
+
+	account, err = db.GetAccount(testAccountId)
+	assert.NoError(t, err)
+	assert.Greater(t, len(account.Balances), balances)
+	statement, err = db.GetAccountStatement(testAccountId, "STOIID65181218000000000007")
+	assert.NoError(t, err)
+	assert.NotContains(t, statement.TransactionRefs, "changed")
+
+	// Accounts in a list of accounts only have their details and balances
+	accounts, err := db.GetAccounts(AccountQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, account.Balances, accounts.Accounts[0].Balances)
+	assert.Nil(t, accounts.Accounts[0].Transactions)
+}
@@ -0,0 +1,232 @@
+// package db is a local mock database.
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default interval between two scans of the data directory.
+const DEFAULT_WATCH_INTERVAL = 2 * time.Second
+
+// Maximum number of ingestions kept in the ingestion log, the oldest ingestions are dropped first.
+const MAX_INGESTIONS = 1000
+
+// Ingestion is a record of a statement file that has been ingested into the database.
+type Ingestion struct {
+	Path         string    `json:"path"`
+	Status       string    `json:"status"`
+	Statements   int       `json:"statements"`
+	Transactions int       `json:"transactions"`
+	Error        string    `json:"error,omitempty"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	IngestedTime time.Time `json:"ingestedTime"`
+	size         int64
+}
+
+// IngestionsResponse is the format for /ingestions request responses.
+type IngestionsResponse struct {
+	Ingestions []Ingestion `json:"ingestions"`
+	TotalCount int         `json:"totalCount"`
+}
+
+// IngestionLog keeps track of what was ingested into the database and when.
+type IngestionLog struct {
+	mu         sync.Mutex
+	ingestions []Ingestion
+}
+
+// Record adds the result of a file import to the ingestion log.
+func (l *IngestionLog) Record(result ImportResult) {
+	ingestion := Ingestion{
+		Path:         result.Path,
+		Status:       result.Status(),
+		Statements:   result.Statements,
+		Transactions: result.Transactions,
+		ModifiedTime: result.ModTime,
+		IngestedTime: result.ImportedAt,
+		size:         result.Size,
+	}
+	if result.Err != nil {
+		ingestion.Error = result.Err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ingestions = append(l.ingestions, ingestion)
+	if len(l.ingestions) > MAX_INGESTIONS {
+		l.ingestions = l.ingestions[len(l.ingestions)-MAX_INGESTIONS:]
+	}
+}
+
+// List returns a copy of the ingestion log, oldest ingestion first.
+func (l *IngestionLog) List() IngestionsResponse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ingestions := make([]Ingestion, len(l.ingestions))
+	copy(ingestions, l.ingestions)
+
+	return IngestionsResponse{
+		Ingestions: ingestions,
+		TotalCount: len(ingestions),
+	}
+}
+
+// Ingestions is the log of every statement file ingested into DB.
+var Ingestions = &IngestionLog{}
+
+// fileState is the state of a file the last time the data directory was scanned.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a data directory and ingests new or changed statement files into a database.
+// A file is ingested once it has been seen with the same size and modification time in two
+// consecutive scans, so that files that are still being written are not ingested half way through.
+type Watcher struct {
+	store    IDataBase
+	log      *IngestionLog
+	dir      string
+	pattern  string
+	interval time.Duration
+
+	ingested map[string]fileState // State of the file when it was last ingested
+	pending  map[string]fileState // State of new or changed files waiting to be ingested
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewWatcher creates a watcher for the files in dir matching pattern.
+// Files already in the ingestion log are only ingested again if they change.
+func NewWatcher(store IDataBase, log *IngestionLog, dir string, pattern string, interval time.Duration) *Watcher {
+	w := &Watcher{
+		store:    store,
+		log:      log,
+		dir:      dir,
+		pattern:  pattern,
+		interval: interval,
+		ingested: make(map[string]fileState),
+		pending:  make(map[string]fileState),
+	}
+
+	for _, ingestion := range log.List().Ingestions {
+		w.ingested[ingestion.Path] = fileState{modTime: ingestion.ModifiedTime, size: ingestion.size}
+	}
+
+	return w
+}
+
+// Start scans the data directory every interval in a separate goroutine until Stop is called.
+func (w *Watcher) Start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				for _, result := range w.Scan() {
+					logImportResult(result)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the watcher and waits for an ongoing scan to finish.
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+// Scan checks the data directory once and ingests every new or changed file that has finished being written.
+func (w *Watcher) Scan() []ImportResult {
+	results := make([]ImportResult, 0)
+
+	paths, err := filepath.Glob(filepath.Join(w.dir, w.pattern))
+	if err != nil {
+		return results
+	}
+
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		present[path] = true
+		state := fileState{modTime: info.ModTime(), size: info.Size()}
+
+		// Skip files that have not changed since they were ingested
+		if ingested, ok := w.ingested[path]; ok && ingested.modTime.Equal(state.modTime) && ingested.size == state.size {
+			delete(w.pending, path)
+			continue
+		}
+
+		// Wait for the file to be unchanged for one interval before ingesting it
+		if pending, ok := w.pending[path]; !ok || !pending.modTime.Equal(state.modTime) || pending.size != state.size {
+			w.pending[path] = state
+			continue
+		}
+
+		result := ImportFile(w.store, path)
+		w.log.Record(result)
+		results = append(results, result)
+
+		delete(w.pending, path)
+		w.ingested[path] = state
+	}
+
+	// Forget files that have been removed, the data they contained is kept in the database
+	for path := range w.ingested {
+		if !present[path] {
+			delete(w.ingested, path)
+		}
+	}
+	for path := range w.pending {
+		if !present[path] {
+			delete(w.pending, path)
+		}
+	}
+
+	return results
+}
+
+// WatchDataDirectory starts watching the data directory for the database if the DATA_WATCH env variable is set to true.
+// The interval between scans is configured with DATA_WATCH_INTERVAL, e.g. "500ms" or "5s".
+func WatchDataDirectory(store IDataBase, log *IngestionLog) (*Watcher, error) {
+	if strings.ToLower(os.Getenv("DATA_WATCH")) != "true" {
+		return nil, nil
+	}
+
+	interval := DEFAULT_WATCH_INTERVAL
+	if rawInterval := os.Getenv("DATA_WATCH_INTERVAL"); rawInterval != "" {
+		var err error
+		if interval, err = time.ParseDuration(rawInterval); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid DATA_WATCH_INTERVAL %q", rawInterval)
+		}
+	}
+
+	watcher := NewWatcher(store, log, DataDirectory(), DataGlob(), interval)
+	watcher.Start()
+	fmt.Printf("%s Watching %s every %s\n", DB_LOG_STRING, filepath.Join(DataDirectory(), DataGlob()), interval)
+
+	return watcher, nil
+}
@@ -0,0 +1,91 @@
+// package db is a local mock database.
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatcherScan checks that new and changed files are ingested once they have stopped changing.
+func TestWatcherScan(t *testing.T) {
+	db := NewBankData()
+	log := &IngestionLog{}
+	dir := t.TempDir()
+
+	// Files loaded at startup are not ingested again
+	copyTestFile(t, dir, "camt053.xml", "day1.xml")
+	results, err := ImportDirectory(&db, dir, DEFAULT_DATA_GLOB)
+	assert.NoError(t, err)
+	for _, result := range results {
+		log.Record(result)
+	}
+
+	watcher := NewWatcher(&db, log, dir, DEFAULT_DATA_GLOB, time.Second)
+	assert.Empty(t, watcher.Scan())
+
+	// A new file is first seen and then ingested on the next scan
+	copyTestFile(t, dir, "goldman_sachs_camt053.xml", "day2.xml")
+	assert.Empty(t, watcher.Scan())
+	results = watcher.Scan()
+	assert.Len(t, results, 1)
+	assert.Equal(t, filepath.Join(dir, "day2.xml"), results[0].Path)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, db.AccountExists("DD01100056869"))
+	assert.Empty(t, watcher.Scan())
+
+	// A broken file is ingested and reported once
+	os.WriteFile(filepath.Join(dir, "day3.xml"), []byte("<Document>"), 0o644)
+	watcher.Scan()
+	results = watcher.Scan()
+	assert.Len(t, results, 1)
+	assert.Error(t, results[0].Err)
+	assert.Empty(t, watcher.Scan())
+
+	// Fixing the file ingests it again
+	copyTestFile(t, dir, "camt053.xml", "day3.xml")
+	os.Chtimes(filepath.Join(dir, "day3.xml"), time.Now(), time.Now().Add(time.Minute))
+	watcher.Scan()
+	results = watcher.Scan()
+	assert.Len(t, results, 1)
+	assert.NoError(t, results[0].Err)
+
+	ingestions := log.List()
+	assert.Equal(t, 4, ingestions.TotalCount)
+	assert.Equal(t, IMPORT_LOADED, ingestions.Ingestions[1].Status)
+	assert.Equal(t, IMPORT_FAILED, ingestions.Ingestions[2].Status)
+	assert.NotEmpty(t, ingestions.Ingestions[2].Error)
+}
+
+// TestWatcherStartStop checks that a started watcher ingests files in the background.
+func TestWatcherStartStop(t *testing.T) {
+	db := NewBankData()
+	log := &IngestionLog{}
+	dir := t.TempDir()
+
+	watcher := NewWatcher(&db, log, dir, DEFAULT_DATA_GLOB, 10*time.Millisecond)
+	watcher.Start()
+	copyTestFile(t, dir, "camt053.xml", "day1.xml")
+
+	assert.Eventually(t, func() bool {
+		return log.List().TotalCount == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	watcher.Stop()
+
+	assert.True(t, db.AccountExists(testAccountId))
+}
+
+// TestIngestionLogLimit checks that the ingestion log drops the oldest ingestions.
+func TestIngestionLogLimit(t *testing.T) {
+	log := &IngestionLog{}
+	for i := 0; i < MAX_INGESTIONS+10; i++ {
+		log.Record(ImportResult{Path: "file", Statements: i})
+	}
+
+	ingestions := log.List()
+	assert.Equal(t, MAX_INGESTIONS, ingestions.TotalCount)
+	assert.Equal(t, 10, ingestions.Ingestions[0].Statements)
+}
@@ -0,0 +1,305 @@
+// package export writes transactions in the formats of external tools, CSV, JSON Lines and camt053 XML.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Export formats, the value of the export query parameter.
+const FORMAT_JSON = "json"
+const FORMAT_CSV = "csv"
+const FORMAT_NDJSON = "ndjson"
+const FORMAT_XML = "xml"
+
+// Media types of the export formats that are not defined by gin.
+const MIME_CSV = "text/csv"
+const MIME_NDJSON = "application/x-ndjson"
+
+// Number of JSON lines written between flushes of a streamed export.
+const NDJSON_FLUSH_INTERVAL = 100
+
+// Columns of a CSV export when no columns are requested.
+var DEFAULT_COLUMNS = []string{
+	"reference", "bookingDate", "valueDate", "amount", "currency", "creditDebitIndicator", "status", "bankTransactionCode", "remittanceInformation",
+}
+
+// Characters that make spreadsheets read a cell as a formula, free text starting with them is escaped in CSV exports.
+const FORMULA_CHARACTERS = "=+-@\t\r"
+
+// columns converts an entry into the value of each CSV column. Columns with free text from the statement are
+// wrapped in text, so that they can not inject formulas into the spreadsheet the export is opened in.
+var columns = map[string]func(entry *camt053.Entry) string{
+	"reference":                text(func(entry *camt053.Entry) string { return stringOrEmpty(entry.URLReference) }),
+	"entryReference":           text(func(entry *camt053.Entry) string { return stringOrEmpty(entry.Reference) }),
+	"accountServicerReference": text(func(entry *camt053.Entry) string { return stringOrEmpty(entry.AccountServicerRef) }),
+	"bookingDate":              func(entry *camt053.Entry) string { return dateOrEmpty(entry.BookingDate) },
+	"valueDate":                func(entry *camt053.Entry) string { return dateOrEmpty(entry.ValueDate) },
+	"amount":                   func(entry *camt053.Entry) string { return entry.Amount.Value.String() },
+	"signedAmount":             signedAmount,
+	"currency":                 func(entry *camt053.Entry) string { return entry.Amount.Currency },
+	"creditDebitIndicator":     func(entry *camt053.Entry) string { return entry.CreditDebitIndicator },
+	"status":                   func(entry *camt053.Entry) string { return string(entry.Status) },
+	"bankTransactionCode":      text(bankTransactionCode),
+	"remittanceInformation":    text(remittanceInformation),
+	"additionalInformation":    text(func(entry *camt053.Entry) string { return stringOrEmpty(entry.AdditionalInformation) }),
+	"intraday":                 func(entry *camt053.Entry) string { return fmt.Sprint(entry.Intraday) },
+}
+
+// ParseFormat checks that an export format is supported.
+func ParseFormat(format string) (string, error) {
+	switch format = strings.ToLower(format); format {
+	case FORMAT_JSON, FORMAT_CSV, FORMAT_NDJSON, FORMAT_XML:
+		return format, nil
+	}
+	return "", errors.New("export must be json, csv, ndjson or xml")
+}
+
+// ParseColumns parses a comma separated list of CSV columns, an empty list selects DEFAULT_COLUMNS.
+func ParseColumns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return DEFAULT_COLUMNS, nil
+	}
+	selected := make([]string, 0)
+	for _, column := range strings.Split(raw, ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("'%s' is not a column, the columns are %s", column, strings.Join(Columns(), ", "))
+		}
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// Columns returns the names of every CSV column, the default columns first.
+func Columns() []string {
+	names := append([]string{}, DEFAULT_COLUMNS...)
+	return append(names, "entryReference", "accountServicerReference", "signedAmount", "additionalInformation", "intraday")
+}
+
+// text escapes the free text of a column, values that start with one of the FORMULA_CHARACTERS are prefixed
+// with a single quote, e.g. '=HYPERLINK(...), so that spreadsheets show them as text.
+func text(column func(entry *camt053.Entry) string) func(entry *camt053.Entry) string {
+	return func(entry *camt053.Entry) string {
+		value := column(entry)
+		if value != "" && strings.ContainsRune(FORMULA_CHARACTERS, rune(value[0])) {
+			return "'" + value
+		}
+		return value
+	}
+}
+
+// WriteCSV writes a header row with the column names followed by a row per entry.
+func WriteCSV(w io.Writer, entries []*camt053.Entry, selected []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(selected); err != nil {
+		return err
+	}
+	row := make([]string, len(selected))
+	for _, entry := range entries {
+		for i, column := range selected {
+			row[i] = columns[column](entry)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// flusher is implemented by writers that can send buffered data to the client, such as gin's ResponseWriter.
+type flusher interface {
+	Flush()
+}
+
+// WriteNDJSON writes every entry as a JSON object on its own line. The writer is flushed every
+// NDJSON_FLUSH_INTERVAL lines if it supports flushing, so that large exports are streamed.
+func WriteNDJSON(w io.Writer, entries []*camt053.Entry) error {
+	encoder := json.NewEncoder(w)
+	for i, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		if f, ok := w.(flusher); ok && (i+1)%NDJSON_FLUSH_INTERVAL == 0 {
+			f.Flush()
+		}
+	}
+	return nil
+}
+
+// Statement builds a camt053 document with a single statement of an account containing the exported entries.
+// The balances of the account dated within the period are included, along with a transaction summary of the entries.
+// Entries that are filtered by more than their booking date do not add up to those balances, see filteredBalances.
+func Statement(account camt053.Account, balances []camt053.Balance, entries []*camt053.Entry, from *camt053.Date, to *camt053.Date, filtered bool, created time.Time) camt053.Document {
+	createdDateTime := camt053.NewDateTime(created)
+	stmt := camt053.Statement{
+		Id:               "EXPORT-" + created.UTC().Format("20060102150405"),
+		CreationDateTime: createdDateTime,
+		Account:          account,
+		Balances:         make([]camt053.Balance, 0),
+	}
+
+	if filtered {
+		stmt.Balances = filteredBalances(account, entries, from, to, created)
+	} else {
+		for _, balance := range balances {
+			date := balance.GetDate()
+			if (from == nil || date.Compare(*from) >= 0) && (to == nil || date.Compare(*to) <= 0) {
+				stmt.Balances = append(stmt.Balances, balance)
+			}
+		}
+	}
+	if from != nil && to != nil {
+		stmt.FromDate = &camt053.FromDate{
+			FromDateTime: camt053.NewDateTime(from.Time()),
+			ToDateTime:   camt053.NewDateTime(to.Time().Add(24*time.Hour - time.Second)),
+		}
+	}
+
+	if len(entries) > 0 {
+		copied := make([]camt053.Entry, len(entries))
+		for i, entry := range entries {
+			copied[i] = *entry
+		}
+		stmt.Entries = &copied
+		stmt.TransactionSummary = camt053.Summarize(copied)
+	}
+
+	return camt053.Document{
+		Version:     camt053.DEFAULT_VERSION,
+		MessageType: camt053.MESSAGE_TYPE_STATEMENT,
+		BankStatement: camt053.BankToCustomerStatement{
+			GroupHeader: camt053.GroupHeader{CreationDateTime: createdDateTime},
+			Statements:  []camt053.Statement{stmt},
+		},
+	}
+}
+
+// filteredBalances returns the balances of an export whose entries are filtered by more than their booking date.
+// The balances of the account do not add up with such entries, so the export instead opens at zero and closes at
+// the net amount of its booked entries.
+func filteredBalances(account camt053.Account, entries []*camt053.Entry, from *camt053.Date, to *camt053.Date, created time.Time) []camt053.Balance {
+	currency := ""
+	if account.Currency != nil {
+		currency = *account.Currency
+	} else if len(entries) > 0 {
+		currency = entries[0].Amount.Currency
+	}
+	if currency == "" {
+		return make([]camt053.Balance, 0)
+	}
+
+	zero := camt053.NewDecimal(0, camt053.MinorUnits(currency))
+	net := zero
+	var first, last *camt053.Date
+	for _, entry := range entries {
+		if entry.Status == "" || entry.Status == "BOOK" {
+			net = net.Add(entry.Amount.Signed(entry.CreditDebitIndicator))
+		}
+		if entry.BookingDate != nil {
+			day := entry.BookingDate.Day()
+			if first == nil || day.Compare(*first) < 0 {
+				first = &day
+			}
+			if last == nil || day.Compare(*last) > 0 {
+				last = &day
+			}
+		}
+	}
+
+	createdDate := camt053.NewDateTime(created).Date()
+	return []camt053.Balance{
+		balance("OPBD", currency, zero, firstDate(from, first, &createdDate)),
+		balance("CLBD", currency, net, firstDate(to, last, &createdDate)),
+	}
+}
+
+// balance returns a balance of a type, with a credit debit indicator that follows the sign of its value.
+func balance(code string, currency string, value camt053.Decimal, date camt053.Date) camt053.Balance {
+	indicator := "CRDT"
+	if value.Sign() < 0 {
+		value, indicator = value.Neg(), "DBIT"
+	}
+	return camt053.Balance{
+		Type:                 camt053.BalanceType{CodeOrProprietary: camt053.CodeOrProprietary{Code: &code}},
+		Amount:               camt053.Amount{Currency: currency, Value: value},
+		CreditDebitIndicator: indicator,
+		Date:                 camt053.DateAndDateTime{Date: &date},
+	}
+}
+
+// firstDate returns the first of the dates that is set.
+func firstDate(dates ...*camt053.Date) camt053.Date {
+	for _, date := range dates {
+		if date != nil {
+			return *date
+		}
+	}
+	return camt053.Date{}
+}
+
+// WriteCamt053 writes a camt053 document as indented XML in the namespace of its version, see camt053.Write.
+func WriteCamt053(w io.Writer, doc camt053.Document) error {
+	return camt053.Write(w, doc)
+}
+
+// signedAmount returns the amount of an entry, negative for debits.
+func signedAmount(entry *camt053.Entry) string {
+	if entry.CreditDebitIndicator == "DBIT" && entry.Amount.Value.Sign() > 0 {
+		return entry.Amount.Value.Neg().String()
+	}
+	return entry.Amount.Value.String()
+}
+
+// bankTransactionCode returns the domain code of an entry, e.g. PMNT-ICDT-ATXN, or its proprietary code.
+func bankTransactionCode(entry *camt053.Entry) string {
+	btc := entry.BankTransactionCode
+	if btc.Domain != nil {
+		return strings.Join([]string{btc.Domain.Code, btc.Domain.Family.Code, btc.Domain.Family.SubFamilyCode}, "-")
+	}
+	if btc.ProprietaryCode != nil {
+		return btc.ProprietaryCode.Code
+	}
+	return ""
+}
+
+// remittanceInformation joins the unstructured remittance information of every transaction of an entry.
+func remittanceInformation(entry *camt053.Entry) string {
+	lines := make([]string, 0)
+	if entry.EntryDetails == nil {
+		return ""
+	}
+	for _, details := range *entry.EntryDetails {
+		if details.TransactionDetails == nil {
+			continue
+		}
+		for _, transaction := range *details.TransactionDetails {
+			if transaction.RemittanceInformation != nil && transaction.RemittanceInformation.Unstructured != nil {
+				lines = append(lines, *transaction.RemittanceInformation.Unstructured...)
+			}
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func dateOrEmpty(date *camt053.DateAndDateTime) string {
+	if date == nil || date.IsZero() {
+		return ""
+	}
+	return date.Day().String()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,235 @@
+// package export writes transactions in the formats of external tools, CSV, JSON Lines and camt053 XML.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEntries returns the entries of the first statement of the local camt053 mock data.
+func testEntries(t *testing.T) (camt053.Statement, []*camt053.Entry) {
+	file, err := os.Open("../../data/camt053.xml")
+	if err != nil {
+		t.Fatalf("failed to open test data: %s", err)
+	}
+	defer file.Close()
+
+	doc, err := camt053.Parse(file)
+	if err != nil {
+		t.Fatalf("failed to parse test data: %s", err)
+	}
+	stmt := doc.Statements()[0]
+	entries := make([]*camt053.Entry, len(*stmt.Entries))
+	for i := range *stmt.Entries {
+		entries[i] = &(*stmt.Entries)[i]
+		ref := strings.ReplaceAll(stringOrEmpty(entries[i].Reference), " ", "-")
+		entries[i].URLReference = &ref
+	}
+	return stmt, entries
+}
+
+// TestParseColumns checks the default columns and that unknown columns are rejected.
+func TestParseColumns(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		raw           string
+		expected      []string
+		expectedError bool
+	}{
+		{"Default", "", DEFAULT_COLUMNS, false},
+		{"Selected", "bookingDate, signedAmount,remittanceInformation", []string{"bookingDate", "signedAmount", "remittanceInformation"}, false},
+		{"Unknown column", "bookingDate,balance", nil, true},
+		{"Empty column", "bookingDate,", nil, true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, err := ParseColumns(test.raw)
+			assert.Equal(t, test.expectedError, err != nil, err)
+			assert.Equal(t, test.expected, columns)
+		})
+	}
+}
+
+// TestWriteCSV checks that a header row and a row per entry are written with the selected columns.
+func TestWriteCSV(t *testing.T) {
+	_, entries := testEntries(t)
+
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WriteCSV(buffer, entries, []string{"entryReference", "amount", "signedAmount", "creditDebitIndicator", "bankTransactionCode"}))
+
+	rows, err := csv.NewReader(buffer).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, len(entries)+1)
+	assert.Equal(t, []string{"entryReference", "amount", "signedAmount", "creditDebitIndicator", "bankTransactionCode"}, rows[0])
+	for i, entry := range entries {
+		row := rows[i+1]
+		assert.Equal(t, *entry.Reference, row[0])
+		assert.Equal(t, entry.Amount.Value.String(), row[1])
+		if entry.CreditDebitIndicator == "DBIT" {
+			assert.Equal(t, "-"+row[1], row[2])
+		} else {
+			assert.Equal(t, row[1], row[2])
+		}
+		assert.Equal(t, bankTransactionCode(entry), row[4])
+	}
+}
+
+// TestWriteCSVFormulas checks that free text that a spreadsheet would read as a formula is escaped, and that amounts are not.
+func TestWriteCSVFormulas(t *testing.T) {
+	ref := func(s string) *string { return &s }
+
+	// Declare Tests
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1+2", "'+1+2"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1", "'\t=1"},
+		{"Invoice =1", "Invoice =1"},
+		{"", ""},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			entry := &camt053.Entry{
+				Amount:                camt053.Amount{Value: camt053.NewDecimal(-150, 2), Currency: "SEK"},
+				Reference:             ref(test.input),
+				AdditionalInformation: ref(test.input),
+			}
+			buffer := &bytes.Buffer{}
+			assert.NoError(t, WriteCSV(buffer, []*camt053.Entry{entry}, []string{"entryReference", "additionalInformation", "amount"}))
+
+			rows, err := csv.NewReader(buffer).ReadAll()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{test.expected, test.expected, "-1.50"}, rows[1])
+		})
+	}
+}
+
+// TestWriteNDJSON checks that every entry is written as a JSON object on its own line.
+func TestWriteNDJSON(t *testing.T) {
+	_, entries := testEntries(t)
+
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WriteNDJSON(buffer, entries))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Len(t, lines, len(entries))
+	for i, line := range lines {
+		var entry camt053.Entry
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, *entries[i].URLReference, *entry.URLReference)
+	}
+}
+
+// TestWriteCamt053 checks that the regenerated statement can be parsed and validated, and that it sums up the entries.
+func TestWriteCamt053(t *testing.T) {
+	stmt, entries := testEntries(t)
+	created := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WriteCamt053(buffer, Statement(stmt.Account, stmt.Balances, entries, nil, nil, false, created)))
+	assert.Contains(t, buffer.String(), `xmlns="`+camt053.Namespace(camt053.DEFAULT_VERSION)+`"`)
+
+	doc, err := camt053.Parse(buffer)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, camt053.ValidateDocument(doc))
+
+	exported := doc.Statements()[0]
+	assert.Equal(t, "EXPORT-20240131120000", exported.Id)
+	assert.Equal(t, stmt.Account.GetId(), exported.Account.GetId())
+	assert.Len(t, exported.Balances, len(stmt.Balances))
+	assert.Len(t, *exported.Entries, len(entries))
+	for i, entry := range *exported.Entries {
+		assert.Equal(t, *entries[i].Reference, *entry.Reference)
+		assert.Equal(t, entries[i].Amount, entry.Amount)
+	}
+
+	summary := exported.TransactionSummary
+	assert.Equal(t, len(entries), summary.TotalEntries.NumberOfEntries)
+	assert.Equal(t, len(entries), summary.TotalCreditEntries.NumberOfEntries+summary.TotalDebitEntries.NumberOfEntries)
+	assert.Equal(t, 0, summary.TotalEntries.Sum.Cmp(summary.TotalCreditEntries.Sum.Add(*summary.TotalDebitEntries.Sum)))
+}
+
+// TestStatementPeriod checks that only balances dated within the exported period are included.
+func TestStatementPeriod(t *testing.T) {
+	stmt, entries := testEntries(t)
+	from := stmt.Balances[0].GetDate()
+
+	doc := Statement(stmt.Account, stmt.Balances, entries, &from, &from, false, time.Now())
+	exported := doc.Statements()[0]
+	for _, balance := range exported.Balances {
+		assert.Equal(t, from, balance.GetDate())
+	}
+	assert.Equal(t, from, exported.FromDate.FromDateTime.Date())
+
+	doc = Statement(stmt.Account, stmt.Balances, nil, nil, nil, false, time.Now())
+	assert.Nil(t, doc.Statements()[0].Entries)
+	assert.Nil(t, doc.Statements()[0].TransactionSummary)
+}
+
+// TestFilteredStatement checks that filtered exports open at zero and close at the net amount of their booked entries.
+func TestFilteredStatement(t *testing.T) {
+	stmt, entries := testEntries(t)
+	created := time.Date(2018, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	// Declare Tests
+	tests := []struct {
+		name      string
+		indicator string
+	}{
+		{"Credits", "CRDT"},
+		{"Debits", "DBIT"},
+		{"No entries", "NONE"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered := make([]*camt053.Entry, 0)
+			net := camt053.NewDecimal(0, 2)
+			for _, entry := range entries {
+				if entry.CreditDebitIndicator == test.indicator {
+					filtered = append(filtered, entry)
+					net = net.Add(entry.Amount.Signed(entry.CreditDebitIndicator))
+				}
+			}
+
+			doc := Statement(stmt.Account, stmt.Balances, filtered, nil, nil, true, created)
+			assert.NoError(t, camt053.ValidateDocument(doc))
+
+			exported := doc.Statements()[0]
+			if !assert.Len(t, exported.Balances, 2) {
+				return
+			}
+			opening, closing := exported.Balances[0], exported.Balances[1]
+			assert.Equal(t, "OPBD", opening.TypeCode())
+			assert.Equal(t, 0, opening.Amount.Value.Sign())
+			assert.Equal(t, "CLBD", closing.TypeCode())
+			assert.Equal(t, 0, closing.Amount.Signed(closing.CreditDebitIndicator).Cmp(net))
+			if len(filtered) > 0 {
+				assert.Equal(t, filtered[0].BookingDate.Day(), opening.GetDate())
+			} else {
+				assert.Equal(t, camt053.NewDate(2018, 12, 20), closing.GetDate())
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+// package generate produces synthetic camt053 statements for load testing and demos.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Transaction kinds that can be mixed, the value of the keys in Config.Mix.
+const KIND_SALARY = "salary"             // Incoming salary from an employer
+const KIND_TRANSFER_IN = "transfer-in"   // Incoming credit transfer paying an invoice
+const KIND_TRANSFER_OUT = "transfer-out" // Outgoing credit transfer paying an invoice
+const KIND_CARD = "card"                 // Card purchase at a merchant
+const KIND_DIRECT_DEBIT = "direct-debit" // Direct debit collected by a creditor
+const KIND_FEE = "fee"                   // Account fee charged by the bank
+
+// Transaction mix used when no mix is configured, the relative weight of each kind.
+var DEFAULT_MIX = map[string]int{
+	KIND_SALARY:       1,
+	KIND_TRANSFER_IN:  4,
+	KIND_TRANSFER_OUT: 4,
+	KIND_CARD:         8,
+	KIND_DIRECT_DEBIT: 2,
+	KIND_FEE:          1,
+}
+
+// Hour of the day after the statement date at which statements are created, in UTC.
+const STATEMENT_HOUR = 2
+
+// MAX_DAYS limits the length of the date range.
+const MAX_DAYS = 3660
+
+// kind describes how entries of a transaction kind are generated.
+type kind struct {
+	indicator string // CRDT or DBIT
+	domain    string
+	family    string
+	subFamily string
+	min       int64 // Smallest amount in the major unit of the currency
+	max       int64 // Largest amount in the major unit of the currency
+	invoice   bool  // The remittance information refers to an invoice, it may be structured
+	text      string
+}
+
+var kinds = map[string]kind{
+	KIND_SALARY:       {"CRDT", "PMNT", "RCDT", "SALA", 20000, 60000, false, "SALARY %s"},
+	KIND_TRANSFER_IN:  {"CRDT", "PMNT", "RCDT", "ESCT", 100, 50000, true, "INVOICE %s %s"},
+	KIND_TRANSFER_OUT: {"DBIT", "PMNT", "ICDT", "ESCT", 100, 30000, true, "INVOICE %s %s"},
+	KIND_CARD:         {"DBIT", "PMNT", "CCRD", "POSD", 20, 2000, false, "CARD PURCHASE %s"},
+	KIND_DIRECT_DEBIT: {"DBIT", "PMNT", "RDDT", "ESDD", 100, 5000, true, "DIRECT DEBIT %s %s"},
+	KIND_FEE:          {"DBIT", "ACMT", "MDOP", "CHRG", 10, 100, false, "ACCOUNT FEE"},
+}
+
+// Config configures the generated statements. The same config always generates the same statements.
+type Config struct {
+	Seed               uint64
+	Accounts           int            // Number of accounts, each account gets a statement per day
+	From               camt053.Date   // First statement date
+	To                 camt053.Date   // Last statement date
+	Currencies         []string       // Currencies of the accounts, assigned in turn
+	Mix                map[string]int // Relative weight of each transaction kind
+	TransactionsPerDay int            // Average number of entries per account and day
+	Counterparties     int            // Number of employers, merchants, creditors and debtors
+	StructuredShare    float64        // Share of invoice payments with structured remittance information, 0 to 1
+	Version            string         // camt053 version of the generated documents
+}
+
+// DefaultConfig returns a config generating a month of statements for 10 SEK accounts.
+func DefaultConfig() Config {
+	return Config{
+		Seed:               1,
+		Accounts:           10,
+		From:               camt053.NewDate(2024, time.January, 1),
+		To:                 camt053.NewDate(2024, time.January, 31),
+		Currencies:         []string{"SEK"},
+		Mix:                DEFAULT_MIX,
+		TransactionsPerDay: 5,
+		Counterparties:     50,
+		StructuredShare:    0.3,
+		Version:            camt053.DEFAULT_VERSION,
+	}
+}
+
+// ParseMix parses a comma separated list of transaction kinds and weights, e.g. "card=10,fee=1".
+// Kinds that are not listed are not generated.
+func ParseMix(raw string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, pair := range strings.Split(raw, ",") {
+		name, rawWeight, found := strings.Cut(strings.TrimSpace(pair), "=")
+		weight, err := strconv.Atoi(rawWeight)
+		if !found || err != nil || weight < 0 {
+			return nil, fmt.Errorf("'%s' is not a kind and a weight, e.g. card=10", pair)
+		}
+		if _, ok := kinds[name]; !ok {
+			return nil, fmt.Errorf("'%s' is not a transaction kind, the kinds are %s", name, strings.Join(Kinds(), ", "))
+		}
+		mix[name] = weight
+	}
+	return mix, nil
+}
+
+// Kinds returns the names of every transaction kind.
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validate checks that the config can generate statements.
+func (config Config) validate() error {
+	switch {
+	case config.Accounts < 1:
+		return errors.New("at least one account is required")
+	case config.From.IsZero() || config.To.IsZero():
+		return errors.New("from and to dates are required")
+	case config.To.Compare(config.From) < 0:
+		return errors.New("to date is before from date")
+	case config.To.Time().Sub(config.From.Time()) >= MAX_DAYS*24*time.Hour:
+		return fmt.Errorf("at most %d days can be generated", MAX_DAYS)
+	case len(config.Currencies) == 0:
+		return errors.New("at least one currency is required")
+	case config.TransactionsPerDay < 0:
+		return errors.New("transactions per day can not be negative")
+	case config.Counterparties < 1:
+		return errors.New("at least one counterparty is required")
+	case config.StructuredShare < 0 || config.StructuredShare > 1:
+		return errors.New("structured share must be between 0 and 1")
+	}
+	for _, currency := range config.Currencies {
+		if _, err := camt053.NewAmount(currency, camt053.NewDecimal(0, 0)); err != nil {
+			return err
+		}
+	}
+	total := 0
+	for name, weight := range config.Mix {
+		if _, ok := kinds[name]; !ok {
+			return fmt.Errorf("'%s' is not a transaction kind", name)
+		}
+		total += weight
+	}
+	if total == 0 && config.TransactionsPerDay > 0 {
+		return errors.New("the transaction mix needs at least one kind with a weight")
+	}
+	_, _, err := camt053.ParseNamespace(camt053.Namespace(config.Version))
+	return err
+}
+
+// account is the state of a generated account between statements.
+type account struct {
+	id       camt053.AccountId
+	currency string
+	owner    string
+	bic      string
+	balance  int64 // In the minor unit of the currency, negative when overdrawn
+}
+
+// Generator generates a document per day, each with a statement per account.
+type Generator struct {
+	config         Config
+	rand           *rand.Rand
+	accounts       []*account
+	counterparties []string
+	mix            []string // Kinds in a stable order, the weights are looked up in the config
+	totalWeight    int
+	date           camt053.Date
+	sequence       int
+}
+
+// NewGenerator validates the config and creates the accounts and counterparties.
+func NewGenerator(config Config) (*Generator, error) {
+	if config.Mix == nil {
+		config.Mix = DEFAULT_MIX
+	}
+	if config.Version == "" {
+		config.Version = camt053.DEFAULT_VERSION
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{
+		config: config,
+		rand:   rand.New(rand.NewPCG(config.Seed, config.Seed^0x9e3779b97f4a7c15)),
+		date:   config.From,
+	}
+	for _, name := range Kinds() {
+		if weight := config.Mix[name]; weight > 0 {
+			g.mix = append(g.mix, name)
+			g.totalWeight += weight
+		}
+	}
+	g.counterparties = make([]string, config.Counterparties)
+	for i := range g.counterparties {
+		g.counterparties[i] = g.companyName()
+	}
+	for i := 0; i < config.Accounts; i++ {
+		currency := config.Currencies[i%len(config.Currencies)]
+		g.accounts = append(g.accounts, &account{
+			id:       g.accountId(currency),
+			currency: currency,
+			owner:    g.companyName(),
+			bic:      bic(currency),
+			balance:  g.between(1000, 100000) * pow10(camt053.MinorUnits(currency)),
+		})
+	}
+	return g, nil
+}
+
+// Next returns the document of the next day, false once the last day has been generated.
+func (g *Generator) Next() (camt053.Document, bool) {
+	if g.date.Compare(g.config.To) > 0 {
+		return camt053.Document{}, false
+	}
+	g.sequence++
+	created := camt053.NewDateTime(g.date.Time().Add((24 + STATEMENT_HOUR) * time.Hour))
+	messageId := g.date.Time().Format("20060102")
+
+	statements := make([]camt053.Statement, len(g.accounts))
+	for i, acc := range g.accounts {
+		statements[i] = g.statement(i, acc, created)
+	}
+	doc := camt053.Document{
+		Version:     g.config.Version,
+		MessageType: camt053.MESSAGE_TYPE_STATEMENT,
+		BankStatement: camt053.BankToCustomerStatement{
+			GroupHeader: camt053.GroupHeader{MessageId: messageId, CreationDateTime: created},
+			Statements:  statements,
+		},
+	}
+	doc.XMLName.Space, doc.XMLName.Local = camt053.Namespace(g.config.Version), "Document"
+
+	g.date = camt053.NewDate(g.date.Time().AddDate(0, 0, 1).Date())
+	return doc, true
+}
+
+// Generate generates the documents of every day in the date range of the config.
+func Generate(config Config) ([]camt053.Document, error) {
+	g, err := NewGenerator(config)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]camt053.Document, 0)
+	for doc, ok := g.Next(); ok; doc, ok = g.Next() {
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// statement generates the statement of an account for the current day. The closing balance is
+// the opening balance plus the credits and minus the debits, and becomes the next opening balance.
+func (g *Generator) statement(index int, acc *account, created camt053.DateTime) camt053.Statement {
+	day := g.date.Time()
+	id := fmt.Sprintf("STMT-%s-%04d", day.Format("20060102"), index+1)
+	sequence := g.sequence
+	currency := acc.currency
+	owner := acc.owner
+	bic := acc.bic
+
+	stmt := camt053.Statement{
+		Id:                       id,
+		ElectronicSequenceNumber: &sequence,
+		LegalSequenceNumber:      &sequence,
+		CreationDateTime:         created,
+		FromDate: &camt053.FromDate{
+			FromDateTime: camt053.NewDateTime(day),
+			ToDateTime:   camt053.NewDateTime(day.Add(24*time.Hour - time.Second)),
+		},
+		Account: camt053.Account{
+			Id:       acc.id,
+			Currency: &currency,
+			Owner:    &camt053.AccountOwner{Name: owner},
+			Servicer: &camt053.Servicer{FinancialInstitutionId: camt053.FinancialInstitutionId{BIC: &bic}},
+		},
+	}
+
+	opening := g.balance(acc, "OPBD")
+	entries := make([]camt053.Entry, 0)
+	count := 0
+	if g.config.TransactionsPerDay > 0 {
+		count = g.rand.IntN(2*g.config.TransactionsPerDay + 1)
+	}
+	for i := 0; i < count; i++ {
+		entry, ok := g.entry(acc, fmt.Sprintf("%s-%04d", id, len(entries)+1))
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	stmt.Balances = []camt053.Balance{opening, g.balance(acc, "CLBD")}
+	if len(entries) > 0 {
+		stmt.Entries = &entries
+		stmt.TransactionSummary = camt053.Summarize(entries)
+	}
+	return stmt
+}
+
+// balance returns the current balance of an account dated with the current day.
+func (g *Generator) balance(acc *account, code string) camt053.Balance {
+	date := g.date
+	value, indicator := acc.balance, "CRDT"
+	if value < 0 {
+		value, indicator = -value, "DBIT"
+	}
+	return camt053.Balance{
+		Type:                 camt053.BalanceType{CodeOrProprietary: camt053.CodeOrProprietary{Code: &code}},
+		Amount:               g.amount(acc.currency, value),
+		CreditDebitIndicator: indicator,
+		Date:                 camt053.DateAndDateTime{Date: &date},
+	}
+}
+
+// entry generates a booked entry of a random kind and books it on the account.
+// Debits that would overdraw the account are rejected by the bank and not generated.
+func (g *Generator) entry(acc *account, reference string) (camt053.Entry, bool) {
+	k := kinds[g.kind()]
+	units := pow10(camt053.MinorUnits(acc.currency))
+	value := g.between(k.min*units, k.max*units)
+	if k.indicator == "DBIT" {
+		if value > acc.balance {
+			return camt053.Entry{}, false
+		}
+		acc.balance -= value
+	} else {
+		acc.balance += value
+	}
+
+	date := g.date
+	servicerRef := fmt.Sprintf("GEN%020d", g.rand.Uint64N(1e18))
+	endToEndId := fmt.Sprintf("E2E%012d", g.rand.Uint64N(1e12))
+	amount := g.amount(acc.currency, value)
+	transaction := camt053.TransactionDetail{
+		References:            &camt053.TransactionReferences{EndToEndId: &endToEndId},
+		AmountDetails:         &camt053.AmountDetails{TransactionAmount: &camt053.AmountAndCurrencyExchangeDetails{Amount: amount}},
+		RemittanceInformation: g.remittance(k, amount),
+	}
+	return camt053.Entry{
+		Reference:            &reference,
+		Amount:               amount,
+		CreditDebitIndicator: k.indicator,
+		Status:               "BOOK",
+		BookingDate:          &camt053.DateAndDateTime{Date: &date},
+		ValueDate:            &camt053.DateAndDateTime{Date: &date},
+		AccountServicerRef:   &servicerRef,
+		BankTransactionCode: camt053.BankTransactionCode{
+			Domain: &camt053.BankTransactionCodeDomain{
+				Code:   k.domain,
+				Family: camt053.BankTransactionCodeFamily{Code: k.family, SubFamilyCode: k.subFamily},
+			},
+		},
+		EntryDetails: &[]camt053.EntryDetail{{TransactionDetails: &[]camt053.TransactionDetail{transaction}}},
+	}, true
+}
+
+// remittance generates the remittance information of an entry. Invoice payments refer to an invoice
+// number and a RF creditor reference when they are structured, the other kinds name the counterparty.
+func (g *Generator) remittance(k kind, amount camt053.Amount) *camt053.RemittanceInformation {
+	counterparty := g.counterparties[g.rand.IntN(len(g.counterparties))]
+	if !k.invoice {
+		text := k.text
+		if strings.Contains(text, "%s") {
+			text = fmt.Sprintf(text, counterparty)
+		}
+		return &camt053.RemittanceInformation{Unstructured: &[]string{text}}
+	}
+
+	invoice := strconv.Itoa(int(g.between(10000, 999999)))
+	if g.rand.Float64() >= g.config.StructuredShare {
+		return &camt053.RemittanceInformation{Unstructured: &[]string{fmt.Sprintf(k.text, invoice, counterparty)}}
+	}
+	invoiceType, referenceType, reference := "CINV", "SCOR", creditorReference(invoice)
+	return &camt053.RemittanceInformation{Structured: &[]camt053.StructuredRemittanceInformation{{
+		ReferredDocumentInformation: &camt053.ReferredDocumentInformation{
+			Type:   camt053.CodeOrProprietary{Code: &invoiceType},
+			Number: invoice,
+		},
+		ReferredDocumentAmount: &camt053.ReferredDocumentAmount{RemittedAmount: &amount},
+		CreditorReferenceInformation: &camt053.CreditorReferenceInformation{
+			Type:      &camt053.CodeOrProprietary{Code: &referenceType},
+			Reference: &reference,
+		},
+	}}}
+}
+
+// kind picks a transaction kind according to the weights of the mix.
+func (g *Generator) kind() string {
+	n := g.rand.IntN(g.totalWeight)
+	for _, name := range g.mix {
+		if n -= g.config.Mix[name]; n < 0 {
+			return name
+		}
+	}
+	return g.mix[len(g.mix)-1]
+}
+
+// amount creates an amount from a value in the minor unit of the currency.
+func (g *Generator) amount(currency string, value int64) camt053.Amount {
+	return camt053.Amount{Currency: currency, Value: camt053.NewDecimal(value, camt053.MinorUnits(currency))}
+}
+
+// between returns a random number from min to max, both included.
+func (g *Generator) between(min int64, max int64) int64 {
+	return min + g.rand.Int64N(max-min+1)
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
@@ -0,0 +1,190 @@
+// package generate produces synthetic camt053 statements for load testing and demos.
+package generate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig returns a config generating a week of statements for accounts in three currencies.
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Accounts = 3
+	config.Currencies = []string{"SEK", "EUR", "USD"}
+	config.From = camt053.NewDate(2024, time.February, 26)
+	config.To = camt053.NewDate(2024, time.March, 3)
+	config.StructuredShare = 0.5
+	return config
+}
+
+// TestGenerate checks that the generated documents are valid and that the balances of each account are consistent.
+func TestGenerate(t *testing.T) {
+	docs, err := Generate(testConfig())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, docs, 7)
+
+	references := make(map[string]bool)
+	closing := make(map[string]camt053.Decimal)
+	for day, doc := range docs {
+		assert.NoError(t, camt053.ValidateDocument(doc))
+
+		// The documents survive a round trip through XML
+		data, err := camt053.Marshal(doc)
+		if !assert.NoError(t, err) {
+			return
+		}
+		parsed, err := camt053.Parse(bytes.NewReader(data))
+		if assert.NoError(t, err) {
+			assert.Equal(t, doc, parsed)
+		}
+
+		stmts := doc.Statements()
+		assert.Len(t, stmts, 3)
+		for _, stmt := range stmts {
+			date := camt053.NewDate(2024, time.February, 26+day)
+			assert.Equal(t, "OPBD", stmt.Balances[0].TypeCode())
+			assert.Equal(t, "CLBD", stmt.Balances[1].TypeCode())
+			assert.Equal(t, date, stmt.Balances[1].GetDate())
+
+			// Opening balance is the closing balance of the previous day
+			opening := stmt.Balances[0].Amount.Signed(stmt.Balances[0].CreditDebitIndicator)
+			if previous, ok := closing[stmt.Account.GetId()]; ok {
+				assert.Equal(t, 0, previous.Cmp(opening), "opening balance of %s", stmt.Id)
+			}
+
+			// Closing balance is the opening balance plus the entries
+			balance := opening
+			if stmt.Entries != nil {
+				for _, entry := range *stmt.Entries {
+					assert.Equal(t, *stmt.Account.Currency, entry.Amount.Currency)
+					assert.Equal(t, date, entry.BookingDate.Day())
+					assert.False(t, references[*entry.Reference], "duplicate reference %s", *entry.Reference)
+					references[*entry.Reference] = true
+					balance = balance.Add(entry.Amount.Signed(entry.CreditDebitIndicator))
+				}
+				assert.Equal(t, len(*stmt.Entries), stmt.TransactionSummary.TotalEntries.NumberOfEntries)
+			}
+			closingBalance := stmt.Balances[1].Amount.Signed(stmt.Balances[1].CreditDebitIndicator)
+			assert.Equal(t, 0, balance.Cmp(closingBalance), "closing balance of %s", stmt.Id)
+			closing[stmt.Account.GetId()] = closingBalance
+		}
+	}
+	assert.NotEmpty(t, references)
+
+	// Accounts in currencies with an IBAN country get an IBAN, the others a BBAN
+	accounts := docs[0].Statements()
+	assert.Equal(t, camt053.SCHEME_IBAN, accounts[0].Account.Id.Scheme())
+	assert.Equal(t, camt053.SCHEME_IBAN, accounts[1].Account.Id.Scheme())
+	assert.Equal(t, camt053.SCHEME_BBAN, accounts[2].Account.Id.Scheme())
+}
+
+// TestGenerateReproducible checks that the same seed generates the same documents and another seed other documents.
+func TestGenerateReproducible(t *testing.T) {
+	marshal := func(seed uint64) []byte {
+		config := testConfig()
+		config.Seed = seed
+		docs, err := Generate(config)
+		if err != nil {
+			t.Fatalf("failed to generate: %s", err)
+		}
+		buffer := &bytes.Buffer{}
+		for _, doc := range docs {
+			if err := camt053.Write(buffer, doc); err != nil {
+				t.Fatalf("failed to write: %s", err)
+			}
+		}
+		return buffer.Bytes()
+	}
+
+	assert.Equal(t, marshal(42), marshal(42))
+	assert.NotEqual(t, marshal(42), marshal(43))
+}
+
+// TestGenerateMix checks that only the kinds of the mix are generated.
+func TestGenerateMix(t *testing.T) {
+	config := testConfig()
+	config.Mix = map[string]int{KIND_SALARY: 1}
+	docs, err := Generate(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, doc := range docs {
+		for _, stmt := range doc.Statements() {
+			if stmt.Entries == nil {
+				continue
+			}
+			for _, entry := range *stmt.Entries {
+				assert.Equal(t, "CRDT", entry.CreditDebitIndicator)
+				assert.Equal(t, "SALA", entry.BankTransactionCode.Domain.Family.SubFamilyCode)
+			}
+		}
+	}
+}
+
+// TestParseMix checks that weights are parsed and that unknown kinds are rejected.
+func TestParseMix(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		raw           string
+		expected      map[string]int
+		expectedError bool
+	}{
+		{"Single kind", "card=10", map[string]int{KIND_CARD: 10}, false},
+		{"Several kinds", "card=10, fee=1,salary=0", map[string]int{KIND_CARD: 10, KIND_FEE: 1, KIND_SALARY: 0}, false},
+		{"Unknown kind", "card=10,loan=1", nil, true},
+		{"Missing weight", "card", nil, true},
+		{"Negative weight", "card=-1", nil, true},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mix, err := ParseMix(test.raw)
+			assert.Equal(t, test.expectedError, err != nil, err)
+			assert.Equal(t, test.expected, mix)
+		})
+	}
+}
+
+// TestConfigErrors checks that configs that can not generate statements are rejected.
+func TestConfigErrors(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name   string
+		modify func(config *Config)
+	}{
+		{"No accounts", func(config *Config) { config.Accounts = 0 }},
+		{"To before from", func(config *Config) { config.To = camt053.NewDate(2024, time.February, 1) }},
+		{"Missing date", func(config *Config) { config.From = camt053.Date{} }},
+		{"No currencies", func(config *Config) { config.Currencies = nil }},
+		{"Invalid currency", func(config *Config) { config.Currencies = []string{"kronor"} }},
+		{"Empty mix", func(config *Config) { config.Mix = map[string]int{KIND_CARD: 0} }},
+		{"Structured share above 1", func(config *Config) { config.StructuredShare = 1.5 }},
+		{"Unsupported version", func(config *Config) { config.Version = "001.01" }},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig()
+			test.modify(&config)
+			_, err := NewGenerator(config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestCreditorReference checks the check digits of RF creditor references.
+func TestCreditorReference(t *testing.T) {
+	assert.Equal(t, "RF18539007547034", creditorReference("539007547034"))
+	assert.Equal(t, 1, mod97("539007547034RF18"))
+}
@@ -0,0 +1,84 @@
+// package generate produces synthetic camt053 statements for load testing and demos.
+package generate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Country and length of the numeric BBAN of the IBANs of accounts in each currency.
+// Accounts in other currencies are identified by a BBAN without an IBAN.
+var ibanCountries = map[string]struct {
+	country string
+	length  int
+}{
+	"SEK": {"SE", 20},
+	"EUR": {"DE", 18},
+	"NOK": {"NO", 11},
+	"DKK": {"DK", 14},
+}
+
+// Length of the BBAN of accounts without an IBAN.
+const BBAN_LENGTH = 12
+
+// Parts of the generated company names.
+var namePrefixes = []string{"Nordic", "Baltic", "Alpine", "Coastal", "Northern", "Golden", "Silver", "Green", "Royal", "United"}
+var nameNouns = []string{"Trading", "Logistics", "Foods", "Energy", "Textiles", "Software", "Timber", "Motors", "Pharma", "Retail"}
+var nameSuffixes = []string{"AB", "AS", "GmbH", "Ltd", "Oy", "ApS"}
+
+// accountId generates an account id, an IBAN if the currency has an IBAN country, otherwise a BBAN.
+func (g *Generator) accountId(currency string) camt053.AccountId {
+	country, ok := ibanCountries[currency]
+	if !ok {
+		return camt053.AccountId{Other: &camt053.OtherId{Id: g.digits(BBAN_LENGTH), SchemeName: camt053.SCHEME_BBAN}}
+	}
+	bban := g.digits(country.length)
+	iban := fmt.Sprintf("%s%02d%s", country.country, 98-mod97(bban+country.country+"00"), bban)
+	return camt053.AccountId{IBAN: &iban}
+}
+
+// companyName generates a company name, e.g. "Nordic Timber AB".
+func (g *Generator) companyName() string {
+	return strings.Join([]string{
+		namePrefixes[g.rand.IntN(len(namePrefixes))],
+		nameNouns[g.rand.IntN(len(nameNouns))],
+		nameSuffixes[g.rand.IntN(len(nameSuffixes))],
+	}, " ")
+}
+
+// digits generates a string of random digits.
+func (g *Generator) digits(n int) string {
+	digits := make([]byte, n)
+	for i := range digits {
+		digits[i] = byte('0' + g.rand.IntN(10))
+	}
+	return string(digits)
+}
+
+// bic returns the BIC of the generated bank in the country of the currency.
+func bic(currency string) string {
+	if country, ok := ibanCountries[currency]; ok {
+		return "GENB" + country.country + "22"
+	}
+	return "GENBUS33"
+}
+
+// creditorReference creates a ISO 11649 RF creditor reference, e.g. RF18539007547034.
+func creditorReference(reference string) string {
+	return fmt.Sprintf("RF%02d%s", 98-mod97(reference+"RF00"), reference)
+}
+
+// mod97 returns the ISO 7064 mod 97-10 remainder of an alphanumeric string, letters are converted to numbers (A = 10 ... Z = 35).
+func mod97(s string) int {
+	remainder := 0
+	for _, char := range s {
+		if char >= 'A' {
+			remainder = (remainder*100 + int(char-'A'+10)) % 97
+		} else {
+			remainder = (remainder*10 + int(char-'0')) % 97
+		}
+	}
+	return remainder
+}
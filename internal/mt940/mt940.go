@@ -0,0 +1,496 @@
+// package mt940 parses SWIFT MT940 statements and MT942 interim transaction reports into the camt053 model.
+package mt940
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+)
+
+// Message types that can be parsed.
+const MESSAGE_TYPE_STATEMENT = "MT940" // End of day customer statement
+const MESSAGE_TYPE_REPORT = "MT942"    // Interim transaction report
+
+// Issuer of the bank transaction codes of entries, the transaction type identification code of :61:.
+const TRANSACTION_CODE_ISSUER = "SWIFT"
+
+// Balance types of the balance fields.
+var balanceTypes = map[string]string{
+	"60F": "OPBD", "60M": "OPBD", // Opening balance, final and intermediate
+	"62F": "CLBD", "62M": "CLBD", // Closing balance, final and intermediate
+	"64": "CLAV", // Closing available balance
+	"65": "FWAV", // Forward available balance
+}
+
+// Field tags at the start of a line, e.g. ":61:" or ":28C:".
+var tagPattern = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+// :60F:, :62F:, :64: and :65: balances, e.g. C181217SEK3865371,31.
+var balancePattern = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+
+// :61: statement lines, e.g. 1812171217D242041,NTRFLBE5419-0186//STOI520111188400029234.
+// Value date, optional entry date, debit credit mark, optional funds code, amount, transaction type and
+// the reference for the account owner, optionally followed by // and the account servicer reference.
+var statementLinePattern = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})(.*)$`)
+
+// :34F: floor limit indicators, e.g. SEKD0,.
+var floorLimitPattern = regexp.MustCompile(`^([A-Z]{3})[DC]?\d+,\d*$`)
+
+// :90D: and :90C: number and sum of entries in MT942 reports, e.g. 3SEK1250,00.
+var entrySumPattern = regexp.MustCompile(`^(\d+)([A-Z]{3})(\d+,\d*)$`)
+
+// ParseError is returned when a MT940 message can not be parsed. It points at the offending field.
+type ParseError struct {
+	Line  int    // Line of the offending field, 1 based
+	Field string // Tag of the offending field, e.g. 61
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf(":%s: (line %d): %s", e.Field, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// field is a tagged field of a message, continuation lines are joined with newlines.
+type field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// message is a single MT940 or MT942 message.
+type message struct {
+	fields []field
+	line   int // Line the message starts on
+}
+
+// IsMT940 sniffs the start of a document to check if it is a SWIFT MT940 or MT942 message rather than XML.
+func IsMT940(data []byte) bool {
+	text := strings.TrimSpace(string(data))
+	return strings.HasPrefix(text, "{1:") || strings.HasPrefix(text, ":20:")
+}
+
+// Parse reads MT940 statements or MT942 reports and converts them into a camt053 document.
+// MT940 statements become camt.053 statements and MT942 reports become camt.052 intraday reports.
+// A file can contain several messages, but they all need to be of the same type.
+func Parse(r io.Reader) (camt053.Document, error) {
+	messages, err := split(r)
+	if err != nil {
+		return camt053.Document{}, err
+	}
+	if len(messages) == 0 {
+		return camt053.Document{}, &ParseError{Line: 1, Err: errors.New("document does not contain any messages")}
+	}
+
+	statements := make([]camt053.Statement, 0, len(messages))
+	messageType := ""
+	for _, msg := range messages {
+		stmt, stmtType, err := msg.statement()
+		if err != nil {
+			return camt053.Document{}, err
+		}
+		if messageType != "" && stmtType != messageType {
+			return camt053.Document{}, &ParseError{Line: msg.line, Err: errors.New("MT940 and MT942 messages can not be mixed")}
+		}
+		messageType = stmtType
+		statements = append(statements, stmt)
+	}
+
+	doc := camt053.Document{Version: camt053.DEFAULT_VERSION}
+	header := camt053.GroupHeader{CreationDateTime: statements[0].CreationDateTime}
+	if messageType == MESSAGE_TYPE_REPORT {
+		doc.MessageType = camt053.MESSAGE_TYPE_REPORT
+		doc.AccountReport = &camt053.BankToCustomerAccountReport{GroupHeader: header, Reports: statements}
+	} else {
+		doc.MessageType = camt053.MESSAGE_TYPE_STATEMENT
+		doc.BankStatement = camt053.BankToCustomerStatement{GroupHeader: header, Statements: statements}
+	}
+	return doc, nil
+}
+
+// split reads the fields of every message. Messages are either wrapped in SWIFT blocks, {1:...}{2:...}{4:...-},
+// or separated by a line containing only '-'.
+func split(r io.Reader) ([]message, error) {
+	messages := make([]message, 0)
+	var current *message
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \r")
+
+		// Text block of a SWIFT message, header blocks before it are ignored
+		if index := strings.Index(line, "{4:"); index >= 0 {
+			line = line[index+len("{4:"):]
+		}
+		if strings.HasPrefix(line, "{") {
+			continue
+		}
+		if line == "-" || strings.HasPrefix(line, "-}") {
+			current = nil
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if match := tagPattern.FindStringSubmatch(line); match != nil {
+			if current == nil || (match[1] == "20" && len(current.fields) > 0) {
+				messages = append(messages, message{line: lineNumber})
+				current = &messages[len(messages)-1]
+			}
+			current.fields = append(current.fields, field{tag: match[1], value: line[len(match[0]):], line: lineNumber})
+			continue
+		}
+		if current == nil || len(current.fields) == 0 {
+			return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("'%s' is not a field", line)}
+		}
+		last := &current.fields[len(current.fields)-1]
+		last.value += "\n" + line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// statementId joins the transaction reference and statement number, e.g. STMT181217 and 00244/001, into an id
+// that can be used in a URL, e.g. STMT181217-00244-001. Slashes become dashes and other characters that are
+// not letters, digits, '-', '.' or '_' are removed.
+func statementId(reference string, number string) string {
+	id := reference
+	if number != "" {
+		id += "-" + number
+	}
+	return strings.Map(func(char rune) rune {
+		switch {
+		case char == '/':
+			return '-'
+		case (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9'):
+			return char
+		case strings.ContainsRune("-._", char):
+			return char
+		}
+		return -1
+	}, id)
+}
+
+// statement converts a message into a statement and returns its message type.
+func (msg message) statement() (camt053.Statement, string, error) {
+	stmt := camt053.Statement{}
+	messageType := MESSAGE_TYPE_STATEMENT
+	reference, number := "", ""
+	currency := ""
+	var creationDateTime *camt053.DateTime
+	var lastEntry *camt053.Entry
+	entries := make([]camt053.Entry, 0)
+
+	for _, f := range msg.fields {
+		fieldErr := func(err error) (camt053.Statement, string, error) {
+			return stmt, messageType, &ParseError{Line: f.line, Field: f.tag, Err: err}
+		}
+
+		switch f.tag {
+		case "20":
+			reference = strings.TrimSpace(f.value)
+		case "25", "25P":
+			stmt.Account = account(f.value)
+		case "28C", "28":
+			number = strings.TrimSpace(f.value)
+			if sequence, ok := statementNumber(number); ok {
+				stmt.ElectronicSequenceNumber, stmt.LegalSequenceNumber = &sequence, &sequence
+			}
+		case "13D":
+			messageType = MESSAGE_TYPE_REPORT
+			dateTime, err := parseDateTimeIndication(f.value)
+			if err != nil {
+				return fieldErr(err)
+			}
+			creationDateTime = &dateTime
+		case "34F":
+			// Floor limit indicator, e.g. SEKD0,. MT942 reports have no balances so the currency is taken from it
+			messageType = MESSAGE_TYPE_REPORT
+			if match := floorLimitPattern.FindStringSubmatch(strings.TrimSpace(f.value)); match != nil && currency == "" {
+				currency = match[1]
+			} else if match == nil {
+				return fieldErr(errors.New("'" + f.value + "' is not a floor limit indicator (e.g. SEKD0,)"))
+			}
+		case "60F", "60M", "62F", "62M", "64", "65":
+			balance, err := parseBalance(f.value, balanceTypes[f.tag])
+			if err != nil {
+				return fieldErr(err)
+			}
+			currency = balance.Amount.Currency
+			stmt.Balances = append(stmt.Balances, balance)
+		case "61":
+			if currency == "" {
+				currency = accountCurrency(stmt.Account)
+			}
+			entry, err := parseStatementLine(f.value, currency)
+			if err != nil {
+				return fieldErr(err)
+			}
+			entries = append(entries, entry)
+			lastEntry = &entries[len(entries)-1]
+		case "86":
+			// Information that does not follow a statement line belongs to the statement and is ignored
+			if lastEntry != nil {
+				addInformation(lastEntry, f.value)
+			}
+		case "90D", "90C":
+			messageType = MESSAGE_TYPE_REPORT
+			summary, err := parseEntrySum(f.value)
+			if err != nil {
+				return fieldErr(err)
+			}
+			if stmt.TransactionSummary == nil {
+				stmt.TransactionSummary = &camt053.TransactionSummary{}
+			}
+			if f.tag == "90D" {
+				stmt.TransactionSummary.TotalDebitEntries = &summary
+			} else {
+				stmt.TransactionSummary.TotalCreditEntries = &summary
+			}
+		}
+		if f.tag != "61" {
+			lastEntry = nil
+		}
+	}
+
+	if reference == "" {
+		return stmt, messageType, &ParseError{Line: msg.line, Field: "20", Err: errors.New("transaction reference is missing")}
+	}
+	if stmt.Account.GetId() == "" {
+		return stmt, messageType, &ParseError{Line: msg.line, Field: "25", Err: errors.New("account identification is missing")}
+	}
+
+	// Statements are identified by the transaction reference and the statement number, the reference is often reused
+	stmt.Id = statementId(reference, number)
+	if len(entries) > 0 {
+		stmt.Entries = &entries
+	}
+	if currency != "" && stmt.Account.Currency == nil {
+		stmt.Account.Currency = &currency
+	}
+
+	// MT940 statements have no creation date time, the date of the closing balance is used
+	if creationDateTime == nil {
+		dateTime := statementDateTime(stmt)
+		creationDateTime = &dateTime
+	}
+	stmt.CreationDateTime = *creationDateTime
+
+	return stmt, messageType, nil
+}
+
+// account converts a :25: account identification, e.g. "SE4550000000058398257466" or "ESSESESS/54400001111".
+// The account number is used if the identification contains a bank identifier.
+func account(value string) camt053.Account {
+	id := strings.TrimSpace(strings.SplitN(value, "\n", 2)[0])
+	if index := strings.LastIndex(id, "/"); index >= 0 {
+		id = id[index+1:]
+	}
+	if camt053.IsValidIBAN(id) {
+		return camt053.Account{Id: camt053.AccountId{IBAN: &id}}
+	}
+	return camt053.Account{Id: camt053.AccountId{Other: &camt053.OtherId{Id: id}}}
+}
+
+// accountCurrency returns the currency of the account, or an empty string if it is unknown.
+func accountCurrency(acc camt053.Account) string {
+	if acc.Currency == nil {
+		return ""
+	}
+	return *acc.Currency
+}
+
+// statementNumber parses the statement number of a :28C: statement number and sequence number, e.g. 00244/001.
+func statementNumber(value string) (int, bool) {
+	number := strings.SplitN(value, "/", 2)[0]
+	var sequence int
+	if _, err := fmt.Sscanf(number, "%d", &sequence); err != nil {
+		return 0, false
+	}
+	return sequence, true
+}
+
+// parseBalance parses a balance field, e.g. C181217SEK3865371,31.
+func parseBalance(value string, balanceType string) (camt053.Balance, error) {
+	match := balancePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return camt053.Balance{}, errors.New("'" + value + "' is not a balance (e.g. C181217SEK3865371,31)")
+	}
+	date, err := parseDate(match[2])
+	if err != nil {
+		return camt053.Balance{}, err
+	}
+	amount, err := parseAmount(match[3], match[4])
+	if err != nil {
+		return camt053.Balance{}, err
+	}
+	return camt053.Balance{
+		Type:                 camt053.BalanceType{CodeOrProprietary: camt053.CodeOrProprietary{Code: &balanceType}},
+		Amount:               amount,
+		CreditDebitIndicator: creditDebitIndicator(match[1]),
+		Date:                 camt053.DateAndDateTime{Date: &date},
+	}, nil
+}
+
+// parseStatementLine parses a :61: statement line and its supplementary details into an entry.
+func parseStatementLine(value string, currency string) (camt053.Entry, error) {
+	lines := strings.SplitN(value, "\n", 2)
+	match := statementLinePattern.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return camt053.Entry{}, errors.New("'" + lines[0] + "' is not a statement line")
+	}
+	if currency == "" {
+		return camt053.Entry{}, errors.New("currency is unknown, the statement line has to come after the opening balance")
+	}
+
+	valueDate, err := parseDate(match[1])
+	if err != nil {
+		return camt053.Entry{}, err
+	}
+	bookingDate := valueDate
+	if match[2] != "" {
+		if bookingDate, err = entryDate(valueDate, match[2]); err != nil {
+			return camt053.Entry{}, err
+		}
+	}
+	amount, err := parseAmount(currency, match[5])
+	if err != nil {
+		return camt053.Entry{}, err
+	}
+
+	code, issuer := match[6], TRANSACTION_CODE_ISSUER
+	entry := camt053.Entry{
+		Amount:               amount,
+		CreditDebitIndicator: creditDebitIndicator(match[3]),
+		Status:               "BOOK",
+		BookingDate:          &camt053.DateAndDateTime{Date: &bookingDate},
+		ValueDate:            &camt053.DateAndDateTime{Date: &valueDate},
+		BankTransactionCode: camt053.BankTransactionCode{
+			ProprietaryCode: &camt053.BankTransactionProprietaryCode{Code: code, Issuer: issuer},
+		},
+	}
+	ownerRef, servicerRef, _ := strings.Cut(match[7], "//")
+	if ownerRef = strings.TrimSpace(ownerRef); ownerRef != "" && ownerRef != "NONREF" {
+		entry.Reference = &ownerRef
+	}
+	if servicerRef = strings.TrimSpace(servicerRef); servicerRef != "" {
+		entry.AccountServicerRef = &servicerRef
+	}
+	if len(lines) > 1 {
+		if details := strings.TrimSpace(lines[1]); details != "" {
+			entry.AdditionalInformation = &details // Supplementary details
+		}
+	}
+
+	return entry, nil
+}
+
+// addInformation adds :86: information to account owner as unstructured remittance information, one line each.
+func addInformation(entry *camt053.Entry, value string) {
+	unstructured := make([]string, 0)
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			unstructured = append(unstructured, line)
+		}
+	}
+	entry.EntryDetails = &[]camt053.EntryDetail{{TransactionDetails: &[]camt053.TransactionDetail{{
+		RemittanceInformation: &camt053.RemittanceInformation{Unstructured: &unstructured},
+	}}}}
+}
+
+// parseEntrySum parses a :90D: or :90C: number and sum of entries, e.g. 3SEK1250,00.
+func parseEntrySum(value string) (camt053.CreditDebitEntry, error) {
+	match := entrySumPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return camt053.CreditDebitEntry{}, errors.New("'" + value + "' is not a number and sum of entries (e.g. 3SEK1250,00)")
+	}
+	var count int
+	fmt.Sscanf(match[1], "%d", &count)
+	amount, err := parseAmount(match[2], match[3])
+	if err != nil {
+		return camt053.CreditDebitEntry{}, err
+	}
+	return camt053.CreditDebitEntry{NumberOfEntries: count, Sum: &amount.Value}, nil
+}
+
+// parseAmount parses an amount with a decimal comma, e.g. 3865371,31 or 100,.
+func parseAmount(currency string, value string) (camt053.Amount, error) {
+	value = strings.TrimSuffix(strings.Replace(value, ",", ".", 1), ".")
+	decimal, err := camt053.ParseDecimal(value)
+	if err != nil {
+		return camt053.Amount{}, err
+	}
+	return camt053.NewAmount(currency, decimal)
+}
+
+// parseDate parses a YYMMDD date.
+func parseDate(value string) (camt053.Date, error) {
+	t, err := time.Parse("060102", value)
+	if err != nil {
+		return camt053.Date{}, errors.New("'" + value + "' is not a date (YYMMDD)")
+	}
+	return camt053.NewDate(t.Date()), nil
+}
+
+// entryDate parses the MMDD entry date of a statement line. The year is taken from the value date,
+// entries booked in December with a value date in January belong to the previous year and vice versa.
+func entryDate(valueDate camt053.Date, value string) (camt053.Date, error) {
+	t, err := time.Parse("0102", value)
+	if err != nil {
+		return camt053.Date{}, errors.New("'" + value + "' is not an entry date (MMDD)")
+	}
+	year := valueDate.Time().Year()
+	switch {
+	case t.Month() == time.December && valueDate.Time().Month() == time.January:
+		year--
+	case t.Month() == time.January && valueDate.Time().Month() == time.December:
+		year++
+	}
+	return camt053.NewDate(year, t.Month(), t.Day()), nil
+}
+
+// parseDateTimeIndication parses a :13D: date time indication, e.g. 1812171030+0100.
+func parseDateTimeIndication(value string) (camt053.DateTime, error) {
+	t, err := time.Parse("0601021504-0700", strings.TrimSpace(value))
+	if err != nil {
+		return camt053.DateTime{}, errors.New("'" + value + "' is not a date time indication (YYMMDDhhmm+hhmm)")
+	}
+	return camt053.NewDateTime(t), nil
+}
+
+// statementDateTime returns midnight UTC of the date of the closing balance, or of the last balance.
+func statementDateTime(stmt camt053.Statement) camt053.DateTime {
+	var date camt053.Date
+	for _, balance := range stmt.Balances {
+		if balance.TypeCode() == "CLBD" {
+			return camt053.NewDateTime(balance.GetDate().Time())
+		}
+		date = balance.GetDate()
+	}
+	return camt053.NewDateTime(date.Time())
+}
+
+// creditDebitIndicator converts a debit credit mark. Reversals of credits are debits and reversals of debits are credits.
+func creditDebitIndicator(mark string) string {
+	if mark == "C" || mark == "RD" {
+		return "CRDT"
+	}
+	return "DBIT"
+}
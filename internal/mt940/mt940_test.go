@@ -0,0 +1,200 @@
+// package mt940 parses SWIFT MT940 statements and MT942 interim transaction reports into the camt053 model.
+package mt940
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/justfredrik/bank-api/internal/camt053"
+	"github.com/stretchr/testify/assert"
+)
+
+// statementMT940 is a MT940 statement wrapped in SWIFT blocks with two statement lines.
+const statementMT940 = `{1:F01ESSESESSAXXX0000000000}{2:O9401200181217ESSESESSAXXX00000000001812171200N}{4:
+:20:STMT181217
+:25:ESSESESS/SE4550000000058398257466
+:28C:00244/001
+:60F:C181216SEK3865371,31
+:61:1812171217D242041,NTRFLBE5419-0186//STOI520111188400029234
+:86:Invoice 5419
+Supplier AB
+:61:1812171217C1000,NMSCNONREF//STOI520111188400029235
+Deposit
+:62F:C181217SEK3624330,31
+:64:C181217SEK3624330,31
+-}`
+
+// reportMT942 is a MT942 interim transaction report with a single statement line.
+const reportMT942 = `:20:RPT181217
+:25:SE4550000000058398257466
+:28C:00245/001
+:34F:SEK0,
+:13D:1812171030+0100
+:61:181217C500,NTRFREF-1//SVC-1
+:90D:0SEK0,
+:90C:1SEK500,
+-`
+
+// TestParseStatement checks that MT940 fields are mapped onto a camt053 statement.
+func TestParseStatement(t *testing.T) {
+	doc, err := Parse(strings.NewReader(statementMT940))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, camt053.MESSAGE_TYPE_STATEMENT, doc.MessageType)
+	assert.False(t, doc.IsIntraday())
+	assert.NoError(t, camt053.ValidateDocument(doc))
+
+	stmts := doc.Statements()
+	assert.Len(t, stmts, 1)
+	stmt := stmts[0]
+	assert.Equal(t, "STMT181217-00244-001", stmt.Id)
+	assert.Equal(t, 244, *stmt.LegalSequenceNumber)
+	assert.Equal(t, "SE4550000000058398257466", stmt.Account.GetId())
+	assert.Equal(t, "SEK", *stmt.Account.Currency)
+	assert.Equal(t, "2018-12-17T00:00:00Z", stmt.CreationDateTime.Time().Format("2006-01-02T15:04:05Z07:00"))
+
+	balanceTypes := []string{}
+	for _, balance := range stmt.Balances {
+		balanceTypes = append(balanceTypes, balance.TypeCode())
+	}
+	assert.Equal(t, []string{"OPBD", "CLBD", "CLAV"}, balanceTypes)
+	assert.Equal(t, "3865371.31", stmt.Balances[0].Amount.Value.String())
+
+	entries := *stmt.Entries
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "DBIT", entries[0].CreditDebitIndicator)
+	assert.Equal(t, "242041.00", entries[0].Amount.Value.String())
+	assert.Equal(t, "SEK", entries[0].Amount.Currency)
+	assert.Equal(t, "LBE5419-0186", *entries[0].Reference)
+	assert.Equal(t, "STOI520111188400029234", *entries[0].AccountServicerRef)
+	assert.Equal(t, "NTRF", entries[0].BankTransactionCode.ProprietaryCode.Code)
+	assert.Equal(t, TRANSACTION_CODE_ISSUER, entries[0].BankTransactionCode.ProprietaryCode.Issuer)
+	assert.Equal(t, []string{"Invoice 5419", "Supplier AB"},
+		*(*(*entries[0].EntryDetails)[0].TransactionDetails)[0].RemittanceInformation.Unstructured)
+
+	assert.Equal(t, "CRDT", entries[1].CreditDebitIndicator)
+	assert.Nil(t, entries[1].Reference) // NONREF
+	assert.Equal(t, "Deposit", *entries[1].AdditionalInformation)
+	assert.Nil(t, entries[1].EntryDetails)
+}
+
+// TestStatementId checks that statement ids can be used in a URL.
+func TestStatementId(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		reference  string
+		number     string
+		expectedId string
+	}{
+		{"STMT181217", "00244/001", "STMT181217-00244-001"},
+		{"STMT181217", "244", "STMT181217-244"},
+		{"STMT181217", "", "STMT181217"},
+		{"REF/2018/12", "1", "REF-2018-12-1"},
+		{"REF (1) ?:+", "1", "REF1-1"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.expectedId, func(t *testing.T) {
+			assert.Equal(t, test.expectedId, statementId(test.reference, test.number))
+		})
+	}
+}
+
+// TestParseReport checks that MT942 reports become camt052 intraday reports.
+func TestParseReport(t *testing.T) {
+	doc, err := Parse(strings.NewReader(reportMT942))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, camt053.MESSAGE_TYPE_REPORT, doc.MessageType)
+	assert.True(t, doc.IsIntraday())
+	assert.NoError(t, camt053.ValidateDocument(doc))
+
+	stmt := doc.Statements()[0]
+	assert.Equal(t, "2018-12-17T09:30:00Z", stmt.CreationDateTime.Time().UTC().Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, 1, stmt.TransactionSummary.TotalCreditEntries.NumberOfEntries)
+	assert.Equal(t, "500.00", stmt.TransactionSummary.TotalCreditEntries.Sum.String())
+	assert.Equal(t, "SVC-1", *(*stmt.Entries)[0].AccountServicerRef)
+}
+
+// TestParseStatementLine checks debit credit marks, reversals and entry dates of statement lines.
+func TestParseStatementLine(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name                 string
+		line                 string
+		creditDebitIndicator string
+		bookingDate          string
+		valueDate            string
+	}{
+		{"Debit", "1812171217D10,NTRFREF", "DBIT", "2018-12-17", "2018-12-17"},
+		{"Credit without entry date", "181217C10,50NTRFREF", "CRDT", "2018-12-17", "2018-12-17"},
+		{"Reversal of credit", "1812171217RC10,NTRFREF", "DBIT", "2018-12-17", "2018-12-17"},
+		{"Reversal of debit", "1812171217RD10,NTRFREF", "CRDT", "2018-12-17", "2018-12-17"},
+		{"Funds code", "1812171217DR10,NTRFREF", "DBIT", "2018-12-17", "2018-12-17"},
+		{"Booked in previous year", "1901021231D10,NTRFREF", "DBIT", "2018-12-31", "2019-01-02"},
+		{"Booked in next year", "1812310102D10,NTRFREF", "DBIT", "2019-01-02", "2018-12-31"},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry, err := parseStatementLine(test.line, "SEK")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.creditDebitIndicator, entry.CreditDebitIndicator)
+			assert.Equal(t, test.bookingDate, entry.BookingDate.Date.String())
+			assert.Equal(t, test.valueDate, entry.ValueDate.Date.String())
+			assert.Equal(t, "REF", *entry.Reference)
+		})
+	}
+}
+
+// TestParseErrors checks that errors point at the offending field and line.
+func TestParseErrors(t *testing.T) {
+
+	// Declare Tests
+	tests := []struct {
+		name          string
+		input         string
+		expectedField string
+		expectedLine  int
+	}{
+		{"Empty document", "", "", 1},
+		{"Invalid balance", ":20:REF\n:25:SE4550000000058398257466\n:60F:C181216SEK\n-", "60F", 3},
+		{"Invalid statement line", ":20:REF\n:25:SE4550000000058398257466\n:60F:C181216SEK1,00\n:61:1812171217X10,NTRF\n-", "61", 4},
+		{"Statement line before balance", ":20:REF\n:25:SE4550000000058398257466\n:61:1812171217D10,NTRFREF\n-", "61", 3},
+		{"Invalid date time indication", ":20:REF\n:25:SE4550000000058398257466\n:13D:18121710\n-", "13D", 3},
+		{"Missing account", ":20:REF\n:60F:C181216SEK1,00\n-", "25", 1},
+		{"Text before first field", "REF\n:20:REF\n-", "", 1},
+		{"Invalid floor limit", ":20:REF\n:25:SE4550000000058398257466\n:34F:0,\n-", "34F", 3},
+		{"Mixed message types", ":20:A\n:25:SE4550000000058398257466\n:60F:C181216SEK1,00\n-\n:20:B\n:25:SE4550000000058398257466\n:13D:1812171030+0100\n-", "", 5},
+	}
+
+	// Run Tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input))
+			var parseErr *ParseError
+			if !assert.True(t, errors.As(err, &parseErr), "expected a ParseError, got %v", err) {
+				return
+			}
+			assert.Equal(t, test.expectedField, parseErr.Field)
+			assert.Equal(t, test.expectedLine, parseErr.Line)
+		})
+	}
+}
+
+// TestIsMT940 checks that MT940 messages are told apart from XML documents.
+func TestIsMT940(t *testing.T) {
+	assert.True(t, IsMT940([]byte(statementMT940)))
+	assert.True(t, IsMT940([]byte("\r\n"+reportMT942)))
+	assert.False(t, IsMT940([]byte(`<?xml version="1.0"?><Document/>`)))
+	assert.False(t, IsMT940([]byte("")))
+}